@@ -0,0 +1,301 @@
+/*
+analytics.go contains the rollups of the points and spend of scored receipts, kept
+up to date as receipts are scored, and methods for handling analytics requests
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Analytics groupings
+const (
+	// GroupByRetailer groups receipts by retailer, ignoring case, spaces and punctuation
+	GroupByRetailer = "retailer"
+	// GroupByDay groups receipts by purchase date, e.g. 2022-01-02
+	GroupByDay = "day"
+	// GroupByWeek groups receipts by the ISO week of their purchase date, e.g. 2021-W52
+	GroupByWeek = "week"
+	// GroupByMonth groups receipts by the month of their purchase date, e.g. 2022-01
+	GroupByMonth = "month"
+	// GroupByRule groups receipts by the rules awarding them points
+	GroupByRule = "rule"
+)
+
+// rollup totals the scored receipts of a group
+type rollup struct {
+	// receipts is the number of receipts
+	receipts int
+	// earning is the number of receipts earning points
+	earning int
+	// points is the points earned
+	points int64
+	// spend is the sum of the totals, in hundredths of the base currency
+	spend int64
+	// earningSpend is the spend of the receipts earning points
+	earningSpend int64
+}
+
+// add adds another rollup to the rollup
+// other: the rollup to add
+// sign: 1 to add, -1 to subtract
+func (r *rollup) add(other rollup, sign int) {
+	r.receipts += sign * other.receipts
+	r.earning += sign * other.earning
+	r.points += int64(sign) * other.points
+	r.spend += int64(sign) * other.spend
+	r.earningSpend += int64(sign) * other.earningSpend
+}
+
+// receiptRollup is the rollup of one receipt
+// spend: the total of the receipt, in hundredths of the base currency
+// points: the points the receipt earned
+func receiptRollup(spend int64, points int) rollup {
+	rolled := rollup{receipts: 1, points: int64(points), spend: spend}
+	if points > 0 {
+		rolled.earning = 1
+		rolled.earningSpend = spend
+	}
+	return rolled
+}
+
+// dayRollups are the rollups of the receipts purchased on one day
+type dayRollups struct {
+	// total is the rollup of every receipt
+	total rollup
+	// retailers is a map of normalized retailer->rollup
+	retailers map[string]*rollup
+	// rules is a map of rule name->rollup of the receipts the rule awarded points,
+	// with the points of each receipt split among its rules by attributePoints
+	rules map[string]*rollup
+}
+
+// add adds or subtracts the Score of a receipt from the rollups
+// retailer: the normalized retailer of the receipt
+// spend: the total of the receipt, in hundredths of the base currency
+// score: the Score of the receipt
+// sign: 1 to add, -1 to subtract
+func (d *dayRollups) add(retailer string, spend int64, score Score, sign int) {
+	d.total.add(receiptRollup(spend, score.Breakdown.Points), sign)
+	addRollup(d.retailers, retailer, receiptRollup(spend, score.Breakdown.Points), sign)
+	for rule, points := range attributePoints(score.Breakdown) {
+		addRollup(d.rules, rule, rollup{receipts: 1, earning: 1, points: int64(points), spend: spend, earningSpend: spend}, sign)
+	}
+}
+
+// attributePoints splits the points a receipt earned, after caps, tier multipliers
+// and review decisions, among the rules awarding it points in proportion to their
+// awards, by largest remainder, so the rule groups add up to the points earned
+// breakdown: the Breakdown of the receipt
+// Returns: a map of rule name->points, empty when the receipt earned no points
+func attributePoints(breakdown Breakdown) map[string]int {
+	awarded := int64(0)
+	for _, result := range breakdown.Rules {
+		awarded += int64(max(result.Points, 0))
+	}
+	attributed := map[string]int{}
+	if awarded == 0 || breakdown.Points <= 0 {
+		return attributed
+	}
+	earned := int64(breakdown.Points)
+	remaining := earned
+	// remainders are in units of 1/awarded points, the largest rounded up
+	type remainder struct {
+		rule  string
+		value int64
+	}
+	remainders := []remainder{}
+	for _, result := range breakdown.Rules {
+		if result.Points <= 0 {
+			continue
+		}
+		share := int64(result.Points) * earned / awarded
+		attributed[result.Rule] = int(share)
+		remaining -= share
+		remainders = append(remainders, remainder{result.Rule, int64(result.Points) * earned % awarded})
+	}
+	sort.SliceStable(remainders, func(i, j int) bool {
+		return remainders[i].value > remainders[j].value
+	})
+	for i := int64(0); i < remaining; i++ {
+		attributed[remainders[i].rule]++
+	}
+	return attributed
+}
+
+// addRollup adds or subtracts a rollup from a group, removing groups without receipts
+func addRollup(groups map[string]*rollup, key string, other rollup, sign int) {
+	group, ok := groups[key]
+	if !ok {
+		group = &rollup{}
+		groups[key] = group
+	}
+	group.add(other, sign)
+	if group.receipts == 0 {
+		delete(groups, key)
+	}
+}
+
+// rollupScore replaces the previous Score of a stored Receipt in the rollups of
+// its purchase date, so the rollups never need to scan the stored receipts
+// receipt: the scored Receipt
+// previous: the Score the Receipt was pinned to, nil when first scored
+// score: the Score the Receipt is pinned to
+func (d *Database) rollupScore(receipt Receipt, previous *Score, score Score) {
+	d.analyticsMu.Lock()
+	defer d.analyticsMu.Unlock()
+	if d.rollups == nil {
+		d.rollups = map[string]*dayRollups{}
+		d.retailers = map[string]string{}
+	}
+	day := receipt.PurchaseDate.Time.Format("2006-01-02")
+	rollups, ok := d.rollups[day]
+	if !ok {
+		rollups = &dayRollups{retailers: map[string]*rollup{}, rules: map[string]*rollup{}}
+		d.rollups[day] = rollups
+	}
+	retailer := normalizeRetailer(receipt.Retailer)
+	if _, ok := d.retailers[retailer]; !ok {
+		d.retailers[retailer] = receipt.Retailer
+	}
+	spend := parseMinor(ConvertReceipt(receipt).Total, 2)
+	if previous != nil {
+		rollups.add(retailer, spend, *previous, -1)
+	}
+	rollups.add(retailer, spend, score, 1)
+	d.rankScore(receipt, previous, score, retailer, spend)
+	if rollups.total.receipts == 0 {
+		delete(d.rollups, day)
+	}
+}
+
+// GetAggregates aggregates the scored receipts purchased in a date range from the
+// rollups of each day
+// groupBy: one of the GroupBy values
+// from: the first purchase date included, YYYY-MM-DD, or empty
+// to: the last purchase date included, YYYY-MM-DD, or empty
+// earning: whether to only count the receipts earning points
+// Returns: the aggregate of every receipt in the range, and the aggregate of each group ordered by key
+func (d *Database) GetAggregates(groupBy string, from string, to string, earning bool) (Aggregate, []Aggregate) {
+	d.analyticsMu.Lock()
+	defer d.analyticsMu.Unlock()
+	total := rollup{}
+	groups := map[string]*rollup{}
+	for day, rollups := range d.rollups {
+		if (from != "" && day < from) || (to != "" && day > to) {
+			continue
+		}
+		total.add(rollups.total, 1)
+		switch groupBy {
+		case GroupByRetailer:
+			for retailer, rolled := range rollups.retailers {
+				addRollup(groups, d.retailers[retailer], *rolled, 1)
+			}
+		case GroupByRule:
+			for rule, rolled := range rollups.rules {
+				addRollup(groups, rule, *rolled, 1)
+			}
+		case GroupByWeek:
+			date, _ := time.Parse("2006-01-02", day)
+			addRollup(groups, periodKey(PeriodWeek, date), rollups.total, 1)
+		case GroupByMonth:
+			addRollup(groups, day[:7], rollups.total, 1)
+		default:
+			addRollup(groups, day, rollups.total, 1)
+		}
+	}
+	aggregates := []Aggregate{}
+	for key, rolled := range groups {
+		if aggregate := newAggregate(key, *rolled, earning); aggregate.Count > 0 {
+			aggregates = append(aggregates, aggregate)
+		}
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		return aggregates[i].Key < aggregates[j].Key
+	})
+	return newAggregate("", total, earning), aggregates
+}
+
+// newAggregate converts a rollup to an Aggregate
+// key: the key of the group
+// rolled: the rollup of the group
+// earning: whether to only count the receipts earning points
+func newAggregate(key string, rolled rollup, earning bool) Aggregate {
+	count, spend := rolled.receipts, rolled.spend
+	if earning {
+		count, spend = rolled.earning, rolled.earningSpend
+	}
+	aggregate := Aggregate{
+		Key:    key,
+		Count:  count,
+		Points: rolled.points,
+		Spend:  strconv.FormatFloat(float64(spend)/100, 'f', 2, 64),
+	}
+	if count > 0 {
+		aggregate.AveragePoints = math.Round(float64(rolled.points)/float64(count)*100) / 100
+	}
+	return aggregate
+}
+
+// AnalyticsHandler handles requests for the aggregates of scored receipts
+type AnalyticsHandler struct {
+	// Database is the receipt storage, with its rollups
+	Database *Database
+}
+
+// NewAnalyticsHandler initializes AnalyticsHandler
+// database: the receipt storage, with its rollups
+func NewAnalyticsHandler(database *Database) AnalyticsHandler {
+	return AnalyticsHandler{
+		Database: database,
+	}
+}
+
+// GetAnalyticsPoints handles GET requests for the points earned by receipts in the
+// groupBy query parameter, only counting the receipts earning points
+// Response example: {"groupBy":"rule","from":"2022-01-01","to":"2022-01-31","total":{"count":2,"points":68,"averagePoints":34,"spend":"2.50"},"groups":[{"key":"retailer-name","count":2,"points":12,"averagePoints":6,"spend":"2.50"}, ...]}
+func (h *AnalyticsHandler) GetAnalyticsPoints(w http.ResponseWriter, r *http.Request) {
+	h.aggregate(w, r, true)
+}
+
+// GetAnalyticsReceipts handles GET requests for the receipts and spend in the
+// groupBy query parameter, counting every scored receipt
+// Response example: {"groupBy":"retailer","total":{"count":3,"points":68,"averagePoints":22.67,"spend":"602.50"},"groups":[{"key":"Target","count":3,"points":68,"averagePoints":22.67,"spend":"602.50"}]}
+func (h *AnalyticsHandler) GetAnalyticsReceipts(w http.ResponseWriter, r *http.Request) {
+	h.aggregate(w, r, false)
+}
+
+// aggregate responds with the aggregates of the receipts purchased between the
+// from and to query parameters, grouped by the groupBy query parameter, by day by default
+// earning: whether to only count the receipts earning points
+func (h *AnalyticsHandler) aggregate(w http.ResponseWriter, r *http.Request, earning bool) {
+	query := r.URL.Query()
+	response := GetAnalyticsResponse{
+		GroupBy: query.Get("groupBy"),
+		From:    query.Get("from"),
+		To:      query.Get("to"),
+	}
+	if response.GroupBy == "" {
+		response.GroupBy = GroupByDay
+	}
+	for name, date := range map[string]string{"from": response.From, "to": response.To} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			http.Error(w, fmt.Sprintf("Invalid %s: %s is not a date", name, date), http.StatusBadRequest)
+			return
+		}
+	}
+	if response.From != "" && response.To != "" && response.From > response.To {
+		http.Error(w, "Invalid date range: from is after to", http.StatusBadRequest)
+		return
+	}
+	response.Total, response.Groups = h.Database.GetAggregates(response.GroupBy, response.From, response.To, earning)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,164 @@
+/*
+analytics_test.go contains functions for testing the aggregates of scored receipts
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// Analyze fetches the aggregates of an analytics endpoint through the router
+func Analyze(t *testing.T, router chi.Router, path string) GetAnalyticsResponse {
+	recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, path, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code, path)
+	response := GetAnalyticsResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response
+}
+
+// TestAnalytics verifies receipts are aggregated by retailer, period and rule in a
+// date range, and the aggregates follow review decisions
+func TestAnalytics(t *testing.T) {
+	router := GetRouter()
+	ids := []string{}
+	for _, receipt := range []struct{ retailer, date, description, total string }{
+		// retailer-name 6, quarter-multiple 25
+		{"Target", "2022-01-02", "Pepsi - 12-oz", "1.25"},
+		// retailer-name 6, quarter-multiple 25, odd-day 6
+		{"target", "2022-01-03", "Pepsi - 12-oz", "1.25"},
+		// held for review, retailer-name 6, round-dollar 50, quarter-multiple 25
+		{"Target", "2022-01-10", "Television", "600.00"},
+		// retailer-name 9, round-dollar 50, quarter-multiple 25, item-description 1, odd-day 6
+		{"Walgreens", "2022-02-01", "Gum", "2.00"},
+	} {
+		recorder := ProcessRequest(router, BuildRequest(`{
+			"retailer": "`+receipt.retailer+`",
+			"purchaseDate": "`+receipt.date+`",
+			"purchaseTime": "13:13",
+			"total": "`+receipt.total+`",
+			"items": [{"shortDescription": "`+receipt.description+`", "price": "`+receipt.total+`"}]
+		}`))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		response := PostReceiptsProcessResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		ids = append(ids, response.Id)
+	}
+
+	// retailers are grouped ignoring case, under the name first scored
+	response := Analyze(t, router, "/analytics/receipts?groupBy=retailer")
+	assert.Equal(t, Aggregate{Count: 4, Points: 159, AveragePoints: 39.75, Spend: "604.50"}, response.Total)
+	assert.Equal(t, []Aggregate{
+		{Key: "Target", Count: 3, Points: 68, AveragePoints: 22.67, Spend: "602.50"},
+		{Key: "Walgreens", Count: 1, Points: 91, AveragePoints: 91, Spend: "2.00"},
+	}, response.Groups)
+
+	// the held receipt earns no points yet
+	response = Analyze(t, router, "/analytics/points?groupBy=retailer")
+	assert.Equal(t, Aggregate{Count: 3, Points: 159, AveragePoints: 53, Spend: "4.50"}, response.Total)
+	assert.Equal(t, Aggregate{Key: "Target", Count: 2, Points: 68, AveragePoints: 34, Spend: "2.50"}, response.Groups[0])
+
+	response = Analyze(t, router, "/analytics/receipts?groupBy=week")
+	keys := []string{}
+	for _, group := range response.Groups {
+		keys = append(keys, group.Key)
+	}
+	assert.Equal(t, []string{"2021-W52", "2022-W01", "2022-W02", "2022-W05"}, keys)
+
+	response = Analyze(t, router, "/analytics/receipts?groupBy=month&from=2022-01-03&to=2022-01-31")
+	assert.Equal(t, "2022-01-03", response.From)
+	assert.Equal(t, []Aggregate{{Key: "2022-01", Count: 2, Points: 37, AveragePoints: 18.5, Spend: "601.25"}}, response.Groups)
+
+	response = Analyze(t, router, "/analytics/points?groupBy=rule&to=2022-01-31")
+	assert.Equal(t, []Aggregate{
+		{Key: "odd-day", Count: 1, Points: 6, AveragePoints: 6, Spend: "1.25"},
+		{Key: "quarter-multiple", Count: 2, Points: 50, AveragePoints: 25, Spend: "2.50"},
+		{Key: "retailer-name", Count: 2, Points: 12, AveragePoints: 6, Spend: "2.50"},
+	}, response.Groups)
+
+	// approving the held receipt replaces its score in the rollups
+	for _, path := range []string{"claim", "decision"} {
+		request := httptest.NewRequest(http.MethodPost, "/reviews/"+ids[2]+"/"+path, strings.NewReader(`{"reviewer": "alice", "decision": "approved"}`))
+		request.Header.Set("Content-Type", "application/json")
+		assert.Equal(t, http.StatusOK, ProcessRequest(router, request).Code)
+	}
+	response = Analyze(t, router, "/analytics/points?groupBy=day&from=2022-01-10&to=2022-01-10")
+	assert.Equal(t, []Aggregate{{Key: "2022-01-10", Count: 1, Points: 81, AveragePoints: 81, Spend: "600.00"}}, response.Groups)
+	response = Analyze(t, router, "/analytics/receipts")
+	assert.Equal(t, Aggregate{Count: 4, Points: 240, AveragePoints: 60, Spend: "604.50"}, response.Total)
+}
+
+// TestAnalyticsInvalid verifies invalid groupings and date ranges are rejected
+func TestAnalyticsInvalid(t *testing.T) {
+	router := GetRouter()
+	for path, message := range map[string]string{
+		"/analytics/points?from=2022-02-01&to=2022-01-01": "Invalid date range: from is after to\n",
+		"/analytics/receipts?groupBy=year":                "",
+		"/analytics/receipts?from=yesterday":              "",
+	} {
+		recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, path)
+		if message != "" {
+			assert.Equal(t, message, recorder.Body.String())
+		}
+	}
+	response := Analyze(t, router, "/analytics/points")
+	assert.Equal(t, GroupByDay, response.GroupBy)
+	assert.Equal(t, []Aggregate{}, response.Groups)
+}
+
+// TestAnalyticsRuleAttribution verifies the points of rule groups add up to the points
+// earned after caps and tier multipliers, split among the rules in proportion to their awards
+func TestAnalyticsRuleAttribution(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	_, err := receipts.RuleSets.Register(RuleConfig{
+		Fraud: FraudConfig{Disabled: true},
+		Caps:  Caps{Receipt: 40},
+		Tiers: Tiers{{Name: "bronze", Points: 0, Multiplier: 1}, {Name: "gold", Points: 1000, Multiplier: 1.5}},
+	})
+	assert.NoError(t, err)
+	receipts.Database.PutTier("alice", TierStatus{Tier: "gold"})
+	router := NewReceiptRouter(receipts)
+	for _, receipt := range []struct{ member, description, total string }{
+		// retailer-name 9, round-dollar 50, quarter-multiple 25, item-description 1, odd-day 6,
+		// capped at 40 and split 4, 22, 11, 0 and 3
+		{"", "Gum", "2.00"},
+		// retailer-name 9, quarter-multiple 25, odd-day 6, 40 times 1.5 for the gold member,
+		// split 14, 37 and 9
+		{"alice", "Pepsi - 12-oz", "1.25"},
+	} {
+		memberId := ""
+		if receipt.member != "" {
+			memberId = `"memberId": "` + receipt.member + `",`
+		}
+		recorder := ProcessRequest(router, BuildRequest(`{`+memberId+`
+			"retailer": "Walgreens",
+			"purchaseDate": "2022-02-01",
+			"purchaseTime": "13:13",
+			"total": "`+receipt.total+`",
+			"items": [{"shortDescription": "`+receipt.description+`", "price": "`+receipt.total+`"}]
+		}`))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	response := Analyze(t, router, "/analytics/points?groupBy=rule")
+	assert.Equal(t, int64(100), response.Total.Points)
+	points := int64(0)
+	for _, group := range response.Groups {
+		points += group.Points
+	}
+	assert.Equal(t, response.Total.Points, points)
+	assert.Equal(t, []Aggregate{
+		{Key: "item-description", Count: 1, Points: 0, AveragePoints: 0, Spend: "2.00"},
+		{Key: "odd-day", Count: 2, Points: 12, AveragePoints: 6, Spend: "3.25"},
+		{Key: "quarter-multiple", Count: 2, Points: 48, AveragePoints: 24, Spend: "3.25"},
+		{Key: "retailer-name", Count: 2, Points: 18, AveragePoints: 9, Spend: "3.25"},
+		{Key: "round-dollar", Count: 1, Points: 22, AveragePoints: 22, Spend: "2.00"},
+	}, response.Groups)
+}
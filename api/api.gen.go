@@ -19,76 +19,1640 @@ import (
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+// Defines values for ReceiptPaymentMethod.
+const (
+	Cash      ReceiptPaymentMethod = "cash"
+	Credit    ReceiptPaymentMethod = "credit"
+	Debit     ReceiptPaymentMethod = "debit"
+	GiftCard  ReceiptPaymentMethod = "gift-card"
+	Mobile    ReceiptPaymentMethod = "mobile"
+	Other     ReceiptPaymentMethod = "other"
+	StoreCard ReceiptPaymentMethod = "store-card"
+)
+
+// Defines values for GetAnalyticsPointsParamsGroupBy.
+const (
+	GetAnalyticsPointsParamsGroupByDay      GetAnalyticsPointsParamsGroupBy = "day"
+	GetAnalyticsPointsParamsGroupByMonth    GetAnalyticsPointsParamsGroupBy = "month"
+	GetAnalyticsPointsParamsGroupByRetailer GetAnalyticsPointsParamsGroupBy = "retailer"
+	GetAnalyticsPointsParamsGroupByRule     GetAnalyticsPointsParamsGroupBy = "rule"
+	GetAnalyticsPointsParamsGroupByWeek     GetAnalyticsPointsParamsGroupBy = "week"
+)
+
+// Defines values for GetAnalyticsReceiptsParamsGroupBy.
+const (
+	GetAnalyticsReceiptsParamsGroupByDay      GetAnalyticsReceiptsParamsGroupBy = "day"
+	GetAnalyticsReceiptsParamsGroupByMonth    GetAnalyticsReceiptsParamsGroupBy = "month"
+	GetAnalyticsReceiptsParamsGroupByRetailer GetAnalyticsReceiptsParamsGroupBy = "retailer"
+	GetAnalyticsReceiptsParamsGroupByRule     GetAnalyticsReceiptsParamsGroupBy = "rule"
+	GetAnalyticsReceiptsParamsGroupByWeek     GetAnalyticsReceiptsParamsGroupBy = "week"
+)
+
+// Defines values for GetLeaderboardsMembersParamsPeriod.
+const (
+	GetLeaderboardsMembersParamsPeriodAll   GetLeaderboardsMembersParamsPeriod = "all"
+	GetLeaderboardsMembersParamsPeriodMonth GetLeaderboardsMembersParamsPeriod = "month"
+	GetLeaderboardsMembersParamsPeriodWeek  GetLeaderboardsMembersParamsPeriod = "week"
+	GetLeaderboardsMembersParamsPeriodYear  GetLeaderboardsMembersParamsPeriod = "year"
+)
+
+// Defines values for GetLeaderboardsRetailersParamsBy.
+const (
+	Receipts GetLeaderboardsRetailersParamsBy = "receipts"
+	Spend    GetLeaderboardsRetailersParamsBy = "spend"
+)
+
+// Defines values for GetLeaderboardsRetailersParamsPeriod.
+const (
+	GetLeaderboardsRetailersParamsPeriodAll   GetLeaderboardsRetailersParamsPeriod = "all"
+	GetLeaderboardsRetailersParamsPeriodMonth GetLeaderboardsRetailersParamsPeriod = "month"
+	GetLeaderboardsRetailersParamsPeriodWeek  GetLeaderboardsRetailersParamsPeriod = "week"
+	GetLeaderboardsRetailersParamsPeriodYear  GetLeaderboardsRetailersParamsPeriod = "year"
+)
+
+// Defines values for GetReceiptsExportParamsFormat.
+const (
+	Csv    GetReceiptsExportParamsFormat = "csv"
+	Ndjson GetReceiptsExportParamsFormat = "ndjson"
+)
+
+// Defines values for GetReviewsParamsStatus.
+const (
+	GetReviewsParamsStatusAdjusted GetReviewsParamsStatus = "adjusted"
+	GetReviewsParamsStatusApproved GetReviewsParamsStatus = "approved"
+	GetReviewsParamsStatusPending  GetReviewsParamsStatus = "pending"
+	GetReviewsParamsStatusRejected GetReviewsParamsStatus = "rejected"
+)
+
+// Defines values for PostReviewsIdDecisionJSONBodyDecision.
+const (
+	PostReviewsIdDecisionJSONBodyDecisionAdjusted PostReviewsIdDecisionJSONBodyDecision = "adjusted"
+	PostReviewsIdDecisionJSONBodyDecisionApproved PostReviewsIdDecisionJSONBodyDecision = "approved"
+	PostReviewsIdDecisionJSONBodyDecisionRejected PostReviewsIdDecisionJSONBodyDecision = "rejected"
+)
+
+// Defines values for PostWebhooksJSONBodyEvents.
+const (
+	PointsRedeemed   PostWebhooksJSONBodyEvents = "points.redeemed"
+	ReceiptFlagged   PostWebhooksJSONBodyEvents = "receipt.flagged"
+	ReceiptProcessed PostWebhooksJSONBodyEvents = "receipt.processed"
+	ReceiptScored    PostWebhooksJSONBodyEvents = "receipt.scored"
+)
+
+// Discount defines model for Discount.
+type Discount struct {
+	// Amount The amount taken off the receipt.
+	Amount string `json:"amount"`
+
+	// Code The code of the coupon redeemed for the discount.
+	Code *string `json:"code,omitempty"`
+
+	// Description What the discount is for.
+	Description *string `json:"description,omitempty"`
+}
+
+// ExchangeRate The exchange rate recorded when the receipt was processed, the amounts are converted with before rules evaluate them. Imported receipts are recorded with the configured rates, replacing the rate they were exported with.
+type ExchangeRate struct {
+	// Base The currency rules evaluate amounts in.
+	Base string `json:"base"`
+
+	// Currency The currency of the receipt.
+	Currency string `json:"currency"`
+
+	// Date When the exchange rates were published.
+	Date *string `json:"date,omitempty"`
+
+	// Rate The base currency value of one unit of the receipt currency.
+	Rate float64 `json:"rate"`
+}
+
 // Item defines model for Item.
 type Item struct {
+	// Discount The discount or coupon taken off the price of the item.
+	Discount *string `json:"discount,omitempty"`
+
 	// Price The total price payed for this item.
 	Price string `json:"price"`
 
-	// ShortDescription The Short Product Description for the item.
-	ShortDescription string `json:"shortDescription"`
+	// Quantity The number of units, or the weight, of the item bought, 1 when not given.
+	Quantity *float64 `json:"quantity,omitempty"`
+
+	// ShortDescription The Short Product Description for the item.
+	ShortDescription string `json:"shortDescription"`
+
+	// UnitPrice The price of one unit of the item. The price is the quantity times the unit price, less the discount.
+	UnitPrice *string `json:"unitPrice,omitempty"`
+}
+
+// Member defines model for Member.
+type Member struct {
+	// Birthday The birthday of the member.
+	Birthday *openapi_types.Date `json:"birthday,omitempty"`
+}
+
+// Receipt defines model for Receipt.
+type Receipt struct {
+	// Currency The ISO 4217 currency of the amounts, whose decimal places the amounts have, e.g. 0 for JPY. Receipts without a currency are in the base currency of the exchange rates with 2 decimal places.
+	Currency *string `json:"currency,omitempty"`
+
+	// Discounts The discounts and coupons taken off the whole receipt, item discounts are on their items.
+	Discounts *[]Discount `json:"discounts,omitempty"`
+
+	// ExchangeRate The exchange rate recorded when the receipt was processed, the amounts are converted with before rules evaluate them. Imported receipts are recorded with the configured rates, replacing the rate they were exported with.
+	ExchangeRate *ExchangeRate `json:"exchangeRate,omitempty"`
+	Items        []Item        `json:"items"`
+
+	// MemberId The ID of the member submitting the receipt.
+	MemberId *string `json:"memberId,omitempty"`
+
+	// PaymentMethod How the receipt was paid.
+	PaymentMethod *ReceiptPaymentMethod `json:"paymentMethod,omitempty"`
+
+	// PurchaseDate The date of the purchase printed on the receipt.
+	PurchaseDate openapi_types.Date `json:"purchaseDate"`
+
+	// PurchaseTime The time of the purchase printed on the receipt. 24-hour time expected.
+	PurchaseTime string `json:"purchaseTime"`
+
+	// Retailer The name of the retailer or store the receipt is from.
+	Retailer string `json:"retailer"`
+
+	// StoreId The ID of the store of the retailer the receipt is from.
+	StoreId *string `json:"storeId,omitempty"`
+
+	// Taxes The tax lines of the receipt.
+	Taxes *[]TaxLine `json:"taxes,omitempty"`
+
+	// TimeZone The IANA time zone of the store, used to evaluate the purchase time.
+	TimeZone *string `json:"timeZone,omitempty"`
+
+	// Tip The tip included in the total.
+	Tip *string `json:"tip,omitempty"`
+
+	// Total The total amount paid on the receipt, with the decimal places of its currency.
+	Total string `json:"total"`
+
+	// UtcOffset The UTC offset of the store at the time of purchase, used when no time zone is given.
+	UtcOffset *string `json:"utcOffset,omitempty"`
+}
+
+// ReceiptPaymentMethod How the receipt was paid.
+type ReceiptPaymentMethod string
+
+// TaxLine defines model for TaxLine.
+type TaxLine struct {
+	// Amount The amount of the tax.
+	Amount string `json:"amount"`
+
+	// Description The name of the tax.
+	Description *string `json:"description,omitempty"`
+
+	// Rate The tax rate in percent.
+	Rate *float64 `json:"rate,omitempty"`
+}
+
+// GetAnalyticsPointsParams defines parameters for GetAnalyticsPoints.
+type GetAnalyticsPointsParams struct {
+	// GroupBy How to group the receipts, defaults to day
+	GroupBy *GetAnalyticsPointsParamsGroupBy `form:"groupBy,omitempty" json:"groupBy,omitempty"`
+
+	// From The first purchase date included
+	From *openapi_types.Date `form:"from,omitempty" json:"from,omitempty"`
+
+	// To The last purchase date included
+	To *openapi_types.Date `form:"to,omitempty" json:"to,omitempty"`
+}
+
+// GetAnalyticsPointsParamsGroupBy defines parameters for GetAnalyticsPoints.
+type GetAnalyticsPointsParamsGroupBy string
+
+// GetAnalyticsReceiptsParams defines parameters for GetAnalyticsReceipts.
+type GetAnalyticsReceiptsParams struct {
+	// GroupBy How to group the receipts, defaults to day
+	GroupBy *GetAnalyticsReceiptsParamsGroupBy `form:"groupBy,omitempty" json:"groupBy,omitempty"`
+
+	// From The first purchase date included
+	From *openapi_types.Date `form:"from,omitempty" json:"from,omitempty"`
+
+	// To The last purchase date included
+	To *openapi_types.Date `form:"to,omitempty" json:"to,omitempty"`
+}
+
+// GetAnalyticsReceiptsParamsGroupBy defines parameters for GetAnalyticsReceipts.
+type GetAnalyticsReceiptsParamsGroupBy string
+
+// GetAuditParams defines parameters for GetAudit.
+type GetAuditParams struct {
+	// EntityId Only return the entries for this receipt, member, rule set version or review
+	EntityId *string `form:"entityId,omitempty" json:"entityId,omitempty"`
+}
+
+// GetAuditExportParams defines parameters for GetAuditExport.
+type GetAuditExportParams struct {
+	// EntityId Only return the entries for this receipt, member, rule set version or review
+	EntityId *string `form:"entityId,omitempty" json:"entityId,omitempty"`
+}
+
+// PostGraphqlJSONBody defines parameters for PostGraphql.
+type PostGraphqlJSONBody struct {
+	// OperationName The operation to run, when the query has several.
+	OperationName *string `json:"operationName,omitempty"`
+
+	// Query The GraphQL query.
+	Query string `json:"query"`
+
+	// Variables The values of the query variables.
+	Variables *map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GetLeaderboardsMembersParams defines parameters for GetLeaderboardsMembers.
+type GetLeaderboardsMembersParams struct {
+	// Period The period to rank, by purchase date, defaults to month
+	Period *GetLeaderboardsMembersParamsPeriod `form:"period,omitempty" json:"period,omitempty"`
+
+	// Date A date in the period to rank, defaults to today
+	Date *openapi_types.Date `form:"date,omitempty" json:"date,omitempty"`
+
+	// Offset The number of ranked entries to skip, defaults to 0
+	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
+
+	// Limit The most ranked entries to return, defaults to 10
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetLeaderboardsMembersParamsPeriod defines parameters for GetLeaderboardsMembers.
+type GetLeaderboardsMembersParamsPeriod string
+
+// GetLeaderboardsRetailersParams defines parameters for GetLeaderboardsRetailers.
+type GetLeaderboardsRetailersParams struct {
+	// By What to rank the retailers by, defaults to receipts
+	By *GetLeaderboardsRetailersParamsBy `form:"by,omitempty" json:"by,omitempty"`
+
+	// Period The period to rank, by purchase date, defaults to month
+	Period *GetLeaderboardsRetailersParamsPeriod `form:"period,omitempty" json:"period,omitempty"`
+
+	// Date A date in the period to rank, defaults to today
+	Date *openapi_types.Date `form:"date,omitempty" json:"date,omitempty"`
+
+	// Offset The number of ranked entries to skip, defaults to 0
+	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
+
+	// Limit The most ranked entries to return, defaults to 10
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetLeaderboardsRetailersParamsBy defines parameters for GetLeaderboardsRetailers.
+type GetLeaderboardsRetailersParamsBy string
+
+// GetLeaderboardsRetailersParamsPeriod defines parameters for GetLeaderboardsRetailers.
+type GetLeaderboardsRetailersParamsPeriod string
+
+// GetReceiptsExportParams defines parameters for GetReceiptsExport.
+type GetReceiptsExportParams struct {
+	// Format ndjson, one receipt per line, or csv, one row per item
+	Format *GetReceiptsExportParamsFormat `form:"format,omitempty" json:"format,omitempty"`
+}
+
+// GetReceiptsExportParamsFormat defines parameters for GetReceiptsExport.
+type GetReceiptsExportParamsFormat string
+
+// PostReceiptsProcessMultipartBody defines parameters for PostReceiptsProcess.
+type PostReceiptsProcessMultipartBody struct {
+	// Attachment A photo or PDF of the receipt
+	Attachment *openapi_types.File `json:"attachment,omitempty"`
+	Receipt    Receipt             `json:"receipt"`
+}
+
+// PostReceiptsProcessTextBody defines parameters for PostReceiptsProcess.
+type PostReceiptsProcessTextBody = string
+
+// GetReceiptsStreamParams defines parameters for GetReceiptsStream.
+type GetReceiptsStreamParams struct {
+	// Retailer Only stream receipts from this retailer, ignoring case, spaces and punctuation
+	Retailer *string `form:"retailer,omitempty" json:"retailer,omitempty"`
+
+	// MemberId Only stream receipts submitted by this member
+	MemberId *string `form:"memberId,omitempty" json:"memberId,omitempty"`
+
+	// LastEventID Resume after the event with this id, an id ahead of the stream resumes from the first buffered event
+	LastEventID *int `json:"Last-Event-ID,omitempty"`
+}
+
+// GetReceiptsIdAttachmentParams defines parameters for GetReceiptsIdAttachment.
+type GetReceiptsIdAttachmentParams struct {
+	// IfNoneMatch The ETag of a cached copy of the attachment
+	IfNoneMatch *string `json:"If-None-Match,omitempty"`
+}
+
+// GetReceiptsIdBreakdownParams defines parameters for GetReceiptsIdBreakdown.
+type GetReceiptsIdBreakdownParams struct {
+	// Ruleset Preview the points under this rule set version instead of the pinned version
+	Ruleset *string `form:"ruleset,omitempty" json:"ruleset,omitempty"`
+}
+
+// GetReceiptsIdPointsParams defines parameters for GetReceiptsIdPoints.
+type GetReceiptsIdPointsParams struct {
+	// Ruleset Preview the points under this rule set version instead of the pinned version
+	Ruleset *string `form:"ruleset,omitempty" json:"ruleset,omitempty"`
+}
+
+// PostReceiptsIdRescoreParams defines parameters for PostReceiptsIdRescore.
+type PostReceiptsIdRescoreParams struct {
+	// Ruleset The rule set version to rescore with, defaults to the active version
+	Ruleset *string `form:"ruleset,omitempty" json:"ruleset,omitempty"`
+}
+
+// GetReviewsParams defines parameters for GetReviews.
+type GetReviewsParams struct {
+	// Status The status of the reviews to return, defaults to pending
+	Status *GetReviewsParamsStatus `form:"status,omitempty" json:"status,omitempty"`
+}
+
+// GetReviewsParamsStatus defines parameters for GetReviews.
+type GetReviewsParamsStatus string
+
+// PostReviewsIdClaimJSONBody defines parameters for PostReviewsIdClaim.
+type PostReviewsIdClaimJSONBody struct {
+	// Comment A comment for the audit trail.
+	Comment *string `json:"comment,omitempty"`
+
+	// Reviewer Who is taking the action.
+	Reviewer string `json:"reviewer"`
+}
+
+// PostReviewsIdCommentsJSONBody defines parameters for PostReviewsIdComments.
+type PostReviewsIdCommentsJSONBody struct {
+	// Comment A comment for the audit trail.
+	Comment string `json:"comment"`
+
+	// Reviewer Who is taking the action.
+	Reviewer string `json:"reviewer"`
+}
+
+// PostReviewsIdDecisionJSONBody defines parameters for PostReviewsIdDecision.
+type PostReviewsIdDecisionJSONBody struct {
+	// Comment A comment for the audit trail.
+	Comment *string `json:"comment,omitempty"`
+
+	// Decision The decision.
+	Decision PostReviewsIdDecisionJSONBodyDecision `json:"decision"`
+
+	// Points The points to award, required to adjust.
+	Points *int `json:"points,omitempty"`
+
+	// Reviewer Who is taking the action.
+	Reviewer string `json:"reviewer"`
+}
+
+// PostReviewsIdDecisionJSONBodyDecision defines parameters for PostReviewsIdDecision.
+type PostReviewsIdDecisionJSONBodyDecision string
+
+// PostRulesSimulateJSONBody defines parameters for PostRulesSimulate.
+type PostRulesSimulateJSONBody struct {
+	// BucketSize The width of the points distribution buckets, widened so there are at most 100 buckets.
+	BucketSize *int `json:"bucketSize,omitempty"`
+
+	// Filter Selects the stored receipts to score, every stored receipt when empty.
+	Filter *struct {
+		// From The first purchase date.
+		From *openapi_types.Date `json:"from,omitempty"`
+
+		// MemberId The id of the member.
+		MemberId *string `json:"memberId,omitempty"`
+
+		// Retailer The name of the retailer.
+		Retailer *string `json:"retailer,omitempty"`
+
+		// Ruleset The rule set version the receipts are pinned to.
+		Ruleset *string `json:"ruleset,omitempty"`
+
+		// To The last purchase date.
+		To *openapi_types.Date `json:"to,omitempty"`
+	} `json:"filter,omitempty"`
+
+	// Receipts The receipts to score, instead of the stored receipts.
+	Receipts *[]Receipt `json:"receipts,omitempty"`
+
+	// Ruleset The candidate rules added to the default rules, see RuleConfig in the api package.
+	Ruleset map[string]interface{} `json:"ruleset"`
+
+	// Top The number of receipts with the largest changes to return.
+	Top *int `json:"top,omitempty"`
+}
+
+// PostRulesetsJSONBody defines parameters for PostRulesets.
+type PostRulesetsJSONBody = map[string]interface{}
+
+// PostWebhooksJSONBody defines parameters for PostWebhooks.
+type PostWebhooksJSONBody struct {
+	Events []PostWebhooksJSONBodyEvents `json:"events"`
+
+	// Secret The secret signing the events, generated when omitted.
+	Secret *string `json:"secret,omitempty"`
+
+	// Url The absolute http or https URL events are POSTed to.
+	Url string `json:"url"`
+}
+
+// PostWebhooksJSONBodyEvents defines parameters for PostWebhooks.
+type PostWebhooksJSONBodyEvents string
+
+// PostGraphqlJSONRequestBody defines body for PostGraphql for application/json ContentType.
+type PostGraphqlJSONRequestBody PostGraphqlJSONBody
+
+// PutMembersIdJSONRequestBody defines body for PutMembersId for application/json ContentType.
+type PutMembersIdJSONRequestBody = Member
+
+// PostReceiptsProcessJSONRequestBody defines body for PostReceiptsProcess for application/json ContentType.
+type PostReceiptsProcessJSONRequestBody = Receipt
+
+// PostReceiptsProcessMultipartRequestBody defines body for PostReceiptsProcess for multipart/form-data ContentType.
+type PostReceiptsProcessMultipartRequestBody PostReceiptsProcessMultipartBody
+
+// PostReceiptsProcessTextRequestBody defines body for PostReceiptsProcess for text/plain ContentType.
+type PostReceiptsProcessTextRequestBody = PostReceiptsProcessTextBody
+
+// PostReviewsIdClaimJSONRequestBody defines body for PostReviewsIdClaim for application/json ContentType.
+type PostReviewsIdClaimJSONRequestBody PostReviewsIdClaimJSONBody
+
+// PostReviewsIdCommentsJSONRequestBody defines body for PostReviewsIdComments for application/json ContentType.
+type PostReviewsIdCommentsJSONRequestBody PostReviewsIdCommentsJSONBody
+
+// PostReviewsIdDecisionJSONRequestBody defines body for PostReviewsIdDecision for application/json ContentType.
+type PostReviewsIdDecisionJSONRequestBody PostReviewsIdDecisionJSONBody
+
+// PostRulesSimulateJSONRequestBody defines body for PostRulesSimulate for application/json ContentType.
+type PostRulesSimulateJSONRequestBody PostRulesSimulateJSONBody
+
+// PostRulesetsJSONRequestBody defines body for PostRulesets for application/json ContentType.
+type PostRulesetsJSONRequestBody = PostRulesetsJSONBody
+
+// PostWebhooksJSONRequestBody defines body for PostWebhooks for application/json ContentType.
+type PostWebhooksJSONRequestBody PostWebhooksJSONBody
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// Returns the points earned by receipts
+	// (GET /analytics/points)
+	GetAnalyticsPoints(w http.ResponseWriter, r *http.Request, params GetAnalyticsPointsParams)
+	// Returns the receipts and spend
+	// (GET /analytics/receipts)
+	GetAnalyticsReceipts(w http.ResponseWriter, r *http.Request, params GetAnalyticsReceiptsParams)
+	// Returns the audit log
+	// (GET /audit)
+	GetAudit(w http.ResponseWriter, r *http.Request, params GetAuditParams)
+	// Exports the audit log
+	// (GET /audit/export)
+	GetAuditExport(w http.ResponseWriter, r *http.Request, params GetAuditExportParams)
+	// Runs a GraphQL query
+	// (POST /graphql)
+	PostGraphql(w http.ResponseWriter, r *http.Request)
+	// Returns the members earning the most points
+	// (GET /leaderboards/members)
+	GetLeaderboardsMembers(w http.ResponseWriter, r *http.Request, params GetLeaderboardsMembersParams)
+	// Returns the retailers with the most receipts or spend
+	// (GET /leaderboards/retailers)
+	GetLeaderboardsRetailers(w http.ResponseWriter, r *http.Request, params GetLeaderboardsRetailersParams)
+	// Returns the member profile
+	// (GET /members/{id})
+	GetMembersId(w http.ResponseWriter, r *http.Request, id string)
+	// Creates or replaces the member profile
+	// (PUT /members/{id})
+	PutMembersId(w http.ResponseWriter, r *http.Request, id string)
+	// Returns the tier of the member
+	// (GET /members/{id}/tier)
+	GetMembersIdTier(w http.ResponseWriter, r *http.Request, id string)
+	// Returns the plugins of the active rule set
+	// (GET /plugins)
+	GetPlugins(w http.ResponseWriter, r *http.Request)
+	// Exports every receipt with its points
+	// (GET /receipts/export)
+	GetReceiptsExport(w http.ResponseWriter, r *http.Request, params GetReceiptsExportParams)
+	// Imports receipts in bulk
+	// (POST /receipts/import)
+	PostReceiptsImport(w http.ResponseWriter, r *http.Request)
+	// Submits a receipt for processing
+	// (POST /receipts/process)
+	PostReceiptsProcess(w http.ResponseWriter, r *http.Request)
+	// Streams processed and scored receipts
+	// (GET /receipts/stream)
+	GetReceiptsStream(w http.ResponseWriter, r *http.Request, params GetReceiptsStreamParams)
+	// Returns the photo or PDF attached to the receipt
+	// (GET /receipts/{id}/attachment)
+	GetReceiptsIdAttachment(w http.ResponseWriter, r *http.Request, id string, params GetReceiptsIdAttachmentParams)
+	// Returns the points awarded for the receipt by each rule
+	// (GET /receipts/{id}/breakdown)
+	GetReceiptsIdBreakdown(w http.ResponseWriter, r *http.Request, id string, params GetReceiptsIdBreakdownParams)
+	// Returns the points awarded for the receipt
+	// (GET /receipts/{id}/points)
+	GetReceiptsIdPoints(w http.ResponseWriter, r *http.Request, id string, params GetReceiptsIdPointsParams)
+	// Rescores the receipt
+	// (POST /receipts/{id}/rescore)
+	PostReceiptsIdRescore(w http.ResponseWriter, r *http.Request, id string, params PostReceiptsIdRescoreParams)
+	// Returns the review queue
+	// (GET /reviews)
+	GetReviews(w http.ResponseWriter, r *http.Request, params GetReviewsParams)
+	// Returns a review
+	// (GET /reviews/{id})
+	GetReviewsId(w http.ResponseWriter, r *http.Request, id string)
+	// Claims a review
+	// (POST /reviews/{id}/claim)
+	PostReviewsIdClaim(w http.ResponseWriter, r *http.Request, id string)
+	// Comments on a review
+	// (POST /reviews/{id}/comments)
+	PostReviewsIdComments(w http.ResponseWriter, r *http.Request, id string)
+	// Decides a review
+	// (POST /reviews/{id}/decision)
+	PostReviewsIdDecision(w http.ResponseWriter, r *http.Request, id string)
+	// Simulates a candidate rule set
+	// (POST /rules/simulate)
+	PostRulesSimulate(w http.ResponseWriter, r *http.Request)
+	// Returns every rule set version
+	// (GET /rulesets)
+	GetRulesets(w http.ResponseWriter, r *http.Request)
+	// Registers a new rule set version
+	// (POST /rulesets)
+	PostRulesets(w http.ResponseWriter, r *http.Request)
+	// Returns a rule set version
+	// (GET /rulesets/{version})
+	GetRulesetsVersion(w http.ResponseWriter, r *http.Request, version string)
+	// Returns the registered webhooks
+	// (GET /webhooks)
+	GetWebhooks(w http.ResponseWriter, r *http.Request)
+	// Registers a webhook
+	// (POST /webhooks)
+	PostWebhooks(w http.ResponseWriter, r *http.Request)
+	// Returns the dead-letter list
+	// (GET /webhooks/deadletters)
+	GetWebhooksDeadletters(w http.ResponseWriter, r *http.Request)
+	// Replays a dead delivery
+	// (POST /webhooks/deadletters/{id}/replay)
+	PostWebhooksDeadlettersIdReplay(w http.ResponseWriter, r *http.Request, id string)
+	// Removes a webhook
+	// (DELETE /webhooks/{id})
+	DeleteWebhooksId(w http.ResponseWriter, r *http.Request, id string)
+}
+
+// Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
+
+type Unimplemented struct{}
+
+// Returns the points earned by receipts
+// (GET /analytics/points)
+func (_ Unimplemented) GetAnalyticsPoints(w http.ResponseWriter, r *http.Request, params GetAnalyticsPointsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the receipts and spend
+// (GET /analytics/receipts)
+func (_ Unimplemented) GetAnalyticsReceipts(w http.ResponseWriter, r *http.Request, params GetAnalyticsReceiptsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the audit log
+// (GET /audit)
+func (_ Unimplemented) GetAudit(w http.ResponseWriter, r *http.Request, params GetAuditParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Exports the audit log
+// (GET /audit/export)
+func (_ Unimplemented) GetAuditExport(w http.ResponseWriter, r *http.Request, params GetAuditExportParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Runs a GraphQL query
+// (POST /graphql)
+func (_ Unimplemented) PostGraphql(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the members earning the most points
+// (GET /leaderboards/members)
+func (_ Unimplemented) GetLeaderboardsMembers(w http.ResponseWriter, r *http.Request, params GetLeaderboardsMembersParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the retailers with the most receipts or spend
+// (GET /leaderboards/retailers)
+func (_ Unimplemented) GetLeaderboardsRetailers(w http.ResponseWriter, r *http.Request, params GetLeaderboardsRetailersParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the member profile
+// (GET /members/{id})
+func (_ Unimplemented) GetMembersId(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Creates or replaces the member profile
+// (PUT /members/{id})
+func (_ Unimplemented) PutMembersId(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the tier of the member
+// (GET /members/{id}/tier)
+func (_ Unimplemented) GetMembersIdTier(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the plugins of the active rule set
+// (GET /plugins)
+func (_ Unimplemented) GetPlugins(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Exports every receipt with its points
+// (GET /receipts/export)
+func (_ Unimplemented) GetReceiptsExport(w http.ResponseWriter, r *http.Request, params GetReceiptsExportParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Imports receipts in bulk
+// (POST /receipts/import)
+func (_ Unimplemented) PostReceiptsImport(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Submits a receipt for processing
+// (POST /receipts/process)
+func (_ Unimplemented) PostReceiptsProcess(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Streams processed and scored receipts
+// (GET /receipts/stream)
+func (_ Unimplemented) GetReceiptsStream(w http.ResponseWriter, r *http.Request, params GetReceiptsStreamParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the photo or PDF attached to the receipt
+// (GET /receipts/{id}/attachment)
+func (_ Unimplemented) GetReceiptsIdAttachment(w http.ResponseWriter, r *http.Request, id string, params GetReceiptsIdAttachmentParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the points awarded for the receipt by each rule
+// (GET /receipts/{id}/breakdown)
+func (_ Unimplemented) GetReceiptsIdBreakdown(w http.ResponseWriter, r *http.Request, id string, params GetReceiptsIdBreakdownParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the points awarded for the receipt
+// (GET /receipts/{id}/points)
+func (_ Unimplemented) GetReceiptsIdPoints(w http.ResponseWriter, r *http.Request, id string, params GetReceiptsIdPointsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Rescores the receipt
+// (POST /receipts/{id}/rescore)
+func (_ Unimplemented) PostReceiptsIdRescore(w http.ResponseWriter, r *http.Request, id string, params PostReceiptsIdRescoreParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the review queue
+// (GET /reviews)
+func (_ Unimplemented) GetReviews(w http.ResponseWriter, r *http.Request, params GetReviewsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns a review
+// (GET /reviews/{id})
+func (_ Unimplemented) GetReviewsId(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Claims a review
+// (POST /reviews/{id}/claim)
+func (_ Unimplemented) PostReviewsIdClaim(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Comments on a review
+// (POST /reviews/{id}/comments)
+func (_ Unimplemented) PostReviewsIdComments(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Decides a review
+// (POST /reviews/{id}/decision)
+func (_ Unimplemented) PostReviewsIdDecision(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Simulates a candidate rule set
+// (POST /rules/simulate)
+func (_ Unimplemented) PostRulesSimulate(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns every rule set version
+// (GET /rulesets)
+func (_ Unimplemented) GetRulesets(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Registers a new rule set version
+// (POST /rulesets)
+func (_ Unimplemented) PostRulesets(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns a rule set version
+// (GET /rulesets/{version})
+func (_ Unimplemented) GetRulesetsVersion(w http.ResponseWriter, r *http.Request, version string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the registered webhooks
+// (GET /webhooks)
+func (_ Unimplemented) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Registers a webhook
+// (POST /webhooks)
+func (_ Unimplemented) PostWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Returns the dead-letter list
+// (GET /webhooks/deadletters)
+func (_ Unimplemented) GetWebhooksDeadletters(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Replays a dead delivery
+// (POST /webhooks/deadletters/{id}/replay)
+func (_ Unimplemented) PostWebhooksDeadlettersIdReplay(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Removes a webhook
+// (DELETE /webhooks/{id})
+func (_ Unimplemented) DeleteWebhooksId(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// GetAnalyticsPoints operation middleware
+func (siw *ServerInterfaceWrapper) GetAnalyticsPoints(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAnalyticsPointsParams
+
+	// ------------- Optional query parameter "groupBy" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "groupBy", r.URL.Query(), &params.GroupBy)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "groupBy", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAnalyticsPoints(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetAnalyticsReceipts operation middleware
+func (siw *ServerInterfaceWrapper) GetAnalyticsReceipts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAnalyticsReceiptsParams
+
+	// ------------- Optional query parameter "groupBy" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "groupBy", r.URL.Query(), &params.GroupBy)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "groupBy", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAnalyticsReceipts(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetAudit operation middleware
+func (siw *ServerInterfaceWrapper) GetAudit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAuditParams
+
+	// ------------- Optional query parameter "entityId" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "entityId", r.URL.Query(), &params.EntityId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "entityId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAudit(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetAuditExport operation middleware
+func (siw *ServerInterfaceWrapper) GetAuditExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAuditExportParams
+
+	// ------------- Optional query parameter "entityId" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "entityId", r.URL.Query(), &params.EntityId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "entityId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAuditExport(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostGraphql operation middleware
+func (siw *ServerInterfaceWrapper) PostGraphql(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostGraphql(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetLeaderboardsMembers operation middleware
+func (siw *ServerInterfaceWrapper) GetLeaderboardsMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetLeaderboardsMembersParams
+
+	// ------------- Optional query parameter "period" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "period", r.URL.Query(), &params.Period)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "period", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "date" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "date", r.URL.Query(), &params.Date)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "date", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", r.URL.Query(), &params.Offset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "offset", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetLeaderboardsMembers(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetLeaderboardsRetailers operation middleware
+func (siw *ServerInterfaceWrapper) GetLeaderboardsRetailers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetLeaderboardsRetailersParams
+
+	// ------------- Optional query parameter "by" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "by", r.URL.Query(), &params.By)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "by", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "period" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "period", r.URL.Query(), &params.Period)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "period", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "date" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "date", r.URL.Query(), &params.Date)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "date", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", r.URL.Query(), &params.Offset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "offset", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetLeaderboardsRetailers(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetMembersId operation middleware
+func (siw *ServerInterfaceWrapper) GetMembersId(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetMembersId(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PutMembersId operation middleware
+func (siw *ServerInterfaceWrapper) PutMembersId(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PutMembersId(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetMembersIdTier operation middleware
+func (siw *ServerInterfaceWrapper) GetMembersIdTier(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetMembersIdTier(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetPlugins operation middleware
+func (siw *ServerInterfaceWrapper) GetPlugins(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetPlugins(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetReceiptsExport operation middleware
+func (siw *ServerInterfaceWrapper) GetReceiptsExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetReceiptsExportParams
+
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", r.URL.Query(), &params.Format)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "format", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceiptsExport(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostReceiptsImport operation middleware
+func (siw *ServerInterfaceWrapper) PostReceiptsImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostReceiptsImport(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostReceiptsProcess operation middleware
+func (siw *ServerInterfaceWrapper) PostReceiptsProcess(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostReceiptsProcess(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetReceiptsStream operation middleware
+func (siw *ServerInterfaceWrapper) GetReceiptsStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetReceiptsStreamParams
+
+	// ------------- Optional query parameter "retailer" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "retailer", r.URL.Query(), &params.Retailer)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "retailer", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "memberId" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "memberId", r.URL.Query(), &params.MemberId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "memberId", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Last-Event-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Last-Event-ID")]; found {
+		var LastEventID int
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Last-Event-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Last-Event-ID", valueList[0], &LastEventID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Last-Event-ID", Err: err})
+			return
+		}
+
+		params.LastEventID = &LastEventID
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceiptsStream(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetReceiptsIdAttachment operation middleware
+func (siw *ServerInterfaceWrapper) GetReceiptsIdAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetReceiptsIdAttachmentParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-None-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-None-Match")]; found {
+		var IfNoneMatch string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-None-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-None-Match", valueList[0], &IfNoneMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-None-Match", Err: err})
+			return
+		}
+
+		params.IfNoneMatch = &IfNoneMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceiptsIdAttachment(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetReceiptsIdBreakdown operation middleware
+func (siw *ServerInterfaceWrapper) GetReceiptsIdBreakdown(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetReceiptsIdBreakdownParams
+
+	// ------------- Optional query parameter "ruleset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "ruleset", r.URL.Query(), &params.Ruleset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "ruleset", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceiptsIdBreakdown(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetReceiptsIdPoints operation middleware
+func (siw *ServerInterfaceWrapper) GetReceiptsIdPoints(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetReceiptsIdPointsParams
+
+	// ------------- Optional query parameter "ruleset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "ruleset", r.URL.Query(), &params.Ruleset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "ruleset", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceiptsIdPoints(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostReceiptsIdRescore operation middleware
+func (siw *ServerInterfaceWrapper) PostReceiptsIdRescore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostReceiptsIdRescoreParams
+
+	// ------------- Optional query parameter "ruleset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "ruleset", r.URL.Query(), &params.Ruleset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "ruleset", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostReceiptsIdRescore(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetReviews operation middleware
+func (siw *ServerInterfaceWrapper) GetReviews(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetReviewsParams
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReviews(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetReviewsId operation middleware
+func (siw *ServerInterfaceWrapper) GetReviewsId(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReviewsId(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// Receipt defines model for Receipt.
-type Receipt struct {
-	Items []Item `json:"items"`
+// PostReviewsIdClaim operation middleware
+func (siw *ServerInterfaceWrapper) PostReviewsIdClaim(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	// PurchaseDate The date of the purchase printed on the receipt.
-	PurchaseDate openapi_types.Date `json:"purchaseDate"`
+	var err error
 
-	// PurchaseTime The time of the purchase printed on the receipt. 24-hour time expected.
-	PurchaseTime string `json:"purchaseTime"`
+	// ------------- Path parameter "id" -------------
+	var id string
 
-	// Retailer The name of the retailer or store the receipt is from.
-	Retailer string `json:"retailer"`
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
 
-	// Total The total amount paid on the receipt.
-	Total string `json:"total"`
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostReviewsIdClaim(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// PostReceiptsProcessJSONRequestBody defines body for PostReceiptsProcess for application/json ContentType.
-type PostReceiptsProcessJSONRequestBody = Receipt
+// PostReviewsIdComments operation middleware
+func (siw *ServerInterfaceWrapper) PostReviewsIdComments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-// ServerInterface represents all server handlers.
-type ServerInterface interface {
-	// Submits a receipt for processing
-	// (POST /receipts/process)
-	PostReceiptsProcess(w http.ResponseWriter, r *http.Request)
-	// Returns the points awarded for the receipt
-	// (GET /receipts/{id}/points)
-	GetReceiptsIdPoints(w http.ResponseWriter, r *http.Request, id string)
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostReviewsIdComments(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
+// PostReviewsIdDecision operation middleware
+func (siw *ServerInterfaceWrapper) PostReviewsIdDecision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-type Unimplemented struct{}
+	var err error
 
-// Submits a receipt for processing
-// (POST /receipts/process)
-func (_ Unimplemented) PostReceiptsProcess(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotImplemented)
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostReviewsIdDecision(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// Returns the points awarded for the receipt
-// (GET /receipts/{id}/points)
-func (_ Unimplemented) GetReceiptsIdPoints(w http.ResponseWriter, r *http.Request, id string) {
-	w.WriteHeader(http.StatusNotImplemented)
+// PostRulesSimulate operation middleware
+func (siw *ServerInterfaceWrapper) PostRulesSimulate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostRulesSimulate(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// ServerInterfaceWrapper converts contexts to parameters.
-type ServerInterfaceWrapper struct {
-	Handler            ServerInterface
-	HandlerMiddlewares []MiddlewareFunc
-	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+// GetRulesets operation middleware
+func (siw *ServerInterfaceWrapper) GetRulesets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetRulesets(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-type MiddlewareFunc func(http.Handler) http.Handler
+// PostRulesets operation middleware
+func (siw *ServerInterfaceWrapper) PostRulesets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-// PostReceiptsProcess operation middleware
-func (siw *ServerInterfaceWrapper) PostReceiptsProcess(w http.ResponseWriter, r *http.Request) {
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostRulesets(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetRulesetsVersion operation middleware
+func (siw *ServerInterfaceWrapper) GetRulesetsVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "version" -------------
+	var version string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "version", chi.URLParam(r, "version"), &version, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "version", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetRulesetsVersion(w, r, version)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetWebhooks operation middleware
+func (siw *ServerInterfaceWrapper) GetWebhooks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PostReceiptsProcess(w, r)
+		siw.Handler.GetWebhooks(w, r)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -98,8 +1662,64 @@ func (siw *ServerInterfaceWrapper) PostReceiptsProcess(w http.ResponseWriter, r
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// GetReceiptsIdPoints operation middleware
-func (siw *ServerInterfaceWrapper) GetReceiptsIdPoints(w http.ResponseWriter, r *http.Request) {
+// PostWebhooks operation middleware
+func (siw *ServerInterfaceWrapper) PostWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostWebhooks(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetWebhooksDeadletters operation middleware
+func (siw *ServerInterfaceWrapper) GetWebhooksDeadletters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetWebhooksDeadletters(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostWebhooksDeadlettersIdReplay operation middleware
+func (siw *ServerInterfaceWrapper) PostWebhooksDeadlettersIdReplay(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostWebhooksDeadlettersIdReplay(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// DeleteWebhooksId operation middleware
+func (siw *ServerInterfaceWrapper) DeleteWebhooksId(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var err error
@@ -114,7 +1734,7 @@ func (siw *ServerInterfaceWrapper) GetReceiptsIdPoints(w http.ResponseWriter, r
 	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetReceiptsIdPoints(w, r, id)
+		siw.Handler.DeleteWebhooksId(w, r, id)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -237,12 +1857,105 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/analytics/points", wrapper.GetAnalyticsPoints)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/analytics/receipts", wrapper.GetAnalyticsReceipts)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/audit", wrapper.GetAudit)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/audit/export", wrapper.GetAuditExport)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/graphql", wrapper.PostGraphql)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/leaderboards/members", wrapper.GetLeaderboardsMembers)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/leaderboards/retailers", wrapper.GetLeaderboardsRetailers)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/members/{id}", wrapper.GetMembersId)
+	})
+	r.Group(func(r chi.Router) {
+		r.Put(options.BaseURL+"/members/{id}", wrapper.PutMembersId)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/members/{id}/tier", wrapper.GetMembersIdTier)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/plugins", wrapper.GetPlugins)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/receipts/export", wrapper.GetReceiptsExport)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/receipts/import", wrapper.PostReceiptsImport)
+	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/receipts/process", wrapper.PostReceiptsProcess)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/receipts/stream", wrapper.GetReceiptsStream)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/receipts/{id}/attachment", wrapper.GetReceiptsIdAttachment)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/receipts/{id}/breakdown", wrapper.GetReceiptsIdBreakdown)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/receipts/{id}/points", wrapper.GetReceiptsIdPoints)
 	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/receipts/{id}/rescore", wrapper.PostReceiptsIdRescore)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/reviews", wrapper.GetReviews)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/reviews/{id}", wrapper.GetReviewsId)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/reviews/{id}/claim", wrapper.PostReviewsIdClaim)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/reviews/{id}/comments", wrapper.PostReviewsIdComments)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/reviews/{id}/decision", wrapper.PostReviewsIdDecision)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/rules/simulate", wrapper.PostRulesSimulate)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/rulesets", wrapper.GetRulesets)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/rulesets", wrapper.PostRulesets)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/rulesets/{version}", wrapper.GetRulesetsVersion)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/webhooks", wrapper.GetWebhooks)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/webhooks", wrapper.PostWebhooks)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/webhooks/deadletters", wrapper.GetWebhooksDeadletters)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/webhooks/deadletters/{id}/replay", wrapper.PostWebhooksDeadlettersIdReplay)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/webhooks/{id}", wrapper.DeleteWebhooksId)
+	})
 
 	return r
 }
@@ -250,21 +1963,169 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/6yVUW+jRhDHv8pqem+HbUxc68JbW0uVVaWKLvcWUmnNDvFeze52dricFfHdqwVsY0wu",
-	"iXQvCYZh5r/z/83wDLktnTVo2EP6DD7fYimbyzVjGf47sg6JNfr2l84xXCj0OWnH2hpI4csWBVuWO9EE",
-	"CCf3qERhSfBWe6EZyylEgN9l6XYIKSyni2uIwElmpJDhnyxTH7NsmmXqOak/QAS8dyHSM2nzCHUEfmuJ",
-	"V/26YzLuQpS4JauqnEUvvJODI2pubGVYaiNW+CTmye1f59Lus+wpy3yWTR4+jiirIyD8r9KECtL7S5lR",
-	"17WH45t28xVzDmf6jDlqx5eNDiLPLz4QFpDCL7OTZbPOr1ljVh1Bqc26jZ8fi0kiuQ8PXUX5VnpcSX7B",
-	"QiUZhS2aLh2ig6OGUQlrmvvUKj5vYBInySSeT+I5RFBYKiVDCiHdmJGH1F90+RJLunyzEJEsJltbUfsS",
-	"fneYM6pzffOr9FxaiB2TRshS75DGZRl5knWIFJaEZ0vYFyW0FwXZIWZZFcfJ8kb8YckgiRtJ/yK/xFob",
-	"PEpcBM2w/WgOZRmYFk7qHzv3/kEc4H7s2ACwgc1RB/JB+uUwhMTaFPbyVL8Jr4PcY3cd2Ry9t6Eoa24O",
-	"0k1SmPzjs29Ivk0xn8bTODTOOjTSaUjhahpPr9qjb5sBm3Xp/azL30yl9Xyp6K7alJq9kEdJYbl0r4Uu",
-	"NYVIhvC1ghRuredOoe8UQttH9Py7VftQI7eG0TTlpHM7nTfvz776dte1w/7aKjhslPrcKKYKmxveWePb",
-	"HZPE8bvKDjaUCn9PJEm1WW5+XcaTGLGYLJJNPrlW8+VEFYtPxVWMn643yZC0uzfsU61egOXcks/IFRnf",
-	"kL5eCem9fjSoBNs+/AGBRXvsy8npDa823+ROq0aMr8pS0v4ttofwE0bPWtUzZ3X3cX3EEZL6sttQIZ8k",
-	"qePn8yR9yNSfeERqrW7bMqG/JEtkJA/p/dgp16vTCjsk1uFhGASIICw5SEPbh/xEfRZet/Hhp+J26uMR",
-	"uXkc91a6NrxcnGSEb8UjUmPJq/A0y70qN2GdFwMfWmQWl9b9bXsYVOZgmGRxAc47XK7ruv4/AAD///2p",
-	"zauXCQAA",
+	"H4sIAAAAAAAC/+x9e3PjuHbnV0Fpb+omeymZkh9tu2or5enumfHN9IzT9txJMu7dQOSRhGuK4ACgbU2X",
+	"v/sWXiRAghTldnc6E/1lmQTxPI/fOTg4+DhK6LqgOeSCj84/jniygjVWP98QntAyF/J3wWgBTBBQb/Da",
+	"Pk+BJ4wUgtB8dD66WQHS75DAd5AjulggsQLEIAFSiMkoGsEjXhcZjM5H08lxPIpGBRYCmPz8/97epn/5",
+	"x9vbye1t+nEaHT790z//aRSNxKaQxblgJF+OnqJRQlMINy7fIKrbTGhZ0BwxSAHWkKIFZep5aobld+b6",
+	"4m9vp63uXP8l2AGv4WY/fllh4TWECJeNNwYf/4OcHb/BX29vH25v+e3t+B8+hFp+ikYMfisJg3R0/qtd",
+	"hg9VOTr/OyRC9vDtY7LC+RLeY9ExVWBKIIaFWiDKUkjRwwpyd8nQA+aoYDQBziGN1CvdLkeYyVnO74EJ",
+	"+SURKzSHBWWAWJkBR3CPs1LWLlawnqDLdUFVSVO1rqBuWX6vFy5fkGXJZEksgEeIQZHhhORL3TNT5QY9",
+	"AJMDMdXKCuQk+7Q6x7yLWErGIE82zd7a4ZHcX7Kfr980luti/B8fPh4+hYnU1L6lbdrDIG9/fr9Dg2lw",
+	"qX+x6+mtN9dTV5TzjPAVpH67s3h2NI5PxvE01A7rJCk51fXI5HQqZqQ5oDInojHWqqTX+HQSn0ajBWVr",
+	"LEbno5SW8wzqbuTleg7McAJOf8qzzehcsBJ8zqgmP9Lrb3od4pRLAeu2hEsd2dceqH2LKLNixpd3BSNJ",
+	"JYiIgLU/wfHkePosyafqDfdJUIEz03CBN5XAIzzQgZPJ0dmzOvBbiXNBRAdV69WR45brzSNkRO4DkOVK",
+	"RO6EoDkt1bOpFjk5FWhJ7sHnuZn8nWQlJ/fwjuRkXa7tcrdJZG0LxC1yiUZ8RZl40ye35QCuZSl0xWha",
+	"JgI5xSvt0Z7Ld5ISMMnRG3hA09nVv3TJ9A9hZSKn6qp7XStSarKR6gmqixCuHtsVQoKsQT9SX6lCEcqA",
+	"8x41ePg8ndxQTK3JtpQbYsB3oNaoxYJzwsQqxR2kZt/a2VirWho69uwsHsfH4+nRyKUXLCA4glbP3mtB",
+	"1e5av2y/vP4JHc2mr1pC3iiWCD2sKAeUQkLWkmcznAB3S6AVvocIwWQ5QbEivb9e/fsEvbd6U2o6WgqE",
+	"6yYwA0S0pPfFMF0Exb9UtrNGFz5J+xhy4v0SkyOcp0Zm8obQfFjRrNIOkZYSzncMEFUDJEy9U91VP2ST",
+	"f2KwGJ2P/tdBDWcPDJY9qIBsvciYMbyR/0MDKPXV44GqJ6fxQb1QiuZJialLXX7a7o4m48u0g7Le+OSO",
+	"eDlfEyEqZBRCEbroeDo7PBoIbwu8WUMu3oFY0UBPvqcPbYSIiQYRuRTAv44SzFejaJQwSIkYRaMU5uov",
+	"F5TBOMEsHUWjJVkI+3tN50RJcSpWwEYf3BF4H7V7W7JkhTm86cQlkuPtxNnSUh7mAlJDU+Gpm8Wz2Tie",
+	"aiC0RYDUHbkh6y4dTdaDO4JmR+MVLZn+CB4LSEQTqE0Pz/2uybKhrjEQmGTAwt3Kcd0tW1KqbjXv3lJL",
+	"S4bRpgK8LeN4dvIOvaYsB4beYXYHoksL6sIdulC1uJ36dceaPd7aUU1HcXw0G8gIAj9Ch0AT+BFlJAce",
+	"QPCDxMENfvyB5BCSSXIV/4PmHUR0efHjhSaK32kO3pREqOSQIkE906smNfmVPyUXa2AkwQevVyTBSxpa",
+	"tPFfDjpWS5Cii84LRPIkK1NIrVZS+LTBXpP4eQ4AVVcfDDZOCCmVGnwV1XZmQ//SBSKCh62S5wPmUiQ/",
+	"LRYcOiyJn29eS/3HQfi0bVwIVmDYFTQLbBCzQwWEB9DzaBwfnzen+Ne/jD/IPs+ezvWf7XCukh4NYdsQ",
+	"eZbw7fqEwJ6l+ue4lMwECfzYtKZenT5rbdJt5oArGFvNXgvJXzf4cTcfTp8BLYWKfCt5pgCWQAOen0xm",
+	"xztaPkNdRrIgyRe03bELxIlsvpKtxhtEmRwcEWoyDDRFV867e2BcVzGdxJNYDp0WkOOCKEMjnhzqmVsp",
+	"AjjAOc42giT8oKDEAMlliG/egyhZrgGzLooAsxxSNN+ohzyhzHUzWSpVsghrOMAkkIvQktGy0F9aKo9Q",
+	"ijcRegC4i9Ca5mIldaH0EUVIwUiJtmiebVyxorsg3+geTdB3Ts3qW8DJSreHVpj7H6t/ygwQfsDKGaZr",
+	"iRRa9saZrKp1MIPGCwEMJbjgERIEGFqXmSBFRoBptM3gnsCDEndyPTSU5kVGhOSsfKnEnvaBSbpjtKBM",
+	"2byCGritesUjxNUDPQiOcJqisjCljHxHr+m6KIX0PjC6RoxmmSx6B4UwZdXsY+47AfWKSVqXMgHL1iUK",
+	"GH0H4sKSxZWmCkkyDK9BAOOj81+D4JSaeXbnOEIpLHCZCa57sRlJgh+dj34rgcl/JLOPzkfqy2/kA62p",
+	"Jf1ZVOsIQl2BJBIFXnOxGkUjOYmjDy2Wf4pCzL4gjItaN6ea77XS7OibnFOvY1uN2lDDGd61XUF3avVD",
+	"NGLApYWnZfssjuWfhOYCtIzHRZGRRK3zwd+5Fr91/b5mUIM+33UGJ0MQu13rbSaOJlIjK3wtoJa8q2rf",
+	"OGwovHtgeAlXlawL6D1dxDJ/AaxGMYL2m++zs8lxwA3W49SsnXd21E1XnKmN5AKWuro76PCBNISpdIlI",
+	"XkG8TFaS+2fxbDr+5XhmJazzXFpckRW5Vvuq6fRn/gazpTIz2oZYz5zycm3r9HRH5D4yBVQHFNZa+sLc",
+	"68jxWWhmeAF5urULVpQr4cmDDpyGf+5kchIP8161rAo6Ot9RHAxioh4wjpdLBktjfcM9sE01YjPUWhe3",
+	"d27+EByyp8UWBq01rGpqVAnLMCrtISs1OzWuklIjBabnRoqmp2h0pHVPh1NIUR4icrT3OCOp6isv12vM",
+	"NtuQpqUB9Y2DXqvnQ/CrorbIVi7Rmlqsml98LPuCUFbzuESslZJbQaZ3jQxipKxCtTmtgO216iDpoJDP",
+	"CACt+3sPAfcQ8KuCgDVJ7GHgHgbuYeAeBu5h4B8VBtbqwGI1g//KlIhBkE/gdQFsDPckhVwg9SHK6FIO",
+	"ggssYKx2mZWX0WIhHiGapcCFVnjaL/iwArECptx3K8xXKFlhkuthCJyICXor5wRywTaI6DJjVUbvEcnO",
+	"6Jcmao8I5eKrm2eIwZrey984rytKQVSbkW24Vuod316IJuO2EFOTUnWDAK9Dlir21bvXkSZ8DgIZrzKq",
+	"YGoHYAEVBXOZerDl88IUM4o+bZ+ENxt+0ht5dg8xYYCFFAD2AQMNkO18TMoiNSWk3xaqT+ppsUWaWMVr",
+	"ISTIcSIoCwUSUrTGqd5RVCQCkcb48sG/jb+lTPuuxz9zYGgFOJXrRu2+rOxTM0AHZyQJKhPl0w4zrA4r",
+	"VAWcrkzkV5qM+z7TJbwh5GWWaYlq5kRVBT1RbndEG2hiJRlD15NGiHpr6FOu2t+rVia0IqFZqEh4y3a4",
+	"15HGDKfzk/nxSTyOARbjo9k8GZ+l05Nxujg6XRzGcHo2n4WalrIi3OwKHtH19xfj2fGJbV8LBrtVIT/1",
+	"3+hZ9zt2tjg9SePT6enpUfIqPTk+w7MFYBwnx8c4jafH+HC+OFpM57N5PD+dzZJ0epyeJNPjebyIYxyf",
+	"hqMj4f777o539CtCsC7Eporw0yaFKjEJtcLhtxLyznA9yolQ8sltyWjRjC4Nz0z9oNeQuhZk3RfPq5db",
+	"Bd9ItmxhtHE4FmQIRATGQhJAB/TNgTvTNGdUhnBVs+VQntJreviHqpuGQENzqrVuaLBKw1VkpTUcdePF",
+	"av1ZK766iTmlGeC8BT50g1ElsgdjDttaD0Twy2hkcKCj1DsBwlv1uvG9tIT+ev3Tjz9o4aLnsgCmAk98",
+	"RNCpiXXFf0x9/DjO07ZObtbZv452qHqK7eQ2Vje4Onp1lwwXq9+U4VNQHoJ+pdxxRd/Jcv/6A1Kzguh9",
+	"baLwyA1qrHyBcwb4LqUPuRKsdtpVSOh/frTf/iNJz9HtaDKZ3I7+CX2sY6FUXeijCQ1+cmr7aBvQO74f",
+	"1V/77Ak9mabQRzTHGc7V10/o6T8n6F9LUESRA1dIQ47hBC0IZClHKUChzXXgNFOQURWYxbEtUm2ea/Gh",
+	"npV5Cgxh0+ifHa8gzRO9HhXp6dMif++AnleUi+/MYmheBy6+oenmEwBd1cKPuCukryqCBEWszKP6BI1e",
+	"arnXz6XRq6OfAhH1kk2CdXs040vXJgW8So/S08NX8zEsjmF89CqJx6dn+Gx8enp2cvxqfnIC87MGiZgl",
+	"Dy93WEgzgueZQbJpqvQczq6cKdOh+R3oqzJi9cRU1U1GbeHrS2v1QYeMrsvJxp9eFNCnWODdB2tI+zfF",
+	"LqmLMHMq0IKWeRoYslG7fdbDGjjHSwgJua1a/WmggtNrw4CXmeg1nHXB2maWvK84XofWFXqvQQY+ZvBI",
+	"xAZlZE1EU20GhKOWq5myH+YUs5QfaBrdvqOCUSHdQF6MdB2Zo55RLizlV8ZLV6CQs28SoQ1gFRQrWTmy",
+	"9kcj3MaqRxWNE4y9maB3plcqDBF+K3Fm+8NXmAHCiOH8Tn0s/82IErbzDbp8M0H/AoVAXB93C2+hRMja",
+	"irVG7rDUf3Cm2PRqG05QCBcYocqBIDsaya55bkN/l8Xui4Qgga4puM3S2FWRkz+KRjjLBu2tXFj/pfag",
+	"NTrsdk/Q7m0g4+n8xK0Wx1uI8ztIK2wlKOJ3pPD7E3f0RceIer0JBP1V1kO4K4r4273QuM/vx7SrI5qL",
+	"vX7gR92PaRw7oYgBm+aF3S2duw53UB17MWuvBy3dZn17ELhrFwJr7nefK6yDsyx4YCCkPR0Z1inedzn7",
+	"MdCT0ud41u+cOu3utsc4vnP7KA7ZqnJ+w23IN37Hh1rAQyxV3cOO0blL3zgSY8TKLpsYNR9bvRKoeToL",
+	"jsPFMpXQk+Rb+75NpYMNUavoDDvbz/s0tm45MkLaRp0zrZiHeb+3qNSA6rZwc1flXX1Xx+urhmqjibL6",
+	"qQ6aCO1VRIOVujxfZ9t09noRWeZU0gdKVAA+L9RpAamfizJPRKmElfuxo9kN7t2i2aVoHaLbt2rxqg/b",
+	"9LjOUaAVYmO65xtfE7A66iKkD+ZdYRLVV2ptBodE7NHFHl18FehivulJ7cE8SWEGPW/Y6JrwA1rmvxdw",
+	"eWkd62mEDig0DE/U0SVDfepubN6Qjf0eJHQYrn/nc5baj14L+CGRLZ+yk29RwPaN/ZN4prMBPCvKZACQ",
+	"qrkoQEA7I6m5C6eqqp8LqOoKPjOk2gZ0ZHVOnIFBYAcfSfo0KNxAf4AKRhfmgHUTQRjb/zLdhhpCZogV",
+	"5PIcVy3H1d6K75lzhfr2s7efLMz7jt7qAXcRQmPC1PIftef4R2pLKo+e2SrBAg0A0G7lRRlYwNdqK5pr",
+	"F46TH2LLYl6VX/FiPs8hP3QdX9YN/HzqUcd30yARdcgQv2i30BhIEy0pcSAIsEGiQhZ0q5QmDFkQk0rI",
+	"QB8VJ5jLfD5qy9CU0TPpnFdkdMlUqhkdZ5TDo9D1V15XP6C+ch0Lhkkmza3pTKMaHlX5TpTConlZ7SQ0",
+	"HLCTXvF2Q4B9Ma74vJFG9ZA7aKp6j1S1dcBX2OfDGzm4ZqGQSLmGN51NqqXAc3oPTuV/5up5hOiaCOEk",
+	"4VuR5Qq4Jgmv7dGSZukzHVm7kpLqsqInf/incdwdw3lDf+ydB9OXHCDVs645pI4vqVjBh5OzjkYNI3U0",
+	"Zt7WXOXNe4v7DFKOlRHmtR9PZoElryTAhegJRTGEJGM8lHSopAKkQ8NSopHop6wusdQwuUh2HwrEH7r7",
+	"pZrymf9l9X+oASmwi6xcknzYmaFfYH7BOazn2Qbp78xevqkXJ4LcQxWh4QeL1nkTU8Ll9qvu+ZrwOayw",
+	"3LcPCtEr079PlGId5p7tSi+VmaFKKrPlh1MX5OqDdvXf4oyDDjZwGrHTAWkolqgzQuqX1aavqzWd2mXD",
+	"OZLCiC7QooQs1PG8M/LAtSYdMmg0hJnIgY2VlHuOKddhLBly6Dkup0t00KQmemvibAuOuhYM8JrbE3HC",
+	"OxFXWU2+ElCPieCoILn8v+YG42Kdb5BS4S1St8fMhkVO6QgkHT1ku+SEaDGU8Hvzlj6oN5IHug53aVp2",
+	"cYRxm1UtOTm2qgcJvw85Lj9HMFU0EvAoDmSLuwddMTd/nQl6stGxyp9ebyK0Y6/8cyP18tbf1PRE1pae",
+	"wjFZOhOt42NXWvH19d/kgv34RsbcRUhhcBVTLfuWrCC5M8JywXCZoozcAcI2BVtNkVremj34ijobDDBR",
+	"rcnYIGxCpRV9yC+btBKhRC5hUuoK6EM9f4hLGUD0foLNCcltssgqk9iNkxYyQlW+SdValco0oVm5NslB",
+	"3DydNn0SfYi8RILvtTPey+un8mV5NcmpdBIX3gEUZuGJSWOk0xahSxNF0sgNXFTMqhlK6GCiZkEuSJYh",
+	"YhIMh4PDbCf04g+OEQvzhROJdVs5m25H57fGa3c7im69ZEnqXZVPbua9l9mT1Pvp4fn0UL1Sfiz9bDI7",
+	"Vo/U2t5KEXTbSrCpSl5BwQkao+lsTH/XDchldmp5+vB0m4+iwVxdj5KkkR1l5A4rcscQqV5Hzc5Fuhs5",
+	"m0Z6cqJ6HiI15EhZHF7/1aNgZz9zzNfWKKwtut/NiphT4RFlPZ+W3NWMnaOfr994uT9bR9ZC0CAjXSni",
+	"5JuGFzhS0lI7m6V0IbmUQF6njp63B0/SDuOEpJX6J83U35X/WR3VQgxw6mXOaw221aqpcbgnP7QO253M",
+	"VTt6oFWU3lC3cmDkGo5ralHZLk02bpdaej1GSnVopUG4xKpcndAygrehP1u6juRoXmZ3DZVpknp168xr",
+	"peekvjLfKFVoPiP5MrLR6nqDiUvClWssTU+7/4TR1U/XJmF7hOBRMJwIV0tmeKOMc1gXmXJ2GSr54eLf",
+	"f/r55vr/fXv5w9vKx2Sgkf2olcAyd84paO1hEiaoCqS8UmpSaTkL2equ6Mhp4/SCPNXrhjXHqrITdMGN",
+	"pwUzcSDB2zjFAkeeHJDvbG5mOT0+TBAqhFjHliIsBE5Wa8jrrzAqVlRQOaVXb75t8TRGf716+12Ern78",
+	"LkLfXX4bSePwyimNhd5NmMboHfkm0nkYJPQVvDrFs9K5Wrs1pknqNvo8XlzTiqLHwGzqffuEylQV8jer",
+	"kzKb5m+MaGj2QPFBh5SvpzqU8K5nzl2Lc05yrOB7YI+z6uTQofsJF/XzD6HAaKWqFXN1Keubi/ffvb25",
+	"zePpQTw7kMoWaWSReyoWIYS0lr356ebih/rfUSS33X+AfClWo/OT4+PDky+uhknqj2ngQbYBmxEN+Z4O",
+	"EuWuaXv5BmHOydI5RmsXrE9qO6lpKwd/NDqaHoaLO8JASg9KUSbBk/7meMg3Up/0CoiGptgm4Rsagyur",
+	"fKvRjnNpuuW6MnVIO4eHbGPr1XHKjSw3mKNrYPfAxtfyy7fye26PM6vKbAZFktq8rdXBO6MydPe0LJbL",
+	"W5lDXsP2oQ2eljJZkqZUFLhWBqbYZRpV+sXGel+arxat2qoD+8rt4KT4WUGWTtDrjMhhqVj7tXuiVY/Q",
+	"aj7MxVhNwPjyjdX6ylzF8gqFPJXWUblY1G5FpTilxI9jXZWOlas8xObgnvoGUtNadWiFqNQ+WDZUZ6SV",
+	"M2k6KImDCyz1t8IuD0S5z7KNXFbTnjbJTPLSTv+KJpBBJ9NMD3xz3ZxLs6sxKEyvw+/iZHDpcX0M6lrt",
+	"DlDJSAlvbCI1WrY0tFvL78MkY8hVChhFlMozEFhJTXG8nyRshzXJ1T32CHJ4yNh2Z5TSa6rpcS1awrbo",
+	"OTqa3eaq7HmD6W5zybnnSJnlhmmVBTxEgSijucuaN5ysHt9P1SPN07ej88Pp023eZa2GNonVOuhsKMp9",
+	"1aM3fAnQuT1s5W0t3hw3lJ+rrBLhanvYh0LbM+662Kim9qaodPJsuzizyrKt1x0ZMf72Bi/7ZMVlelH3",
+	"cqct3Bq2fZbIhmAkpByNAt8owckKUpTQor4ExB1IkMMuF+MfaQ7jd1gkqxc8LlukC5+ltgJZZWfjJRz8",
+	"710/DB+6rYf+FI0OQ7tqsljvpEkG0OFqom9rrkYwjb05JyOFLrFSfhqvb92bGi7t6y8CGLDNYtXp211y",
+	"WttU0ItGh+cbkwa6kcav9v+qoQpGlkslzuVDVZr3s9g3VS+/ag67MmfqnKnSp4g1IGieSyc5F44KNNtB",
+	"5m0XHtCiftTR1XuZX/5Pnz3rXYKLosvBpRe/wst56p08VASgYhDBTR9uTu2rIlhP1bm8juMwkeNWv/zt",
+	"w1/t5TEpVsdvh7vn6rx3nRESlr5deq4TvHU4X9X7AdG1Xl3VeLRbvWszVFE9dGX9qtNJ6l9zyGi+lJbZ",
+	"5JnhKs4EhHPbHceOs4Hk4uRoFAw6LrOuW0oMUagSikr0ujQWmUkhOU5plqlzEL+VmAlgYxNEBLutPBcM",
+	"C1j2ZFTU85fQ9Vzdn+InOaty0bu3CfFyrX0So2iksGpgu1PK7KJgCvoErFEwNg5GepGlFK+uljNdqivw",
+	"FySQS2SrV1zaeIE1YaXMW0Ky9rU6GV4u9ckfXvKCJISWtbWIsEC/A6N1hrvcpG61CYXaMQryu6thp//c",
+	"GwU4IguEi4LR+4az/DAYUr9DKJoTtDck3mw4C0XOJQh+zfFAFlLzGG6KCyxK55qfe5UaV6I7u2YV5DUp",
+	"oMzqRNU0RlXeCuUbT/9e8taOkPko6E20DP7MHSkpDBfSplFYRZkEkZ46P7VvqG14LDKc466rbjftqyvq",
+	"XfnI28B1Q5WCTVWja68ByVN4rCOcgjDHdsQXbnE0jWbRYUCEOcvfOla6s/DW+riDCk+Hy/Fhqq0RMYkF",
+	"ZTgNxj0NlIm1vmwKQTOwPmJxZE6Z94GWvqkzmdmeycFN/7m+lMEs44cB4toivk6w5WHK5j6vE+zhL839",
+	"9Bmhjo3T0vqySEeMyhaDUb3DYiF1+98oDNS7TKmzSH6Mdb1/GoxZ3YUKelZ+NnDlhx3v6Qadz7cjc1rT",
+	"RqOENTC2JpQfYuaFbMrdL0nqaKnfKBx2687eIvwiFmG95rvLx0FcUsdN+DTzVbJIiCtM8puedGy6gI97",
+	"lasStyglciP3zDPJlybwQO/7FMTPDKxFsjO83li01PTn63dqtpUgtamG1Pw1Tr63Jm7vatm7Wvaulr2r",
+	"Ze9q2bta9q6Wvatl72rZu1r2rpb/Ya6Wyi4HFS5WWYBf0Lpsm3/WjpTaZphDpYr2ygFSHXu/Ktc4r/K8",
+	"VhalqtTotajCEq4NabO4GwWnMJdzYgozcAFLWmqjRueBUAfRIn0mzRx1r65iVqfGCBeQJxt9ek2HAUqt",
+	"qI7f62JRlY8uBH7KXJDMgygBV5GetwEGbEi9d+YIq5V0yGzUVQWTu9Uf2n4re1iDAvnUYILRh89tVTok",
+	"1QEnkgyT9ZZzz4aG1A0KuvjwY8+SHtPh9Zviw+s3lLml/lp6m/K7NdA3gQww74VL4ca7Ava7kq/aamSV",
+	"DSjqsGSwZv2g48Kf6tsI5YDZ2Pn/HjKamAObJS9xNjb8qv6M14SvZXyY5GnJzWOVatJXS1Vtz8vh9RJW",
+	"TWRMMMOUA4ycFeGCdmXE15cvqLwevhjxb8Po8WpsuYHJLG5kWS2SxvMacnXX0i42RvVZiCZwH7/oHiqK",
+	"FfgO8uHMYtrsImF9A9OfuR2S32HM7yB10JDRFCbkrel33MkJY2eojWdbdiF03DwlKL1zZmdQ8uNhhxe3",
+	"ZVtusPvLXK/Un+rZ5lNZUSfA1pl/vw/Gbzj9hOzPHvtGOhuGowu2cOzuSQ+H5jnspwnDoo4UGJgT24CH",
+	"Lhnwsj6FQVcz+Ie/NGoYesLUlPdSGxHmikrem35Qfi1vXijBA8LDMwz2+mnq+FR1tKjuVA+W3DV5XaPR",
+	"rzUl4R767aHfHvrtod8e+u2h3x76/Y+Efn0Irt8LKks0XZx27TozDuKq6iawO1Az2B0m81q+lhX4259G",
+	"MtjFUddF51SfBK6eGvrhiIiO4BeD8lQjXy/Ue4kbBDvl8oUVxVVUk6PgGlwulT24J9AcUc31QcId+Yco",
+	"BWP9xX2CdU1ymwhiui2XQtXml7+pb4+m92h6j6b3aHqPpvdoeo+m92j6oTelhhpwMxHTs6G3/PasT1a4",
+	"kEAO3M73fINw7mPn5p0PFoZ343gtgnoS912kKUc4ILaqOG1fF1SN9WN32+4evv/h4HutAPdAfg/k90B+",
+	"D+T3QH4P5PdAfg/kvzogb0DYS0F5H3zryjmieR8CtzG/PQhczxq3s8brSWueycLVGlopviA5zsjvFuzp",
+	"A5e+9Jkg04JzK4IkXBMJogJ762MiZt38kk7Qr4kIthKqVaO+scGRXD1Wwhs7N3sr4XNYCS7ptSfUvnWP",
+	"920PiXZFuXn6TDFK9RGACNlVUo9Upa2TDX25Q//LraFqmvfm0N4c2ptDe3Nobw7tzaG9ObQ3h742c6g6",
+	"fvhltzZwxgCnG0sR6pyhuX/C2eoQjoxpWFpvTMBQw8qSB4UPOFmXGRY9KX2u9YlO/3ZBjBKcp+oCP+fu",
+	"Rxsiz2BJuACm49Jlf3UqG/k/zjdipZc3T6UoLDDzc2cYcG7SUFuh5Z/Z1EYSsQhHmRlqYjhkmlKq7Pv+",
+	"hZZV/xckE8DURVkPK5Ks1MUGgYary/9qK9JJa1VfOPjeuQDK6ZT63Iyy577CsJ0nV+jaLtBLmVPzMrkD",
+	"cU1+B+8CTJm4ok2GDyQVq2rMegZSInlrXgqVr0nVpo5HpCBnhKvVY6AGXt+PFNuSk5FjjgTlmV6ZACGq",
+	"peXBRa2zQYRvMZXKHdaF2Mjm/fmQhBLmQX1vg72CT11u1QIDu2sXkvrn4jvw8HNkejjfxnOSAWiyN0Su",
+	"EwMNSBsVjQQNt5XhZ8xkCEDY/vXaCS49NNLRNQjHQ6qD7pJS2Xsu9SfyEpgdMzD4YtPmJjAizzCjfhUh",
+	"DoCkDHhN8wVZWsGCC4IKnNzhpW9uaBtNltcuoDvYPFCW+mk1jHfHeWIhyix++hBEbLTw5MQ0jobdCOjc",
+	"OseWwAXSF5s6J9InOhWSEQXxFj9FIDOGnOX/AqeBHscWCuwaflQ92GYqpZAJ/26WcJIK0itocIPkg3JC",
+	"ZaXpSVijK6qgi6fhwuZEDg9e14+PQqVo5t9Ddni0DeAOgq07ST26CGj97clPhlg3rqYcfpNmrhN6GG2r",
+	"1Wak8QzJbZ4vQeukXz00ZJVbTULHQ1ZrOmSxZqFCgnpljuPn3Xqah3JYXWtEW61YJUrrNfNHL/OsbSfC",
+	"NeDcK3Y0DeTwWhO/0Ow0PHyBM6/c6WzYDNAs3T7iFo32j/dw2HgPX3K8JwPH26/IA3yhUxNtu1+2N4mg",
+	"f4e94ijnrhecMMr9DDQvIJ2brBUu1eStuC+t1pCEWYPORQ8yjK2FWF8g33sLo7Z6KGuwqEE7DAZZzqGk",
+	"Q2BMto7UQ9ZO4kHb1DF5YUBaZ3MbfithrefkRDf1dYCmhLrcT5t20rotuZOoTqVkcgmrfT7bdu8T4UoH",
+	"xSYKRnZrxT4wOhm1qCUaJQyw2Lb7YOdQejKsX2CnLRQiwVK413yF5c1ndOE3pQfqq/DjZDaP51OI0xin",
+	"x/OjZDpPj+fx4iiJFzGcLg5htjjFrxYni1fz6Xy2iOEsOU5P8Kv5aXKWxsGuWUIchDMaiGL2LJZ9iga0",
+	"1JWPIEzU2k0ZzuWsV4tbe6TPVjE3zeXwKFpN6B1vyRaKP4mXgMs4t7R0R1zgTW12mh12ZwY7nCSWaZ7n",
+	"H9mdHT7VNktwYSyeF7HShlg800/agd9Ljv9ekqPLx21nMSgGurV6W3k3JIyVFFhruVblrvY9+Ggeb89/",
+	"0k4a36c3/wZsaDRMoNpAEEz9clAkzJfKpr7nxj8GNwZZcAdEvPWSB9zBiQ8wX1F6NzQbZyU07HfNZEgc",
+	"EqZVcIs3f7FNfSZMO4B0Ta+fTbnmEmG3EzUBtK49745B2WXX2/TZJ7bpIoYjfJaMXyVHMD6an8L4LD0+",
+	"Hs/w6eJkES9m6TS4C62Xp4P11Dsk79u3IV7VrebyAm7tqZVr35xO0pzNVrsly8KN/vz+B/cu86ufrm+q",
+	"bYZ6uCshCn5+cGCeTBK6PlC0VF1K8nIYuuKIvlReLT4Ygp2xGq0wqXbvAxflR61r8u3/NvaBMuvyYZAC",
+	"rO0l4v6l/YTbmcRc3bIfqWWF1KLTfxsbbhxfk2WORcnAXnmvvNVaDP8fBddl+RU8ou/fXbweX39/4cjn",
+	"upobsgYu8Low1UQqowUj1FzaL0vPabqZoG910vYUMnIPjBg0wUAwYrdF4VHvuRCcoTlO7uhioW6IydEd",
+	"1E7nFHA6zkAIYCgjvGPn1JM6L7FpGpIBJuYzJAH8BXUemBWtEH21oMF7INYktztNbSHyTK5eQi5ny3I0",
+	"1eEtk0b45sku7IznnGalACQ5VgXESc59SS6vBHXJiL8VqT7/5/ODg87bdrxdIzmGSqJ/+PKG1F5d7dXV",
+	"pxluZjwagqmsj2a++gw4ZxK2W3CmsA8VD6Tg1XJ3GGx0RL1CqubaDu2Ekvy7LoTvUO2Dj2+c1j8PkjRd",
+	"2rofYcuhNU59J89JyGc/hOPNVG10jLn+YEd+D/davULyC59UW+qpVel2lredjtTxiTbEeGMHpaFBkONk",
+	"VMjb/itfZBE754aGAmO5BxViVtA8hRQdxzG6zAWwHGfoGph8q5sJ3pS1yShO+yaQm2DdFrLsCmS8Mlm9",
+	"dOz1HORvMwZII4VhdJCnxw3B4cmyoW4bvrwcKpprUSmFgFqy4H1fzwXQakw1z/fg6CaC65Yz9s7BIsOb",
+	"nvhEyFUGBrcHG4SXmORRFezHgK+UHexwcC9ydASOvEVQdWGnU1a2J18uXe7sE4DJXvrtpd9e+g3GYxXN",
+	"Et4coJY8fb48X1CFb94hgld914bAWp0rbIpVKZdawq8hUW2m8RQyEBBCbbJuB/nVN+m4II4ioqOZzaL4",
+	"svONqtxKz11TjJuGv5ysPOrHyd6Ud6+kLd5aw9Y6NWZY0tXT/x8AGDHoTRz4AAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
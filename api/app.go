@@ -1,7 +1,10 @@
 package api
 
 import (
+	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -9,10 +12,43 @@ import (
 )
 
 func Serve() {
-	http.ListenAndServe(":8080", GetRouter())
+	database := &Database{}
+	rulesets := LoadRuleSets(database)
+	go NewTierQualifier(database, rulesets).Run(time.UTC, nil)
+	// gRPC and REST share the receipt storage and rules
+	receipts := NewReceiptHandler(database)
+	receipts.RuleSets = rulesets
+	receipts.Text = LoadTextParser()
+	receipts.Rates = LoadExchangeRates()
+	if dir := os.Getenv("BLOB_DIR"); dir != "" {
+		receipts.Blobs = LocalBlobStore{Dir: dir}
+	}
+	go func() {
+		log.Fatal(ServeGRPC(":9090", receipts))
+	}()
+	http.ListenAndServe(":8080", NewReceiptRouter(receipts))
 }
 
+// GetRouter initializes a router with empty storage and the RULES_FILE rules
 func GetRouter() chi.Router {
+	database := &Database{}
+	return NewRouter(database, LoadRuleSets(database))
+}
+
+// NewRouter initializes a router for the API
+// database: the receipt and member storage
+// rulesets: the rule set registry
+func NewRouter(database *Database, rulesets *RuleSets) chi.Router {
+	receipts := NewReceiptHandler(database)
+	receipts.RuleSets = rulesets
+	return NewReceiptRouter(receipts)
+}
+
+// NewReceiptRouter initializes a router for the API around a ReceiptHandler,
+// so it can be shared with the gRPC server
+// receipts: the receipt handler, with the storage and rule set registry
+func NewReceiptRouter(receipts ReceiptHandler) chi.Router {
+	database, rulesets := receipts.Database, receipts.RuleSets
 	router := chi.NewRouter()
 	router.Use(middleware.Logger)
 
@@ -30,17 +66,153 @@ func GetRouter() chi.Router {
 	})
 
 	// API Routes
-	router.Mount("/receipts", ReceiptRoutes())
+	router.Mount("/receipts", ReceiptRoutes(receipts))
+	router.Mount("/rules", RuleRoutes(receipts))
+	router.Mount("/members", MemberRoutes(database, rulesets))
+	router.Mount("/rulesets", RuleSetRoutes(database, rulesets))
+	router.Mount("/plugins", PluginRoutes(database, rulesets))
+	router.Mount("/reviews", ReviewRoutes(receipts))
+	router.Mount("/audit", AuditRoutes(database))
+	router.Mount("/analytics", AnalyticsRoutes(database))
+	router.Mount("/leaderboards", LeaderboardRoutes(database))
+	router.Mount("/webhooks", WebhookRoutes(receipts.Webhooks))
+	router.Mount("/graphql", GraphQLRoutes(receipts))
 	return router
 }
 
-func ReceiptRoutes() chi.Router {
-	router := chi.NewRouter()
-	// request validator
+// LoadRuleSets initializes the rule sets with v1, the default rules plus
+// the rules in the optional RULES_FILE, and the plugins in its plugins
+// directory, or the optional PLUGINS_DIR, for every rule set
+func LoadRuleSets(members MemberDirectory) *RuleSets {
+	var config RuleConfig
+	if path := os.Getenv("RULES_FILE"); path != "" {
+		var err error
+		if config, err = LoadRuleConfig(path); err != nil {
+			log.Fatal(err)
+		}
+	}
+	rulesets := NewRuleSets(members)
+	plugins := config.Plugins
+	if plugins == "" {
+		plugins = os.Getenv("PLUGINS_DIR")
+	}
+	rulesets.UsePlugins(plugins, config.PluginLimits)
+	if _, err := rulesets.Register(config); err != nil {
+		log.Fatal(err)
+	}
+	return rulesets
+}
+
+// RequestValidator validates requests against the OpenAPI spec
+func RequestValidator() func(http.Handler) http.Handler {
 	spec, _ := GetSwagger()
-	router.Use(oapimiddleware.OapiRequestValidator(spec))
-	handler := NewReceiptHandler()
+	return oapimiddleware.OapiRequestValidator(spec)
+}
+
+func ReceiptRoutes(handler ReceiptHandler) chi.Router {
+	router := chi.NewRouter()
+	router.Use(handler.limitAttachments, RequestValidator())
 	router.Post("/process", handler.PostReceiptsProcess)
+	router.Get("/stream", handler.GetReceiptsStream)
+	router.Post("/import", handler.PostReceiptsImport)
+	router.Get("/export", handler.GetReceiptsExport)
 	router.Get("/{id}/points", handler.GetReceiptsIdPoints)
+	router.Get("/{id}/breakdown", handler.GetReceiptsIdBreakdown)
+	router.Get("/{id}/attachment", handler.GetReceiptsIdAttachment)
+	router.Post("/{id}/rescore", handler.PostReceiptsIdRescore)
+	return router
+}
+
+func RuleRoutes(handler ReceiptHandler) chi.Router {
+	router := chi.NewRouter()
+	router.Use(RequestValidator())
+	router.Post("/simulate", handler.PostRulesSimulate)
+	return router
+}
+
+func MemberRoutes(database *Database, rulesets *RuleSets) chi.Router {
+	router := chi.NewRouter()
+	router.Use(RequestValidator())
+	handler := NewMemberHandler(database)
+	router.Get("/{id}", handler.GetMembersId)
+	router.Put("/{id}", handler.PutMembersId)
+	router.Get("/{id}/tier", NewTierQualifier(database, rulesets).GetMembersIdTier)
+	return router
+}
+
+func RuleSetRoutes(database *Database, rulesets *RuleSets) chi.Router {
+	router := chi.NewRouter()
+	router.Use(RequestValidator())
+	handler := NewRuleSetHandler(database, rulesets)
+	router.Get("/", handler.GetRulesets)
+	router.Post("/", handler.PostRulesets)
+	router.Get("/{version}", handler.GetRulesetsVersion)
+	return router
+}
+
+func PluginRoutes(database *Database, rulesets *RuleSets) chi.Router {
+	router := chi.NewRouter()
+	router.Use(RequestValidator())
+	handler := NewRuleSetHandler(database, rulesets)
+	router.Get("/", handler.GetPlugins)
+	return router
+}
+
+func ReviewRoutes(receipts ReceiptHandler) chi.Router {
+	router := chi.NewRouter()
+	router.Use(RequestValidator())
+	handler := NewReviewHandler(receipts)
+	router.Get("/", handler.GetReviews)
+	router.Get("/{id}", handler.GetReviewsId)
+	router.Post("/{id}/claim", handler.PostReviewsIdClaim)
+	router.Post("/{id}/comments", handler.PostReviewsIdComments)
+	router.Post("/{id}/decision", handler.PostReviewsIdDecision)
+	return router
+}
+
+func AuditRoutes(database *Database) chi.Router {
+	router := chi.NewRouter()
+	router.Use(RequestValidator())
+	handler := NewAuditHandler(database)
+	router.Get("/", handler.GetAudit)
+	router.Get("/export", handler.GetAuditExport)
+	return router
+}
+
+func AnalyticsRoutes(database *Database) chi.Router {
+	router := chi.NewRouter()
+	router.Use(RequestValidator())
+	handler := NewAnalyticsHandler(database)
+	router.Get("/points", handler.GetAnalyticsPoints)
+	router.Get("/receipts", handler.GetAnalyticsReceipts)
+	return router
+}
+
+func LeaderboardRoutes(database *Database) chi.Router {
+	router := chi.NewRouter()
+	router.Use(RequestValidator())
+	handler := NewLeaderboardHandler(database)
+	router.Get("/members", handler.GetLeaderboardsMembers)
+	router.Get("/retailers", handler.GetLeaderboardsRetailers)
+	return router
+}
+
+func WebhookRoutes(webhooks *Webhooks) chi.Router {
+	router := chi.NewRouter()
+	router.Use(RequestValidator())
+	handler := NewWebhookHandler(webhooks)
+	router.Get("/", handler.GetWebhooks)
+	router.Post("/", handler.PostWebhooks)
+	router.Delete("/{id}", handler.DeleteWebhooksId)
+	router.Get("/deadletters", handler.GetWebhooksDeadletters)
+	router.Post("/deadletters/{id}/replay", handler.PostWebhooksDeadlettersIdReplay)
+	return router
+}
+
+func GraphQLRoutes(receipts ReceiptHandler) chi.Router {
+	router := chi.NewRouter()
+	router.Use(RequestValidator())
+	handler := NewGraphQLHandler(receipts)
+	router.Post("/", handler.PostGraphql)
 	return router
 }
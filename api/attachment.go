@@ -0,0 +1,233 @@
+/*
+attachment.go contains the photos and PDFs of receipts submitted alongside them,
+kept in a pluggable blob store by content hash
+*/
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/go-chi/chi/v5"
+)
+
+// attachmentTypes are the content types of attachments accepted, as detected from their content
+var attachmentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf"}
+
+func init() {
+	// attachments are validated by PostReceiptsProcess
+	for _, contentType := range attachmentTypes {
+		openapi3filter.RegisterBodyDecoder(contentType, openapi3filter.FileBodyDecoder)
+	}
+}
+
+// ErrBlobNotFound is returned when a BlobStore has no blob for a hash
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores blobs by the hex SHA-256 hash of their content
+type BlobStore interface {
+	// Put stores a blob, keeping the existing blob when one is stored with the hash
+	Put(hash string, content io.Reader) error
+	// Open reads a blob, returning ErrBlobNotFound when none is stored with the hash
+	Open(hash string) (io.ReadCloser, error)
+}
+
+// LocalBlobStore stores blobs as files in a directory, sharded by the first
+// two characters of their hash
+type LocalBlobStore struct {
+	// Dir is the directory of the blobs, created when the first blob is stored
+	Dir string
+}
+
+// path returns the file of a blob
+func (s LocalBlobStore) path(hash string) string {
+	return filepath.Join(s.Dir, hash[:2], hash)
+}
+
+// Put stores a blob in a file, written to a temporary file first so a partly
+// written blob is never read
+func (s LocalBlobStore) Put(hash string, content io.Reader) error {
+	path := s.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), hash+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Open reads a blob from its file
+func (s LocalBlobStore) Open(hash string) (io.ReadCloser, error) {
+	if len(hash) < 2 {
+		return nil, ErrBlobNotFound
+	}
+	file, err := os.Open(s.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+// Attachment describes the photo or PDF of a Receipt
+type Attachment struct {
+	// Hash is the hex SHA-256 hash of the content, its key in the BlobStore
+	Hash string `json:"hash"`
+	// ContentType is detected from the content
+	ContentType string `json:"contentType"`
+	// Size of the content in bytes
+	Size int64 `json:"size"`
+	// Filename is the name of the uploaded file
+	Filename string `json:"filename,omitempty"`
+	// UploadedAt is when the attachment was submitted
+	UploadedAt time.Time `json:"uploadedAt"`
+}
+
+// readAttachment reads an uploaded attachment, checking its size and content type
+// part: the attachment part of a multipart request
+// Returns: the Attachment and its content, and the status code of an error
+func (h *ReceiptHandler) readAttachment(part *multipart.Part) (Attachment, []byte, int, error) {
+	content, err := io.ReadAll(io.LimitReader(part, h.AttachmentLimit+1))
+	if err != nil {
+		return Attachment{}, nil, http.StatusBadRequest, fmt.Errorf("Invalid attachment")
+	}
+	if int64(len(content)) > h.AttachmentLimit {
+		return Attachment{}, nil, http.StatusRequestEntityTooLarge, fmt.Errorf("Attachment over %d bytes", h.AttachmentLimit)
+	}
+	if len(content) == 0 {
+		return Attachment{}, nil, http.StatusBadRequest, fmt.Errorf("Empty attachment")
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	supported := false
+	for _, allowed := range attachmentTypes {
+		supported = supported || contentType == allowed
+	}
+	if !supported {
+		return Attachment{}, nil, http.StatusUnsupportedMediaType, fmt.Errorf("Unsupported attachment type %s", contentType)
+	}
+	sum := sha256.Sum256(content)
+	attachment := Attachment{
+		Hash:        hex.EncodeToString(sum[:]),
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Filename:    filepath.Base(part.FileName()),
+		UploadedAt:  time.Now().UTC(),
+	}
+	if attachment.Filename == "." || attachment.Filename == string(filepath.Separator) {
+		attachment.Filename = ""
+	}
+	return attachment, content, http.StatusOK, nil
+}
+
+// readMultipart reads a receipt submitted as multipart/form-data, with the Receipt
+// JSON in the receipt part and the optional photo or PDF in the attachment part
+// r: the multipart request
+// Returns: the Receipt, its Attachment and content if any, and the status code of an error
+func (h *ReceiptHandler) readMultipart(r *http.Request) (Receipt, *Attachment, []byte, int, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return Receipt{}, nil, nil, http.StatusBadRequest, fmt.Errorf("Invalid request payload")
+	}
+	var receipt *Receipt
+	var attachment *Attachment
+	var content []byte
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Receipt{}, nil, nil, http.StatusBadRequest, fmt.Errorf("Invalid request payload")
+		}
+		switch part.FormName() {
+		case "receipt":
+			receipt = &Receipt{}
+			if err := json.NewDecoder(part).Decode(receipt); err != nil {
+				return Receipt{}, nil, nil, http.StatusBadRequest, fmt.Errorf("Invalid request payload")
+			}
+		case "attachment":
+			read, data, status, err := h.readAttachment(part)
+			if err != nil {
+				return Receipt{}, nil, nil, status, err
+			}
+			attachment, content = &read, data
+		}
+		part.Close()
+	}
+	if receipt == nil {
+		return Receipt{}, nil, nil, http.StatusBadRequest, fmt.Errorf("Missing receipt part")
+	}
+	return *receipt, attachment, content, http.StatusOK, nil
+}
+
+// limitAttachments rejects multipart requests over the attachment limit, before
+// the request validator reads them
+func (h *ReceiptHandler) limitAttachments(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+			// room for the receipt part and the multipart headers
+			limit := h.AttachmentLimit + 1<<20
+			if r.ContentLength > limit {
+				http.Error(w, fmt.Sprintf("Attachment over %d bytes", h.AttachmentLimit), http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetReceiptsIdAttachment handles GET requests for the photo or PDF submitted with
+// a Receipt, with its content hash as the ETag
+// Response example: the attachment content, e.g. a JPEG
+func (h *ReceiptHandler) GetReceiptsIdAttachment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	attachment, err := h.Database.GetAttachment(id)
+	if err != nil {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+	etag := `"` + attachment.Hash + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	content, err := h.Blobs.Open(attachment.Hash)
+	if err != nil {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+	defer content.Close()
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", fmt.Sprint(attachment.Size))
+	w.Header().Set("ETag", etag)
+	if attachment.Filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.Filename}))
+	}
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, content)
+}
@@ -0,0 +1,134 @@
+/*
+attachment_test.go contains functions for testing receipts submitted with a photo
+or PDF attachment.
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// png is the start of a PNG image, enough for its content type to be detected
+var png = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+
+// pepsiReceipt is a receipt earning 31 points
+const pepsiReceipt = `{"retailer":"Target","purchaseDate":"2022-01-02","purchaseTime":"13:13","total":"1.25","items":[{"shortDescription":"Pepsi - 12-oz","price":"1.25"}]}`
+
+// Upload submits a receipt with an attachment as multipart/form-data through the router
+func Upload(t *testing.T, router chi.Router, receipt string, contentType string, filename string, attachment []byte) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if receipt != "" {
+		part, _ := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Disposition": {`form-data; name="receipt"`},
+			"Content-Type":        {"application/json"},
+		})
+		part.Write([]byte(receipt))
+	}
+	if attachment != nil {
+		part, _ := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Disposition": {`form-data; name="attachment"; filename="` + filename + `"`},
+			"Content-Type":        {contentType},
+		})
+		part.Write(attachment)
+	}
+	assert.NoError(t, writer.Close())
+	request := httptest.NewRequest(http.MethodPost, "/receipts/process", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return ProcessRequest(router, request)
+}
+
+// TestPostReceiptsProcessAttachment verifies a receipt submitted with a photo is processed,
+// and the photo is returned by its receipt id with its hash as the ETag
+func TestPostReceiptsProcessAttachment(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	receipts.Blobs = LocalBlobStore{Dir: t.TempDir()}
+	router := NewReceiptRouter(receipts)
+	recorder := Upload(t, router, pepsiReceipt, "image/png", "receipt.png", png)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	response := PostReceiptsProcessResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/"+response.Id+"/points", nil))
+	assert.JSONEq(t, `{"points":31}`, recorder.Body.String())
+
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/"+response.Id+"/attachment", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, png, recorder.Body.Bytes())
+	assert.Equal(t, "image/png", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `inline; filename=receipt.png`, recorder.Header().Get("Content-Disposition"))
+	etag := recorder.Header().Get("ETag")
+	attachment, _ := receipts.Database.GetAttachment(response.Id)
+	assert.Equal(t, `"`+attachment.Hash+`"`, etag)
+	assert.Equal(t, int64(len(png)), attachment.Size)
+
+	request := httptest.NewRequest(http.MethodGet, "/receipts/"+response.Id+"/attachment", nil)
+	request.Header.Set("If-None-Match", etag)
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	// the part content type is not trusted, the content is checked
+	recorder = Upload(t, router, pepsiReceipt, "application/octet-stream", "receipt", png)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	other, _ := receipts.Database.GetAttachment(response.Id)
+	assert.Equal(t, attachment.Hash, other.Hash)
+	assert.Equal(t, "image/png", other.ContentType)
+
+	// receipts submitted without an attachment have none
+	recorder = Upload(t, router, pepsiReceipt, "", "", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/"+response.Id+"/attachment", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestPostReceiptsProcessAttachmentInvalid verifies receipts with oversized or unsupported
+// attachments, or without a valid receipt part, are rejected
+func TestPostReceiptsProcessAttachmentInvalid(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	receipts.Blobs = LocalBlobStore{Dir: t.TempDir()}
+	receipts.AttachmentLimit = 32
+	router := NewReceiptRouter(receipts)
+
+	recorder := Upload(t, router, pepsiReceipt, "image/png", "receipt.png", png)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Equal(t, "Attachment over 32 bytes\n", recorder.Body.String())
+
+	recorder = Upload(t, router, pepsiReceipt, "text/plain", "receipt.txt", []byte("TARGET"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+	assert.Equal(t, "Unsupported attachment type text/plain\n", recorder.Body.String())
+
+	recorder = Upload(t, router, "", "image/png", "receipt.png", png[:8])
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = Upload(t, router, `{"retailer":"Target"}`, "image/png", "receipt.png", png[:8])
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Empty(t, receipts.Database.GetReceiptIds())
+}
+
+// TestLocalBlobStore verifies blobs are stored once by hash and read back
+func TestLocalBlobStore(t *testing.T) {
+	store := LocalBlobStore{Dir: t.TempDir()}
+	hash := "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	assert.NoError(t, store.Put(hash, bytes.NewReader([]byte("foo"))))
+	assert.NoError(t, store.Put(hash, bytes.NewReader([]byte("bar"))))
+	content, err := store.Open(hash)
+	assert.NoError(t, err)
+	data := &bytes.Buffer{}
+	data.ReadFrom(content)
+	content.Close()
+	assert.Equal(t, "foo", data.String())
+
+	_, err = store.Open("fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+}
@@ -0,0 +1,193 @@
+/*
+audit.go contains the tamper-evident audit log of state-changing operations,
+and methods for handling audit log requests
+*/
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Audit actions
+const (
+	// ReceiptCreated records a processed Receipt
+	ReceiptCreated = "receipt.created"
+	// ReceiptImported records a Receipt imported in bulk
+	ReceiptImported = "receipt.imported"
+	// ReceiptRescored records a Receipt pinned to another Score
+	ReceiptRescored = "receipt.rescored"
+	// MemberUpdated records a created or replaced member profile
+	MemberUpdated = "member.updated"
+	// RuleSetCreated records a registered rule set version
+	RuleSetCreated = "ruleset.created"
+	// ReviewUpdated records a claimed, commented on or decided Review
+	ReviewUpdated = "review.updated"
+)
+
+// AuditEntry records who changed what and when, with the values before and after.
+// Each entry's Hash covers the entry and the Hash of the entry before it, so
+// changing or removing an entry breaks the chain.
+type AuditEntry struct {
+	// Sequence is the position of the entry in the log, from 1
+	Sequence int `json:"sequence"`
+	// Time is when the change was made
+	Time time.Time `json:"time"`
+	// Actor is who made the change
+	Actor string `json:"actor"`
+	// Action is what was done, e.g. receipt.created
+	Action string `json:"action"`
+	// Entity is the kind of thing changed, e.g. receipt
+	Entity string `json:"entity"`
+	// EntityId is the id of the thing changed
+	EntityId string `json:"entityId"`
+	// Before is the value before the change, null when created
+	Before json.RawMessage `json:"before"`
+	// After is the value after the change
+	After json.RawMessage `json:"after"`
+	// PrevHash is the Hash of the entry before, empty for the first entry
+	PrevHash string `json:"prevHash"`
+	// Hash is the hex sha256 of the entry and PrevHash
+	Hash string `json:"hash"`
+}
+
+// digest computes the Hash of the entry, from every field but Hash
+func (e AuditEntry) digest() string {
+	e.Hash = ""
+	content, _ := json.Marshal(e)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordAudit appends an entry to the audit log in the Database, chained to the last entry
+// actor: who made the change
+// action: what was done
+// entity: the kind of thing changed
+// entityId: the id of the thing changed
+// before: the value before the change, nil when created
+// after: the value after the change
+// Returns: the recorded AuditEntry
+func (d *Database) RecordAudit(actor string, action string, entity string, entityId string, before any, after any) AuditEntry {
+	marshal := func(value any) json.RawMessage {
+		if value == nil {
+			return json.RawMessage("null")
+		}
+		content, err := json.Marshal(value)
+		if err != nil {
+			return json.RawMessage("null")
+		}
+		return content
+	}
+	d.auditMu.Lock()
+	defer d.auditMu.Unlock()
+	entry := AuditEntry{
+		Sequence: len(d.audit) + 1,
+		Time:     time.Now().UTC(),
+		Actor:    actor,
+		Action:   action,
+		Entity:   entity,
+		EntityId: entityId,
+		Before:   marshal(before),
+		After:    marshal(after),
+	}
+	if len(d.audit) > 0 {
+		entry.PrevHash = d.audit[len(d.audit)-1].Hash
+	}
+	entry.Hash = entry.digest()
+	d.audit = append(d.audit, entry)
+	return entry
+}
+
+// GetAuditEntries retrieves the audit log from the Database
+// entityId: only entries for this id are returned, every entry when empty
+// Returns: the entries, oldest first
+func (d *Database) GetAuditEntries(entityId string) []AuditEntry {
+	d.auditMu.Lock()
+	defer d.auditMu.Unlock()
+	entries := []AuditEntry{}
+	for _, entry := range d.audit {
+		if entityId == "" || entry.EntityId == entityId {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// VerifyAudit checks every entry of the audit log in the Database is unchanged
+// and in place, by recomputing the hash chain
+// Returns: an error describing the first broken entry
+func (d *Database) VerifyAudit() error {
+	d.auditMu.Lock()
+	defer d.auditMu.Unlock()
+	prevHash := ""
+	for i, entry := range d.audit {
+		if entry.Sequence != i+1 {
+			return fmt.Errorf("audit entry %d is out of sequence", i+1)
+		}
+		if entry.PrevHash != prevHash {
+			return fmt.Errorf("audit entry %d does not follow entry %d", entry.Sequence, i)
+		}
+		if entry.digest() != entry.Hash {
+			return fmt.Errorf("audit entry %d was changed", entry.Sequence)
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
+
+// actor identifies who made a request, from the X-Forwarded-User header set by
+// the authentication proxy
+func actor(r *http.Request) string {
+	if user := r.Header.Get("X-Forwarded-User"); user != "" {
+		return user
+	}
+	return "anonymous"
+}
+
+// AuditHandler handles the access of the audit log
+type AuditHandler struct {
+	// Database is the audit log storage
+	Database *Database
+}
+
+// NewAuditHandler initializes AuditHandler
+// database: the audit log storage
+func NewAuditHandler(database *Database) AuditHandler {
+	return AuditHandler{
+		Database: database,
+	}
+}
+
+// GetAudit handles GET requests for the audit log, optionally for the entity
+// in the entityId query parameter, and whether the hash chain is intact
+// Response example: {"valid":true,"entries":[{"sequence":1,"time":"2024-08-20T05:11:44Z","actor":"alice","action":"receipt.created","entity":"receipt","entityId":"7d4d837b-ef5e-47c0-89a9-889657b66eb9","before":null,"after":{...},"prevHash":"","hash":"9f86d081..."}]}
+func (h *AuditHandler) GetAudit(w http.ResponseWriter, r *http.Request) {
+	response := GetAuditResponse{
+		Valid:   true,
+		Entries: h.Database.GetAuditEntries(r.URL.Query().Get("entityId")),
+	}
+	if err := h.Database.VerifyAudit(); err != nil {
+		response.Valid = false
+		response.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetAuditExport handles GET requests to export the audit log as JSONL, one entry
+// per line, optionally for the entity in the entityId query parameter
+// Response example: {"sequence":1,"time":"2024-08-20T05:11:44Z","actor":"alice","action":"receipt.created",...}
+func (h *AuditHandler) GetAuditExport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	for _, entry := range h.Database.GetAuditEntries(r.URL.Query().Get("entityId")) {
+		encoder.Encode(entry)
+	}
+}
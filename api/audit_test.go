@@ -0,0 +1,114 @@
+/*
+audit_test.go contains functions for testing the audit log.
+*/
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAuditChain verifies the hash chain detects changed, removed and reordered entries
+func TestAuditChain(t *testing.T) {
+	build := func() *Database {
+		database := &Database{}
+		database.RecordAudit("alice", MemberUpdated, "member", "member-1", nil, Member{})
+		database.RecordAudit("bob", MemberUpdated, "member", "member-2", nil, Member{})
+		database.RecordAudit("alice", MemberUpdated, "member", "member-1", Member{}, Member{})
+		return database
+	}
+	database := build()
+	assert.NoError(t, database.VerifyAudit())
+	entries := database.GetAuditEntries("")
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "", entries[0].PrevHash)
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+	assert.Equal(t, entries[1].Hash, entries[2].PrevHash)
+	assert.Len(t, database.GetAuditEntries("member-1"), 2)
+
+	database.audit[1].Actor = "mallory"
+	assert.EqualError(t, database.VerifyAudit(), "audit entry 2 was changed")
+
+	database = build()
+	database.audit[1].After = json.RawMessage(`{"birthday":"1990-05-14"}`)
+	database.audit[1].Hash = database.audit[1].digest()
+	assert.EqualError(t, database.VerifyAudit(), "audit entry 3 does not follow entry 2")
+
+	database = build()
+	database.audit = append(database.audit[:1], database.audit[2:]...)
+	assert.EqualError(t, database.VerifyAudit(), "audit entry 2 is out of sequence")
+}
+
+// TestAuditLog verifies state-changing requests are recorded with who made them,
+// and the log can be queried by entity and exported as JSONL
+func TestAuditLog(t *testing.T) {
+	router := GetRouter()
+	request := BuildRequest(`{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"total": "1.25",
+		"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}]
+	}`)
+	request.Header.Set("X-Forwarded-User", "alice")
+	recorder := ProcessRequest(router, request)
+	id := &PostReceiptsProcessResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &id)
+
+	request = httptest.NewRequest(http.MethodPost, "/rulesets", strings.NewReader(`{"itemRules": [{"name": "pepsi", "keyword": "pepsi", "points": 10}]}`))
+	request.Header.Set("Content-Type", "application/json")
+	ProcessRequest(router, request)
+	ProcessRequest(router, httptest.NewRequest(http.MethodPost, "/receipts/"+id.Id+"/rescore", nil))
+
+	getAudit := func(query string) *GetAuditResponse {
+		recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/audit"+query, nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		response := &GetAuditResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return response
+	}
+	audit := getAudit("")
+	assert.True(t, audit.Valid)
+	var actions []string
+	for _, entry := range audit.Entries {
+		actions = append(actions, entry.Actor+" "+entry.Action+" "+entry.EntityId)
+	}
+	assert.Equal(t, []string{
+		"alice receipt.created " + id.Id,
+		"anonymous ruleset.created v2",
+		"anonymous receipt.rescored " + id.Id,
+	}, actions)
+
+	audit = getAudit("?entityId=" + id.Id)
+	assert.Len(t, audit.Entries, 2)
+	assert.JSONEq(t, `null`, string(audit.Entries[0].Before))
+	rescored := audit.Entries[1]
+	before, after := &Score{}, &Score{}
+	json.Unmarshal(rescored.Before, &before)
+	json.Unmarshal(rescored.After, &after)
+	assert.Equal(t, "v1", before.Version)
+	assert.Equal(t, 31, before.Breakdown.Points)
+	assert.Equal(t, "v2", after.Version)
+	assert.Equal(t, 41, after.Breakdown.Points)
+
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/audit/export?entityId="+id.Id, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+	scanner := bufio.NewScanner(recorder.Body)
+	var exported []AuditEntry
+	for scanner.Scan() {
+		entry := AuditEntry{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		// exported entries can be verified on their own
+		assert.Equal(t, entry.Hash, entry.digest())
+		exported = append(exported, entry)
+	}
+	assert.Len(t, exported, 2)
+	assert.Equal(t, audit.Entries[1].Hash, exported[1].Hash)
+}
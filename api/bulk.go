@@ -0,0 +1,329 @@
+/*
+bulk.go contains the bulk import of receipts from CSV or NDJSON, and the export
+of stored receipts with their points
+*/
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/google/uuid"
+)
+
+const (
+	// CSVFormat is one row per item, the rows of a receipt sharing its id
+	CSVFormat = "csv"
+	// NDJSONFormat is one JSON receipt per line
+	NDJSONFormat = "ndjson"
+)
+
+// csvColumns are the columns of imported and exported CSV, exports add ruleset and points.
+// quantity, unitPrice and discount are of the item on the row, exchangeRate, discounts
+// and taxes are JSON, as in NDJSON.
+var csvColumns = []string{"id", "retailer", "purchaseDate", "purchaseTime", "total", "memberId", "timeZone", "utcOffset", "shortDescription", "price",
+	"quantity", "unitPrice", "discount", "currency", "exchangeRate", "tip", "paymentMethod", "storeId", "discounts", "taxes"}
+
+// csvRequired are the columns an imported CSV must have
+var csvRequired = []string{"retailer", "purchaseDate", "purchaseTime", "total", "shortDescription", "price"}
+
+func init() {
+	// imports are read by ImportReceipts, so malformed rows are reported
+	// with the other row errors rather than rejecting the request
+	openapi3filter.RegisterBodyDecoder("text/csv", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.FileBodyDecoder)
+}
+
+// ImportError reports why a receipt was not imported
+type ImportError struct {
+	// Line is the line of the receipt, its first row in CSV
+	Line int `json:"line"`
+	// Error describes the invalid field
+	Error string `json:"error"`
+}
+
+// ImportReceipts validates receipts against the Receipt schema and stores the valid
+// ones, scored with the active rule set. Imported receipts keep their id when given,
+// and are checked for fraud and held for review like submitted receipts.
+// r: the receipts, in CSV or NDJSON
+// format: CSVFormat or NDJSONFormat
+// actor: who imported the receipts, for the audit log
+// Returns: the ids of the imported receipts and the errors of the others by line,
+// or an error when the receipts cannot be read
+func (h *ReceiptHandler) ImportReceipts(r io.Reader, format string, actor string) (PostReceiptsImportResponse, error) {
+	response := PostReceiptsImportResponse{Ids: []string{}, Errors: []ImportError{}}
+	store := func(line int, id string, value map[string]any) {
+		receipt, err := DecodeReceipt(value)
+		if err == nil {
+			receipt, err = h.Rates.Record(receipt)
+		}
+		if err == nil {
+			if id == "" {
+				id = uuid.New().String()
+			}
+			if err = h.Database.AddReceipt(id, receipt); err == nil {
+				ruleset := h.RuleSets.Active()
+				h.flag(id, receipt, ruleset)
+				h.score(id, receipt, ruleset, true)
+				h.Database.RecordAudit(actor, ReceiptImported, "receipt", id, nil, receipt)
+				response.Ids = append(response.Ids, id)
+				response.Imported++
+				return
+			}
+			err = fmt.Errorf("Receipt %s already exists", id)
+		}
+		response.Errors = append(response.Errors, ImportError{Line: line, Error: err.Error()})
+	}
+	var err error
+	switch format {
+	case CSVFormat:
+		err = readCSV(r, store, func(line int, err error) {
+			response.Errors = append(response.Errors, ImportError{Line: line, Error: err.Error()})
+		})
+	case NDJSONFormat:
+		err = readNDJSON(r, store, func(line int, err error) {
+			response.Errors = append(response.Errors, ImportError{Line: line, Error: err.Error()})
+		})
+	default:
+		err = fmt.Errorf("Unsupported format %q", format)
+	}
+	return response, err
+}
+
+// readCSV reads receipts from CSV with a header row, one row per item. Consecutive
+// rows with the same id are the items of one receipt, whose other fields are read
+// from its first row. Rows without an id are receipts with one item.
+// r: the CSV
+// store: called with the line, id and JSON value of each receipt
+// invalid: called with the line and error of each malformed row
+// Returns: an error when the header cannot be read or misses a column
+func readCSV(r io.Reader, store func(line int, id string, value map[string]any), invalid func(line int, err error)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("Invalid CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range csvRequired {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("Missing CSV column %s", name)
+		}
+	}
+
+	line, id := 0, ""
+	var value map[string]any
+	flush := func() {
+		if value != nil {
+			store(line, id, value)
+		}
+		value = nil
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		parseErr := &csv.ParseError{}
+		if errors.As(err, &parseErr) {
+			flush()
+			invalid(parseErr.StartLine, fmt.Errorf("Invalid CSV: %w", parseErr.Err))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		item := map[string]any{"shortDescription": field("shortDescription"), "price": field("price")}
+		for _, name := range []string{"unitPrice", "discount"} {
+			if optional := field(name); optional != "" {
+				item[name] = optional
+			}
+		}
+		if quantity := field("quantity"); quantity != "" {
+			// unparsable quantities are reported by the Receipt schema
+			item["quantity"] = quantity
+			if parsed, err := strconv.ParseFloat(quantity, 64); err == nil {
+				item["quantity"] = parsed
+			}
+		}
+		if value != nil && id != "" && field("id") == id {
+			value["items"] = append(value["items"].([]any), item)
+			continue
+		}
+		flush()
+		line, _ = reader.FieldPos(0)
+		id = field("id")
+		value = map[string]any{
+			"retailer":     field("retailer"),
+			"purchaseDate": field("purchaseDate"),
+			"purchaseTime": field("purchaseTime"),
+			"total":        field("total"),
+			"items":        []any{item},
+		}
+		for _, name := range []string{"memberId", "timeZone", "utcOffset", "currency", "tip", "paymentMethod", "storeId"} {
+			if optional := field(name); optional != "" {
+				value[name] = optional
+			}
+		}
+		for _, name := range []string{"exchangeRate", "discounts", "taxes"} {
+			if optional := field(name); optional != "" {
+				// invalid JSON is reported by the Receipt schema
+				value[name] = optional
+				var parsed any
+				if json.Unmarshal([]byte(optional), &parsed) == nil {
+					value[name] = parsed
+				}
+			}
+		}
+	}
+	flush()
+	return nil
+}
+
+// readNDJSON reads receipts from NDJSON, one JSON receipt per line, skipping blank lines
+// r: the NDJSON
+// store: called with the line, id and JSON value of each receipt
+// invalid: called with the line and error of each malformed line
+// Returns: an error when a line cannot be read
+func readNDJSON(r io.Reader, store func(line int, id string, value map[string]any), invalid func(line int, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var value map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &value); err != nil {
+			invalid(line, fmt.Errorf("Invalid JSON: %w", err))
+			continue
+		}
+		id, _ := value["id"].(string)
+		store(line, id, value)
+	}
+	return scanner.Err()
+}
+
+// ExportReceipts writes every stored Receipt with its points, sorted by id,
+// pinning receipts that were never scored to the active rule set
+// w: the destination of the receipts
+// format: CSVFormat, one row per item, or NDJSONFormat
+// Returns: an error when the receipts cannot be written
+func (h *ReceiptHandler) ExportReceipts(w io.Writer, format string) error {
+	if format != CSVFormat && format != NDJSONFormat {
+		return fmt.Errorf("Unsupported format %q", format)
+	}
+	writer := csv.NewWriter(w)
+	if format == CSVFormat {
+		writer.Write(append(csvColumns, "ruleset", "points"))
+	}
+	encoder := json.NewEncoder(w)
+	for _, id := range h.Database.GetReceiptIds() {
+		receipt, err := h.Database.GetReceipt(id)
+		if err != nil {
+			continue
+		}
+		score := h.pinnedScore(id, receipt)
+		if format == NDJSONFormat {
+			if err := encoder.Encode(ExportedReceipt{Id: id, Receipt: receipt, Ruleset: score.Version, Points: score.Breakdown.Points}); err != nil {
+				return err
+			}
+			continue
+		}
+		optional := func(value *string) string {
+			if value == nil {
+				return ""
+			}
+			return *value
+		}
+		paymentMethod, exchangeRate, discounts, taxes := "", "", "", ""
+		if receipt.PaymentMethod != nil {
+			paymentMethod = string(*receipt.PaymentMethod)
+		}
+		if receipt.ExchangeRate != nil {
+			exchangeRate = jsonCell(receipt.ExchangeRate)
+		}
+		if receipt.Discounts != nil {
+			discounts = jsonCell(receipt.Discounts)
+		}
+		if receipt.Taxes != nil {
+			taxes = jsonCell(receipt.Taxes)
+		}
+		for _, item := range receipt.Items {
+			quantity := ""
+			if item.Quantity != nil {
+				quantity = strconv.FormatFloat(*item.Quantity, 'f', -1, 64)
+			}
+			writer.Write([]string{
+				id, receipt.Retailer, receipt.PurchaseDate.Format("2006-01-02"), receipt.PurchaseTime, receipt.Total,
+				optional(receipt.MemberId), optional(receipt.TimeZone), optional(receipt.UtcOffset),
+				item.ShortDescription, item.Price, quantity, optional(item.UnitPrice), optional(item.Discount),
+				optional(receipt.Currency), exchangeRate, optional(receipt.Tip), paymentMethod, optional(receipt.StoreId),
+				discounts, taxes, score.Version, strconv.Itoa(score.Breakdown.Points),
+			})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// jsonCell encodes a field of a Receipt as JSON for a CSV cell
+func jsonCell(value any) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+// PostReceiptsImport handles POST requests to import receipts in bulk, as text/csv
+// or application/x-ndjson, storing the valid receipts and reporting the others by line
+// Response example: {"imported":2,"ids":["7d4d837b-ef5e-47c0-89a9-889657b66eb9","r2"],"errors":[{"line":4,"error":"Invalid total: USD amounts have 2 decimal places"}]}
+func (h *ReceiptHandler) PostReceiptsImport(w http.ResponseWriter, r *http.Request) {
+	format := NDJSONFormat
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		format = CSVFormat
+	}
+	response, err := h.ImportReceipts(r.Body, format, actor(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetReceiptsExport handles GET requests to export every stored Receipt with its points,
+// as NDJSON, or CSV with the format query parameter
+// Response example: {"id":"7d4d837b-ef5e-47c0-89a9-889657b66eb9","retailer":"Target","purchaseDate":"2022-01-02","purchaseTime":"13:13","total":"1.25","items":[{"shortDescription":"Pepsi - 12-oz","price":"1.25"}],"ruleset":"v1","points":31}
+func (h *ReceiptHandler) GetReceiptsExport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = NDJSONFormat
+	}
+	contentType := "application/x-ndjson"
+	if format == CSVFormat {
+		contentType = "text/csv"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	h.ExportReceipts(w, format)
+}
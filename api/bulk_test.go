@@ -0,0 +1,195 @@
+/*
+bulk_test.go contains functions for testing the bulk import and export of receipts.
+*/
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// Import imports receipts through the router
+func Import(t *testing.T, router chi.Router, contentType string, body string) (int, PostReceiptsImportResponse) {
+	request := httptest.NewRequest(http.MethodPost, "/receipts/import", strings.NewReader(body))
+	request.Header.Set("Content-Type", contentType)
+	recorder := ProcessRequest(router, request)
+	response := PostReceiptsImportResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder.Code, response
+}
+
+// TestImportCSV verifies CSV rows are grouped into receipts by id, and invalid
+// receipts are reported by line while the valid ones are imported
+func TestImportCSV(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	router := NewReceiptRouter(receipts)
+	code, response := Import(t, router, "text/csv", `id,retailer,purchaseDate,purchaseTime,total,memberId,shortDescription,price
+r1,Target,2022-01-01,13:01,35.35,member-1,Mountain Dew 12PK,6.49
+r1,Target,2022-01-01,13:01,35.35,member-1,Emils Cheese Pizza,12.25
+r1,Target,2022-01-01,13:01,35.35,member-1,Knorr Creamy Chicken,1.26
+r1,Target,2022-01-01,13:01,35.35,member-1,Doritos Nacho Cheese,3.35
+r1,Target,2022-01-01,13:01,35.35,member-1,   Klarbrunn 12-PK 12 FL OZ  ,12.00
+,Walgreens,2022-01-02,08:13,2.65,,Pepsi - 12-oz,1.25
+r3,Target,2022-01-02,13:13,1.2,,Pepsi - 12-oz,1.20
+r4,Target,2022-02-30,13:13,1.25,,Pepsi - 12-oz,1.25
+r1,Target,2022-01-01,13:01,35.35,,Pepsi - 12-oz,1.25
+r5,"Target,2022-01-02,13:13,1.25,,Pepsi - 12-oz,1.25
+`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, response.Imported)
+	assert.Equal(t, "r1", response.Ids[0])
+	assert.Equal(t, []ImportError{
+		{Line: 8, Error: "Invalid total: USD amounts have 2 decimal places"},
+		{Line: 9, Error: "Invalid purchaseDate"},
+		{Line: 10, Error: "Receipt r1 already exists"},
+		{Line: 11, Error: "Invalid CSV: extraneous or missing \" in quoted-field"},
+	}, response.Errors)
+
+	// imported like a submitted receipt, scored with the active rule set
+	recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/r1/points", nil))
+	assert.JSONEq(t, `{"points":28}`, recorder.Body.String())
+	receipt, _ := receipts.Database.GetReceipt("r1")
+	assert.Len(t, receipt.Items, 5)
+	assert.Equal(t, []string{"r1"}, receipts.Database.GetMemberReceiptIds("member-1"))
+	entries := receipts.Database.GetAuditEntries("r1")
+	assert.Equal(t, ReceiptImported, entries[0].Action)
+
+	code, _ = Import(t, router, "text/csv", "retailer,total\nTarget,1.25\n")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// TestImportNDJSON verifies NDJSON lines are imported, reporting invalid lines
+func TestImportNDJSON(t *testing.T) {
+	router := NewReceiptRouter(NewReceiptHandler(&Database{}))
+	code, response := Import(t, router, "application/x-ndjson", `{"retailer":"Target","purchaseDate":"2022-01-02","purchaseTime":"13:13","total":"1.25","items":[{"shortDescription":"Pepsi - 12-oz","price":"1.25"}]}
+
+{"retailer":"Target","purchaseDate":"2022-01-02","purchaseTime":"13:13","total":"1.25","items":[]}
+{"retailer":"Target",
+{"id":"r4","retailer":"Walgreens","purchaseDate":"2022-01-02","purchaseTime":"25:13","total":"1.25","items":[{"shortDescription":"Pepsi - 12-oz","price":"1.25"}]}
+`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, response.Imported)
+	assert.Equal(t, []ImportError{
+		{Line: 3, Error: "Invalid items: minimum number of items is 1"},
+		{Line: 4, Error: "Invalid JSON: unexpected end of JSON input"},
+		{Line: 5, Error: "Invalid purchaseTime"},
+	}, response.Errors)
+}
+
+// TestImportFraud verifies imported receipts are checked for fraud and against the
+// review rules like submitted receipts, and held for review when flagged
+func TestImportFraud(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	router := NewReceiptRouter(receipts)
+	code, response := Import(t, router, "application/x-ndjson", `{"id":"a","retailer":"Target","purchaseDate":"2022-01-02","purchaseTime":"13:13","total":"1.25","items":[{"shortDescription":"Pepsi - 12-oz","price":"1.25"}]}
+{"id":"b","retailer":"Target","purchaseDate":"2022-01-02","purchaseTime":"13:13","total":"1.25","items":[{"shortDescription":"Pepsi - 12-oz","price":"1.25"}]}
+{"id":"c","retailer":"Target","purchaseDate":"2022-01-10","purchaseTime":"13:13","total":"600.00","items":[{"shortDescription":"Television","price":"600.00"}]}
+`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"a", "b", "c"}, response.Ids)
+
+	_, err := receipts.Database.GetReview("a")
+	assert.Error(t, err)
+	review, err := receipts.Database.GetReview("b")
+	assert.NoError(t, err)
+	assert.Equal(t, DuplicateFlag, review.Flags[0].Type)
+	assert.Equal(t, "a", review.Flags[0].ReceiptId)
+	_, err = receipts.Database.GetReview("c")
+	assert.NoError(t, err)
+	for _, id := range []string{"b", "c"} {
+		recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/"+id+"/points", nil))
+		assert.JSONEq(t, `{"points":0}`, recorder.Body.String(), id)
+	}
+}
+
+// TestExport verifies receipts are exported with their points, and re-imported with their ids
+func TestExport(t *testing.T) {
+	router := NewReceiptRouter(NewReceiptHandler(&Database{}))
+	_, imported := Import(t, router, "text/csv", `id,retailer,purchaseDate,purchaseTime,total,timeZone,shortDescription,price
+a,Target,2022-01-02,13:13,2.50,America/Chicago,Pepsi - 12-oz,1.25
+a,Target,2022-01-02,13:13,2.50,America/Chicago,Dasani,1.25
+b,Walgreens,2022-01-02,08:13,1.25,,Pepsi - 12-oz,1.25
+`)
+	assert.Equal(t, 2, imported.Imported)
+
+	recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/export", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+	ndjson := recorder.Body.String()
+	scanner := bufio.NewScanner(strings.NewReader(ndjson))
+	var exported []ExportedReceipt
+	for scanner.Scan() {
+		receipt := ExportedReceipt{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &receipt))
+		exported = append(exported, receipt)
+	}
+	assert.Len(t, exported, 2)
+	assert.Equal(t, "a", exported[0].Id)
+	assert.Equal(t, "America/Chicago", *exported[0].TimeZone)
+	assert.Len(t, exported[0].Items, 2)
+	assert.Equal(t, "v1", exported[0].Ruleset)
+	assert.Equal(t, 6+25+5+1, exported[0].Points)
+
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/export?format=csv", nil))
+	assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	csvText := recorder.Body.String()
+	rows, err := csv.NewReader(strings.NewReader(csvText)).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "retailer", "purchaseDate", "purchaseTime", "total", "memberId", "timeZone", "utcOffset", "shortDescription", "price",
+		"quantity", "unitPrice", "discount", "currency", "exchangeRate", "tip", "paymentMethod", "storeId", "discounts", "taxes", "ruleset", "points"}, rows[0])
+	assert.Equal(t, []string{"a", "Target", "2022-01-02", "13:13", "2.50", "", "America/Chicago", "", "Dasani", "1.25", "", "", "", "", "", "", "", "", "", "", "v1", "37"}, rows[2])
+	assert.Len(t, rows, 4)
+
+	// round trips into another server, keeping ids
+	other := NewReceiptRouter(NewReceiptHandler(&Database{}))
+	_, reimported := Import(t, other, "application/x-ndjson", ndjson)
+	assert.Equal(t, []string{"a", "b"}, reimported.Ids)
+	recorder = ProcessRequest(other, httptest.NewRequest(http.MethodGet, "/receipts/export", nil))
+	assert.Equal(t, ndjson, recorder.Body.String())
+	_, reimported = Import(t, NewReceiptRouter(NewReceiptHandler(&Database{})), "text/csv", csvText)
+	assert.Equal(t, []string{"a", "b"}, reimported.Ids)
+}
+
+// TestExportCSVFields verifies the currency, exchange rate, item quantities, discounts,
+// taxes, tip, payment method and store of receipts round trip through CSV
+func TestExportCSVFields(t *testing.T) {
+	rates, err := NewExchangeRates(ExchangeRates{Rates: map[string]float64{"EUR": 1.08}})
+	assert.NoError(t, err)
+	handler := func() chi.Router {
+		receipts := NewReceiptHandler(&Database{})
+		receipts.Rates = rates
+		return NewReceiptRouter(receipts)
+	}
+	router := handler()
+	_, imported := Import(t, router, "application/x-ndjson", `{"id":"a","retailer":"Target","storeId":"store-0042","paymentMethod":"store-card","currency":"EUR","exchangeRate":{"currency":"EUR","base":"USD","rate":1.1,"date":"2024-06-01"},"purchaseDate":"2022-01-02","purchaseTime":"13:13","total":"2.50","items":[{"shortDescription":"Pepsi - 12-oz","price":"1.25","quantity":2,"unitPrice":"0.75","discount":"0.25"},{"shortDescription":"Dasani","price":"1.25","quantity":0.5,"unitPrice":"2.50"}],"discounts":[{"code":"SAVE10","amount":"0.20"}],"taxes":[{"description":"State Tax","rate":6.25,"amount":"0.10"}],"tip":"0.10"}
+`)
+	assert.Equal(t, []string{"a"}, imported.Ids)
+	recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/export?format=csv", nil))
+	rows, err := csv.NewReader(strings.NewReader(recorder.Body.String())).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "Target", "2022-01-02", "13:13", "2.50", "", "", "", "Pepsi - 12-oz", "1.25",
+		"2", "0.75", "0.25", "EUR", `{"base":"USD","currency":"EUR","rate":1.08}`, "0.10", "store-card", "store-0042",
+		`[{"amount":"0.20","code":"SAVE10"}]`, `[{"amount":"0.10","description":"State Tax","rate":6.25}]`}, rows[1][:20])
+
+	other := handler()
+	_, reimported := Import(t, other, "text/csv", recorder.Body.String())
+	assert.Equal(t, []string{"a"}, reimported.Ids)
+	exported := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/export", nil)).Body.String()
+	assert.Equal(t, exported, ProcessRequest(other, httptest.NewRequest(http.MethodGet, "/receipts/export", nil)).Body.String())
+
+	// malformed JSON cells are reported by line
+	_, reimported = Import(t, handler(), "text/csv", "retailer,purchaseDate,purchaseTime,total,shortDescription,price,quantity,taxes\nTarget,2022-01-02,13:13,1.25,Pepsi - 12-oz,1.25,two,\nTarget,2022-01-02,13:13,1.25,Pepsi - 12-oz,1.25,,[{\n")
+	assert.Equal(t, 0, reimported.Imported)
+	assert.Equal(t, []ImportError{
+		{Line: 2, Error: "Invalid items.0.quantity: value must be a number"},
+		{Line: 3, Error: "Invalid taxes: value must be an array"},
+	}, reimported.Errors)
+}
@@ -0,0 +1,193 @@
+/*
+calendar.go contains the holiday calendar and rules that award points for the date of purchase
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Holiday is a named date in a Calendar
+type Holiday struct {
+	// Date of the holiday, e.g. 2024-12-25
+	Date string `json:"date"`
+	// Name of the holiday, e.g. Christmas Day
+	Name string `json:"name"`
+}
+
+// Calendar lists the holidays and weekend days used by CalendarRules,
+// usually loaded from a json calendar file, see calendars/us.json
+type Calendar struct {
+	// Name of the calendar
+	Name string `json:"name"`
+	// Weekend lists the weekend days, defaults to Saturday and Sunday
+	Weekend []string `json:"weekend,omitempty"`
+	// Holidays lists the holidays
+	Holidays []Holiday `json:"holidays"`
+
+	// weekend and holidays are the parsed Weekend and Holidays
+	weekend  []time.Weekday
+	holidays map[string]Holiday
+}
+
+// LoadCalendar reads a Calendar from a json file
+// path: the location of the calendar file
+// Returns: the Calendar defined in the file
+func LoadCalendar(path string) (*Calendar, error) {
+	calendar := &Calendar{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, calendar); err != nil {
+		return nil, fmt.Errorf("invalid calendar file %s: %w", path, err)
+	}
+	if err := calendar.compile(); err != nil {
+		return nil, fmt.Errorf("invalid calendar file %s: %w", path, err)
+	}
+	return calendar, nil
+}
+
+// DefaultCalendar returns a Calendar with Saturday and Sunday weekends and no holidays
+func DefaultCalendar() *Calendar {
+	calendar := &Calendar{Name: "default"}
+	calendar.compile()
+	return calendar
+}
+
+// compile validates and indexes the weekend days and holidays
+func (c *Calendar) compile() error {
+	c.weekend = []time.Weekday{time.Saturday, time.Sunday}
+	if len(c.Weekend) > 0 {
+		c.weekend = nil
+		for _, name := range c.Weekend {
+			day, err := parseWeekday(name)
+			if err != nil {
+				return err
+			}
+			c.weekend = append(c.weekend, day)
+		}
+	}
+	c.holidays = map[string]Holiday{}
+	for _, holiday := range c.Holidays {
+		if _, err := time.Parse(time.DateOnly, holiday.Date); err != nil {
+			return fmt.Errorf("holiday %q has an invalid date: %w", holiday.Name, err)
+		}
+		c.holidays[holiday.Date] = holiday
+	}
+	return nil
+}
+
+// IsWeekend reports whether a date is a weekend day
+func (c *Calendar) IsWeekend(date time.Time) bool {
+	return slices.Contains(c.weekend, date.Weekday())
+}
+
+// Holiday finds the holiday on a date
+// date: the date to look up
+// Returns: the Holiday, and whether the date is a holiday
+func (c *Calendar) Holiday(date time.Time) (Holiday, bool) {
+	holiday, ok := c.holidays[date.Format(time.DateOnly)]
+	return holiday, ok
+}
+
+// parseWeekday parses an English weekday name, ignoring case
+func parseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid weekday %q", name)
+}
+
+// CalendarRule awards points for purchases on a kind of date. On is one of:
+//   - weekend: a weekend day in the Calendar
+//   - holiday: a holiday in the Calendar, limited to Holidays when given
+//   - birthday-month: the birthday month of the member
+//   - last-friday: the last Friday of the month, or the last of any other weekday
+//
+// Example json, 25 points on Thanksgiving and Christmas:
+//
+//	{"name": "holidays", "on": "holiday", "holidays": ["Thanksgiving Day", "Christmas Day"], "points": 25}
+type CalendarRule struct {
+	// RuleName identifies the rule in a Breakdown
+	RuleName string `json:"name"`
+	// On is the kind of date awarded points
+	On string `json:"on"`
+	// Holidays limits a holiday rule to the named holidays
+	Holidays []string `json:"holidays,omitempty"`
+	// Points awarded for purchases on the date
+	Points int `json:"points"`
+
+	// match reports whether a receipt matches, set by Compile
+	match func(r Receipt) bool
+}
+
+// Compile validates the rule and prepares it for scoring
+// calendar: the Calendar of weekends and holidays
+// members: looks up the member of a receipt, may be nil
+func (c *CalendarRule) Compile(calendar *Calendar, members MemberDirectory) error {
+	if c.RuleName == "" {
+		return fmt.Errorf("calendar rule is missing a name")
+	}
+	if len(c.Holidays) > 0 && c.On != "holiday" {
+		return fmt.Errorf("calendar rule %q lists holidays but is not a holiday rule", c.RuleName)
+	}
+	switch {
+	case c.On == "weekend":
+		c.match = func(r Receipt) bool {
+			return calendar.IsWeekend(r.PurchaseDate.Time)
+		}
+	case c.On == "holiday":
+		for _, name := range c.Holidays {
+			if !slices.ContainsFunc(calendar.Holidays, func(h Holiday) bool { return h.Name == name }) {
+				return fmt.Errorf("calendar rule %q references unknown holiday %q", c.RuleName, name)
+			}
+		}
+		c.match = func(r Receipt) bool {
+			holiday, ok := calendar.Holiday(r.PurchaseDate.Time)
+			return ok && (len(c.Holidays) == 0 || slices.Contains(c.Holidays, holiday.Name))
+		}
+	case c.On == "birthday-month":
+		c.match = func(r Receipt) bool {
+			if members == nil || r.MemberId == nil {
+				return false
+			}
+			member, err := members.GetMember(*r.MemberId)
+			return err == nil && member.Birthday != nil && member.Birthday.Month() == r.PurchaseDate.Month()
+		}
+	case strings.HasPrefix(c.On, "last-"):
+		weekday, err := parseWeekday(strings.TrimPrefix(c.On, "last-"))
+		if err != nil {
+			return fmt.Errorf("calendar rule %q: %w", c.RuleName, err)
+		}
+		c.match = func(r Receipt) bool {
+			date := r.PurchaseDate.Time
+			return date.Weekday() == weekday && date.AddDate(0, 0, 7).Month() != date.Month()
+		}
+	default:
+		return fmt.Errorf("calendar rule %q has an invalid on %q", c.RuleName, c.On)
+	}
+	return nil
+}
+
+// Name returns the name of the rule
+func (c *CalendarRule) Name() string {
+	return c.RuleName
+}
+
+// Score awards Points when the purchase date matches
+func (c *CalendarRule) Score(r Receipt) RuleResult {
+	result := RuleResult{Rule: c.RuleName}
+	if c.match(r) {
+		result.Points = c.Points
+		result.Uncapped = c.Points
+	}
+	return result
+}
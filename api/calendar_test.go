@@ -0,0 +1,140 @@
+/*
+calendar_test.go contains functions for testing calendar rules with the bundled calendar.
+*/
+package api
+
+import (
+	"testing"
+
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCalendarRules verifies weekend, holiday, birthday month and last weekday rules
+func TestCalendarRules(t *testing.T) {
+	database := &Database{}
+	birthday := openapi_types.Date{}
+	assert.NoError(t, birthday.UnmarshalText([]byte("1990-11-14")))
+	database.PutMember("member-1", Member{Birthday: &birthday})
+
+	processor, err := NewRuleProcessorFromConfig(RuleConfig{
+		Calendar: "../calendars/us.json",
+		CalendarRules: []CalendarRule{
+			{RuleName: "weekend", On: "weekend", Points: 5},
+			{RuleName: "holiday", On: "holiday", Points: 10},
+			{RuleName: "thanksgiving", On: "holiday", Holidays: []string{"Thanksgiving Day"}, Points: 20},
+			{RuleName: "birthday", On: "birthday-month", Points: 50},
+			{RuleName: "last-friday", On: "last-friday", Points: 15},
+		},
+	}, database)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		date     string
+		member   string
+		expected map[string]int
+	}{
+		{
+			name:     "weekday",
+			date:     "2024-11-13",
+			expected: map[string]int{},
+		},
+		{
+			name:     "weekend",
+			date:     "2024-11-16",
+			expected: map[string]int{"weekend": 5},
+		},
+		{
+			name:     "holiday on a weekend",
+			date:     "2022-12-25",
+			expected: map[string]int{"weekend": 5, "holiday": 10},
+		},
+		{
+			name:     "named holiday",
+			date:     "2024-11-28",
+			expected: map[string]int{"holiday": 10, "thanksgiving": 20},
+		},
+		{
+			name:     "birthday month",
+			date:     "2024-11-13",
+			member:   `"memberId": "member-1",`,
+			expected: map[string]int{"birthday": 50},
+		},
+		{
+			name:     "not birthday month",
+			date:     "2024-10-16",
+			member:   `"memberId": "member-1",`,
+			expected: map[string]int{},
+		},
+		{
+			name:     "unknown member",
+			date:     "2024-11-13",
+			member:   `"memberId": "member-2",`,
+			expected: map[string]int{},
+		},
+		{
+			name:     "last friday",
+			date:     "2024-11-29",
+			expected: map[string]int{"last-friday": 15},
+		},
+		{
+			name:     "second to last friday",
+			date:     "2024-11-22",
+			expected: map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt := ParseReceipt(t, `{`+tt.member+`"purchaseDate": "`+tt.date+`", "purchaseTime": "12:00"}`)
+			breakdown := processor.Breakdown(receipt)
+			for _, name := range []string{"weekend", "holiday", "thanksgiving", "birthday", "last-friday"} {
+				assert.Equal(t, tt.expected[name], FindResult(t, breakdown, name).Points, name)
+			}
+		})
+	}
+}
+
+// TestCalendarRuleConfigErrors verifies invalid calendar rules and calendars are rejected
+func TestCalendarRuleConfigErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      RuleConfig
+		expectedErr string
+	}{
+		{
+			name:        "missing calendar file",
+			config:      RuleConfig{Calendar: "missing.json"},
+			expectedErr: "no such file",
+		},
+		{
+			name:        "invalid on",
+			config:      RuleConfig{CalendarRules: []CalendarRule{{RuleName: "bad", On: "tuesday"}}},
+			expectedErr: "invalid on",
+		},
+		{
+			name:        "invalid last weekday",
+			config:      RuleConfig{CalendarRules: []CalendarRule{{RuleName: "bad", On: "last-funday"}}},
+			expectedErr: "invalid weekday",
+		},
+		{
+			name: "unknown holiday",
+			config: RuleConfig{
+				Calendar:      "../calendars/us.json",
+				CalendarRules: []CalendarRule{{RuleName: "bad", On: "holiday", Holidays: []string{"Festivus"}}},
+			},
+			expectedErr: "unknown holiday",
+		},
+		{
+			name:        "holidays on a weekend rule",
+			config:      RuleConfig{CalendarRules: []CalendarRule{{RuleName: "bad", On: "weekend", Holidays: []string{"Festivus"}}}},
+			expectedErr: "not a holiday rule",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRuleProcessorFromConfig(tt.config, nil)
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
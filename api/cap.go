@@ -0,0 +1,104 @@
+/*
+cap.go contains methods for limiting the points earned from a Receipt
+*/
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// Caps limits the points earned per rule, per receipt and per member
+//
+// Example json, at most 500 points per receipt and 1000 points per member per day:
+//
+//	{"receipt": 500, "rules": {"item-description": 100}, "member": {"day": 1000}}
+type Caps struct {
+	// Receipt is the most points earned per receipt, zero for no cap
+	Receipt int `json:"receipt,omitempty"`
+	// Rules is the most points earned per receipt from each named rule, zero for no cap
+	Rules map[string]int `json:"rules,omitempty"`
+	// Member limits the points earned by a member per period
+	Member MemberCaps `json:"member,omitempty"`
+}
+
+// MemberCaps limits the points earned by a member from receipts purchased
+// in the same day, ISO week, or month, counting the receipts submitted before
+// the receipt being scored. Zero means no cap.
+type MemberCaps struct {
+	Day   int `json:"day,omitempty"`
+	Week  int `json:"week,omitempty"`
+	Month int `json:"month,omitempty"`
+}
+
+// validate checks the caps are not negative
+func (c Caps) validate() error {
+	values := map[string]int{
+		"receipt":      c.Receipt,
+		"member day":   c.Member.Day,
+		"member week":  c.Member.Week,
+		"member month": c.Member.Month,
+	}
+	for name, value := range c.Rules {
+		values["rule "+name] = value
+	}
+	for name, value := range values {
+		if value < 0 {
+			return fmt.Errorf("%s cap must not be negative", name)
+		}
+	}
+	return nil
+}
+
+// period is a span of purchase dates a member cap applies to
+type period struct {
+	// cap is the name of the member cap, e.g. member-day
+	cap string
+	// key identifies the span of dates, e.g. 2022-01-02
+	key string
+	// limit is the most points earned in the span
+	limit int
+}
+
+// periods returns each capped period containing the purchase date
+func (m MemberCaps) periods(date time.Time) []period {
+	var periods []period
+	if m.Day > 0 {
+		periods = append(periods, period{"member-day", date.Format(time.DateOnly), m.Day})
+	}
+	if m.Week > 0 {
+		year, week := date.ISOWeek()
+		periods = append(periods, period{"member-week", fmt.Sprintf("%d-W%02d", year, week), m.Week})
+	}
+	if m.Month > 0 {
+		periods = append(periods, period{"member-month", date.Format("2006-01"), m.Month})
+	}
+	return periods
+}
+
+// Earned is the points earned from one of a member's receipts
+type Earned struct {
+	// Date is the purchase date of the receipt
+	Date time.Time
+	// Points earned from the receipt
+	Points int
+}
+
+// apply limits the points in a Breakdown by the points remaining in each period
+// breakdown: the Breakdown of a receipt purchased on date
+// earned: the points earned from the member's other receipts
+func (m MemberCaps) apply(breakdown *Breakdown, date time.Time, earned []Earned) {
+	used := map[period]int{}
+	for _, e := range earned {
+		for _, p := range m.periods(e.Date) {
+			used[p] += e.Points
+		}
+	}
+	for _, p := range m.periods(date) {
+		remaining := max(p.limit-used[p], 0)
+		if breakdown.Points > remaining {
+			breakdown.Points = remaining
+			breakdown.Capped = append(breakdown.Capped, p.cap)
+		}
+	}
+}
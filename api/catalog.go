@@ -0,0 +1,51 @@
+/*
+catalog.go contains the product catalog used to recognize items on a Receipt
+*/
+package api
+
+import (
+	"strings"
+)
+
+// Product is an entry in the Catalog, listing the short descriptions
+// the product is printed under on receipts
+type Product struct {
+	// Sku uniquely identifies the product
+	Sku string `json:"sku"`
+	// Name is the display name of the product
+	Name string `json:"name,omitempty"`
+	// Descriptions are the short descriptions printed for the product
+	Descriptions []string `json:"descriptions"`
+}
+
+// Catalog is the list of known products
+type Catalog []Product
+
+// Product finds a Product in the Catalog
+// sku: the sku of the Product
+// Returns: the Product, and whether it was found
+func (c Catalog) Product(sku string) (Product, bool) {
+	for _, product := range c {
+		if product.Sku == sku {
+			return product, true
+		}
+	}
+	return Product{}, false
+}
+
+// Matches reports whether a short description is one of the product descriptions,
+// ignoring case and surrounding or repeated whitespace
+func (p Product) Matches(description string) bool {
+	description = normalizeDescription(description)
+	for _, d := range p.Descriptions {
+		if normalizeDescription(d) == description {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDescription lower cases a short description and collapses whitespace
+func normalizeDescription(description string) string {
+	return strings.ToLower(strings.Join(strings.Fields(description), " "))
+}
@@ -0,0 +1,134 @@
+/*
+config.go contains methods for loading the configurable rules
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+// bundledCalendar matches the calendars bundled in the calendars directory, the only
+// calendars rule sets submitted over the API may use, e.g. calendars/us.json
+var bundledCalendar = regexp.MustCompile(`^calendars/[\w-]+\.json$`)
+
+// RuleConfig defines the rules added to the default rules by
+// NewRuleProcessorFromConfig, usually loaded from a json rules file
+//
+// Example json:
+//
+//	{
+//	  "catalog": [
+//	    {"sku": "GTRD-20", "name": "Gatorade 20oz", "descriptions": ["Gatorade", "Gatorade 20oz"]}
+//	  ],
+//	  "itemRules": [
+//	    {"name": "gatorade", "sku": "GTRD-20", "points": 20, "cap": 100}
+//	  ],
+//	  "timeRules": [
+//	    {"name": "happy-hour", "start": "17:00", "end": "19:00", "points": 15}
+//	  ],
+//	  "calendarRules": [
+//	    {"name": "weekend", "on": "weekend", "points": 5},
+//	    {"name": "birthday", "on": "birthday-month", "points": 50}
+//	  ],
+//	  "expressionRules": [
+//	    {"name": "big-basket", "expression": "len(items) >= 5 && total > 20 ? 15 : 0"}
+//	  ],
+//	  "groups": [
+//	    {"name": "total-bonus", "strategy": "max", "rules": ["round-dollar", "quarter-multiple"]}
+//	  ],
+//	  "tiers": [
+//	    {"name": "bronze", "points": 0, "multiplier": 1},
+//	    {"name": "gold", "points": 5000, "multiplier": 1.5}
+//	  ],
+//	  "plugins": "plugins",
+//	  "pluginLimits": {"memoryPages": 16, "fuel": 100000, "timeout": "50ms"},
+//	  "calendar": "calendars/us.json",
+//	  "timeZone": "America/Chicago",
+//	  "caps": {"receipt": 500, "member": {"day": 1000}},
+//	  "fraud": {"maxReceiptsPerHour": 10},
+//	  "review": {"highValue": 1000, "totalTolerance": 0.05}
+//	}
+type RuleConfig struct {
+	// Catalog contains the products referenced by ItemRules
+	Catalog Catalog `json:"catalog,omitempty"`
+	// ItemRules award points for matching items
+	ItemRules []ItemRule `json:"itemRules,omitempty"`
+	// TimeRules award points for the time of purchase
+	TimeRules []TimeWindowRule `json:"timeRules,omitempty"`
+	// CalendarRules award points for the date of purchase
+	CalendarRules []CalendarRule `json:"calendarRules,omitempty"`
+	// ExpressionRules award the points returned by expressions over the receipt
+	ExpressionRules []ExpressionRule `json:"expressionRules,omitempty"`
+	// Groups combine the points of rules by a strategy instead of summing them
+	Groups []RuleGroup `json:"groups,omitempty"`
+	// Tiers multiply the points members earn, defaults to DefaultTiers
+	Tiers Tiers `json:"tiers,omitempty"`
+	// Plugins is the location of a directory of .wasm plugin rules, loaded into
+	// every rule set, only set in the rules file
+	Plugins string `json:"plugins,omitempty"`
+	// PluginLimits limits the resources each plugin may use, only set in the rules file
+	PluginLimits PluginLimits `json:"pluginLimits,omitempty"`
+	// Calendar is the location of the calendar file used by CalendarRules,
+	// defaults to Saturday and Sunday weekends and no holidays. Rule sets submitted
+	// over the API may only use a calendar bundled in the calendars directory.
+	Calendar string `json:"calendar,omitempty"`
+	// TimeZone is the IANA time zone of receipts without a time zone or UTC offset,
+	// defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+	// Caps limits the points earned
+	Caps Caps `json:"caps,omitempty"`
+	// Fraud tunes the checks that hold suspicious receipts for review
+	Fraud FraudConfig `json:"fraud,omitempty"`
+	// Review sets which receipts besides suspicious ones are held for review
+	Review ReviewConfig `json:"review,omitempty"`
+}
+
+// LoadRuleConfig reads a RuleConfig from a json file
+// path: the location of the rules file
+// Returns: the RuleConfig defined in the file
+func LoadRuleConfig(path string) (RuleConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return RuleConfig{}, err
+	}
+	defer file.Close()
+	config, err := DecodeRuleConfig(file)
+	if err != nil {
+		return config, fmt.Errorf("invalid rules file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// DecodeRuleConfig reads a json RuleConfig, rejecting unknown fields
+// reader: the json RuleConfig
+// Returns: the decoded RuleConfig
+func DecodeRuleConfig(reader io.Reader) (RuleConfig, error) {
+	var config RuleConfig
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&config)
+	return config, err
+}
+
+// DecodeRequestRuleConfig reads a json RuleConfig from a request body, which unlike
+// the rules file may only name a calendar bundled in the calendars directory, and
+// may not set plugins, so callers cannot make the server read or run other files
+// reader: the json RuleConfig
+// Returns: the decoded RuleConfig
+func DecodeRequestRuleConfig(reader io.Reader) (RuleConfig, error) {
+	config, err := DecodeRuleConfig(reader)
+	if err != nil {
+		return config, err
+	}
+	if config.Plugins != "" || config.PluginLimits != (PluginLimits{}) {
+		return config, fmt.Errorf("plugins can only be set in the rules file or PLUGINS_DIR")
+	}
+	if config.Calendar != "" && !bundledCalendar.MatchString(config.Calendar) {
+		return config, fmt.Errorf("calendar %q is not a bundled calendar, e.g. calendars/us.json", config.Calendar)
+	}
+	return config, nil
+}
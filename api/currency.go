@@ -0,0 +1,245 @@
+/*
+currency.go contains the ISO 4217 currencies of receipts, and the exchange rates
+their amounts are converted with before rules evaluate them
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// currencyDecimals are the decimal places of the amounts of each ISO 4217 currency accepted
+var currencyDecimals = map[string]int{
+	// currencies without minor units
+	"CLP": 0, "ISK": 0, "JPY": 0, "KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "XAF": 0, "XOF": 0,
+	// currencies with 3 decimal places
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	// currencies with cents
+	"AED": 2, "ARS": 2, "AUD": 2, "BDT": 2, "BGN": 2, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2, "COP": 2,
+	"CZK": 2, "DKK": 2, "EGP": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"KES": 2, "MAD": 2, "MXN": 2, "MYR": 2, "NGN": 2, "NOK": 2, "NZD": 2, "PEN": 2, "PHP": 2, "PKR": 2,
+	"PLN": 2, "QAR": 2, "RON": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TRY": 2, "TWD": 2, "UAH": 2,
+	"USD": 2, "ZAR": 2,
+}
+
+// defaultCurrency is the base currency when no exchange rates are configured
+const defaultCurrency = "USD"
+
+// CurrencyDecimals returns the decimal places of the amounts of a currency
+// currency: the ISO 4217 code, receipts without a currency have 2 decimal places
+// Returns: the decimal places, or an error for an unknown currency
+func CurrencyDecimals(currency *string) (int, error) {
+	if currency == nil {
+		return 2, nil
+	}
+	decimals, ok := currencyDecimals[*currency]
+	if !ok {
+		return 0, fmt.Errorf("unknown currency %s", *currency)
+	}
+	return decimals, nil
+}
+
+// ExchangeRates converts receipt amounts to a base currency, so receipts in different
+// currencies earn comparable points, usually loaded from a json rates file
+//
+// Example json, the base currency value of one unit of each currency:
+//
+//	{"base": "USD", "date": "2024-06-01", "rates": {"EUR": 1.08, "GBP": 1.27, "JPY": 0.0064}}
+type ExchangeRates struct {
+	// Base is the currency rules evaluate amounts in, defaults to USD
+	Base string `json:"base"`
+	// Date is when the rates were published, recorded with the rate on receipts
+	Date string `json:"date,omitempty"`
+	// Rates are the base currency value of one unit of each other currency
+	Rates map[string]float64 `json:"rates"`
+}
+
+// NewExchangeRates validates exchange rates, defaulting the base currency to USD
+// rates: the exchange rates
+// Returns: the ExchangeRates, or an error describing an invalid rate
+func NewExchangeRates(rates ExchangeRates) (*ExchangeRates, error) {
+	if rates.Base == "" {
+		rates.Base = defaultCurrency
+	}
+	if _, ok := currencyDecimals[rates.Base]; !ok {
+		return nil, fmt.Errorf("unknown base currency %s", rates.Base)
+	}
+	for currency, rate := range rates.Rates {
+		if _, ok := currencyDecimals[currency]; !ok {
+			return nil, fmt.Errorf("unknown currency %s", currency)
+		}
+		if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return nil, fmt.Errorf("exchange rate of %s must be positive", currency)
+		}
+	}
+	return &rates, nil
+}
+
+// LoadExchangeRates initializes ExchangeRates from the optional EXCHANGE_RATES_FILE,
+// a json ExchangeRates, accepting only USD receipts when not set
+func LoadExchangeRates() *ExchangeRates {
+	var rates ExchangeRates
+	if path := os.Getenv("EXCHANGE_RATES_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &rates); err != nil {
+			log.Fatalf("invalid exchange rates file %s: %v", path, err)
+		}
+	}
+	loaded, err := NewExchangeRates(rates)
+	if err != nil {
+		log.Fatalf("invalid exchange rates: %v", err)
+	}
+	return loaded
+}
+
+// Record records the exchange rate to the base currency on a Receipt with a currency,
+// replacing any rate the Receipt already has, e.g. an imported Receipt, so amounts are
+// only converted with the configured rates
+// receipt: the validated Receipt
+// Returns: the Receipt with its ExchangeRate, or an error when there is no rate for its currency
+func (x *ExchangeRates) Record(receipt Receipt) (Receipt, error) {
+	receipt.ExchangeRate = nil
+	if receipt.Currency == nil {
+		return receipt, nil
+	}
+	rate := 1.0
+	if *receipt.Currency != x.Base {
+		var ok bool
+		if rate, ok = x.Rates[*receipt.Currency]; !ok {
+			return receipt, fmt.Errorf("Invalid currency: no exchange rate from %s to %s", *receipt.Currency, x.Base)
+		}
+	}
+	receipt.ExchangeRate = &ExchangeRate{Currency: *receipt.Currency, Base: x.Base, Rate: rate}
+	if x.Date != "" {
+		receipt.ExchangeRate.Date = &x.Date
+	}
+	return receipt, nil
+}
+
+// ConvertReceipt converts the amounts of a Receipt to the base currency with its
+// recorded ExchangeRate, rounded to the decimal places of the base currency.
+// Receipts without a recorded rate, or in the base currency, are returned unchanged.
+// receipt: the Receipt to convert
+// Returns: the converted Receipt, in the base currency
+func ConvertReceipt(receipt Receipt) Receipt {
+	rate := receipt.ExchangeRate
+	if receipt.Currency == nil || rate == nil || *receipt.Currency == rate.Base {
+		return receipt
+	}
+	decimals, ok := currencyDecimals[rate.Base]
+	if !ok {
+		decimals = 2
+	}
+	convert := func(amount string) string {
+		converted := parseAmount(amount) * rate.Rate
+		return strconv.FormatFloat(math.Round(converted*math.Pow10(decimals))/math.Pow10(decimals), 'f', decimals, 64)
+	}
+	convertOptional := func(amount *string) *string {
+		if amount == nil {
+			return nil
+		}
+		converted := convert(*amount)
+		return &converted
+	}
+	converted := receipt
+	converted.Currency = &rate.Base
+	converted.Total = convert(receipt.Total)
+	converted.Tip = convertOptional(receipt.Tip)
+	converted.Items = make([]Item, len(receipt.Items))
+	for i, item := range receipt.Items {
+		item.Price = convert(item.Price)
+		item.UnitPrice = convertOptional(item.UnitPrice)
+		item.Discount = convertOptional(item.Discount)
+		converted.Items[i] = item
+	}
+	if receipt.Discounts != nil {
+		discounts := make([]Discount, len(*receipt.Discounts))
+		for i, discount := range *receipt.Discounts {
+			discount.Amount = convert(discount.Amount)
+			discounts[i] = discount
+		}
+		converted.Discounts = &discounts
+	}
+	if receipt.Taxes != nil {
+		taxes := make([]TaxLine, len(*receipt.Taxes))
+		for i, tax := range *receipt.Taxes {
+			tax.Amount = convert(tax.Amount)
+			taxes[i] = tax
+		}
+		converted.Taxes = &taxes
+	}
+	return converted
+}
+
+// validateAmounts validates each amount of a Receipt has the decimal places of its currency
+// receipt: the Receipt to validate
+// Returns: an error describing the first invalid amount
+func validateAmounts(receipt Receipt) error {
+	decimals, err := CurrencyDecimals(receipt.Currency)
+	if err != nil {
+		return fmt.Errorf("Invalid currency: %w", err)
+	}
+	currency := defaultCurrency
+	if receipt.Currency != nil {
+		currency = *receipt.Currency
+	}
+	check := func(field string, amount *string) error {
+		if amount == nil {
+			return nil
+		}
+		places := 0
+		if dot := strings.IndexByte(*amount, '.'); dot >= 0 {
+			places = len(*amount) - dot - 1
+		}
+		if places != decimals {
+			return fmt.Errorf("Invalid %s: %s amounts have %d decimal places", field, currency, decimals)
+		}
+		return nil
+	}
+	if err := check("total", &receipt.Total); err != nil {
+		return err
+	}
+	if err := check("tip", receipt.Tip); err != nil {
+		return err
+	}
+	for i, item := range receipt.Items {
+		if err := check(fmt.Sprintf("items.%d.price", i), &item.Price); err != nil {
+			return err
+		}
+		if err := check(fmt.Sprintf("items.%d.unitPrice", i), item.UnitPrice); err != nil {
+			return err
+		}
+		if err := check(fmt.Sprintf("items.%d.discount", i), item.Discount); err != nil {
+			return err
+		}
+	}
+	if receipt.Discounts != nil {
+		for i, discount := range *receipt.Discounts {
+			if err := check(fmt.Sprintf("discounts.%d.amount", i), &discount.Amount); err != nil {
+				return err
+			}
+		}
+	}
+	if receipt.Taxes != nil {
+		for i, tax := range *receipt.Taxes {
+			if err := check(fmt.Sprintf("taxes.%d.amount", i), &tax.Amount); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseMinor parses an amount in the minor units of a currency, e.g. cents
+func parseMinor(amount string, decimals int) int64 {
+	return int64(math.Round(parseAmount(amount) * math.Pow10(decimals)))
+}
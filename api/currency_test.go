@@ -0,0 +1,153 @@
+/*
+currency_test.go contains functions for testing receipts in other currencies, and
+the exchange rates they are converted with.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// currencyRouter initializes a router converting EUR, JPY and KWD receipts to USD
+func currencyRouter(t *testing.T) (ReceiptHandler, chi.Router) {
+	receipts := NewReceiptHandler(&Database{})
+	rates, err := NewExchangeRates(ExchangeRates{Date: "2024-06-01", Rates: map[string]float64{"EUR": 1.08, "JPY": 0.0064, "KWD": 3.25}})
+	assert.NoError(t, err)
+	receipts.Rates = rates
+	// the same receipt is submitted in each currency
+	_, err = receipts.RuleSets.Register(RuleConfig{Fraud: FraudConfig{Disabled: true}})
+	assert.NoError(t, err)
+	return receipts, NewReceiptRouter(receipts)
+}
+
+// submit processes a Pepsi receipt with a currency and total, returning the response
+func submit(router chi.Router, currency string, total string) *httptest.ResponseRecorder {
+	currencyField := ""
+	if currency != "" {
+		currencyField = `"currency": "` + currency + `",`
+	}
+	return ProcessRequest(router, BuildRequest(`{
+		"retailer": "Target",
+		`+currencyField+`
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"total": "`+total+`",
+		"items": [{"shortDescription": "Pepsi - 12-oz", "price": "`+total+`"}]
+	}`))
+}
+
+// TestCurrencyReceipts verifies receipts in other currencies earn the points of their
+// amounts converted to the base currency, with the rate recorded on the receipt
+func TestCurrencyReceipts(t *testing.T) {
+	receipts, router := currencyRouter(t)
+	points := map[string]float64{}
+	for _, receipt := range []struct{ currency, total string }{{"", "32.00"}, {"USD", "32.00"}, {"JPY", "5000"}, {"KWD", "9.846"}} {
+		recorder := submit(router, receipt.currency, receipt.total)
+		assert.Equal(t, http.StatusOK, recorder.Code, receipt.currency)
+		response := &PostReceiptsProcessResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		code, result := Query(t, router, `query Receipt($id: ID!) { receipt(id: $id) { total currency exchangeRate { currency base rate date } points } }`, map[string]any{"id": response.Id})
+		assert.Equal(t, http.StatusOK, code)
+		stored := result["data"].(map[string]any)["receipt"].(map[string]any)
+		assert.Equal(t, receipt.total, stored["total"], "the amounts are stored as submitted")
+		points[receipt.currency] = stored["points"].(float64)
+		switch receipt.currency {
+		case "":
+			assert.Nil(t, stored["exchangeRate"])
+		case "JPY":
+			assert.Equal(t, map[string]any{"currency": "JPY", "base": "USD", "rate": 0.0064, "date": "2024-06-01"}, stored["exchangeRate"])
+		}
+	}
+	// 32.00 USD earns the round dollar and quarter multiple points
+	assert.Equal(t, map[string]float64{"": 6 + 50 + 25, "USD": 81, "JPY": 81, "KWD": 81}, points)
+
+	// reviewed in the base currency, 100000 JPY is 640.00 USD
+	recorder := submit(router, "JPY", "100000")
+	response := &PostReceiptsProcessResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	review, err := receipts.Database.GetReview(response.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "total 640.00 is over 500.00", review.Flags[0].Reason)
+}
+
+// TestCurrencyValidation verifies amounts have the decimal places of their currency,
+// and currencies without an exchange rate are rejected
+func TestCurrencyValidation(t *testing.T) {
+	_, router := currencyRouter(t)
+	invalid := map[string]struct{ currency, total string }{
+		"Invalid total: JPY amounts have 0 decimal places\n":         {"JPY", "5000.00"},
+		"Invalid total: KWD amounts have 3 decimal places\n":         {"KWD", "9.85"},
+		"Invalid total: USD amounts have 2 decimal places\n":         {"", "32"},
+		"Invalid currency: unknown currency XYZ\n":                   {"XYZ", "32.00"},
+		"Invalid currency: no exchange rate from GBP to USD\n":       {"GBP", "32.00"},
+		"Invalid items.0.price: EUR amounts have 2 decimal places\n": {"EUR", "32.0"},
+	}
+	for message, receipt := range invalid {
+		recorder := submit(router, receipt.currency, receipt.total)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, message)
+		if receipt.total != "32.0" {
+			assert.Equal(t, message, recorder.Body.String())
+		}
+	}
+
+	// the exchange rate is recorded by the server
+	recorder := ProcessRequest(router, BuildRequest(`{
+		"retailer": "Target",
+		"currency": "EUR",
+		"exchangeRate": {"currency": "EUR", "base": "USD", "rate": 100},
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"total": "1.25",
+		"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}]
+	}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// TestImportCurrency verifies imported receipts are converted with the configured exchange
+// rates, replacing the rate they were exported with
+func TestImportCurrency(t *testing.T) {
+	receipts, router := currencyRouter(t)
+	receipt := `{"retailer":"Target","currency":"EUR","purchaseDate":"2022-01-02","purchaseTime":"13:13","total":"1.25","items":[{"shortDescription":"Pepsi - 12-oz","price":"1.25"}]`
+	code, response := Import(t, router, "application/x-ndjson", strings.Join([]string{
+		strings.Replace(receipt, "{", `{"id":"a",`, 1) + `,"exchangeRate":{"currency":"EUR","base":"USD","rate":1000}}`,
+		strings.Replace(receipt, "{", `{"id":"b",`, 1) + `,"exchangeRate":{"currency":"JPY","base":"XXX","rate":1.1}}`,
+		strings.Replace(receipt, "{", `{"id":"c",`, 1) + `}`,
+		strings.Replace(strings.Replace(receipt, "{", `{"id":"d",`, 1), `"currency":"EUR",`, "", 1) + `,"exchangeRate":{"currency":"EUR","base":"USD","rate":1.1}}`,
+		strings.Replace(receipt, "EUR", "GBP", 1) + `,"exchangeRate":{"currency":"GBP","base":"USD","rate":1.27}}`,
+	}, "\n"))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, response.Ids)
+	assert.Equal(t, []ImportError{{Line: 5, Error: "Invalid currency: no exchange rate from GBP to USD"}}, response.Errors)
+	date := "2024-06-01"
+	for _, id := range []string{"a", "b", "c"} {
+		stored, _ := receipts.Database.GetReceipt(id)
+		assert.Equal(t, &ExchangeRate{Currency: "EUR", Base: "USD", Rate: 1.08, Date: &date}, stored.ExchangeRate, id)
+	}
+	d, _ := receipts.Database.GetReceipt("d")
+	assert.Nil(t, d.ExchangeRate)
+	// scored at 1.35 USD, not 1250.00
+	recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/a/points", nil))
+	assert.JSONEq(t, `{"points":6}`, recorder.Body.String())
+}
+
+// TestNewExchangeRates verifies invalid exchange rates are rejected
+func TestNewExchangeRates(t *testing.T) {
+	for message, rates := range map[string]ExchangeRates{
+		"unknown base currency ABC":             {Base: "ABC"},
+		"unknown currency ABC":                  {Rates: map[string]float64{"ABC": 1}},
+		"exchange rate of EUR must be positive": {Rates: map[string]float64{"EUR": 0}},
+	} {
+		_, err := NewExchangeRates(rates)
+		assert.EqualError(t, err, message)
+	}
+	rates, err := NewExchangeRates(ExchangeRates{})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", rates.Base)
+}
@@ -5,14 +5,41 @@ package api
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
 // Database is a simple in memory key/value store implementation
-// for id/Receipt
+// for id/Receipt, id/Score, id/Member, id/TierStatus and id/Review, along with the audit log
 type Database struct {
 	// receipts is a shared map of id->Receipt
 	receipts sync.Map
+	// members is a shared map of id->Member
+	members sync.Map
+	// scores is a shared map of receipt id->Score
+	scores sync.Map
+	// tiers is a shared map of member id->TierStatus
+	tiers sync.Map
+	// reviews is a shared map of receipt id->Review
+	reviews sync.Map
+	// attachments is a shared map of receipt id->Attachment
+	attachments sync.Map
+	// auditMu guards audit
+	auditMu sync.Mutex
+	// audit is the hash-chained audit log, oldest first
+	audit []AuditEntry
+	// mu guards memberReceipts
+	mu sync.Mutex
+	// memberReceipts is a map of member id->receipt ids, in the order stored
+	memberReceipts map[string][]string
+	// analyticsMu guards rollups, retailers and leaderboards
+	analyticsMu sync.Mutex
+	// rollups is a map of purchase date->the rollups of the receipts scored
+	rollups map[string]*dayRollups
+	// retailers is a map of normalized retailer->the retailer name first scored
+	retailers map[string]string
+	// leaderboards is a map of leaderboard/period/key->leaderboard, e.g. members/month/2022-01
+	leaderboards map[string]*leaderboard
 }
 
 // GetReceipt retrieves a Receipt from the Database
@@ -32,4 +59,194 @@ func (d *Database) GetReceipt(id string) (Receipt, error) {
 // receipt: the Receipt to store
 func (d *Database) PutReceipt(id string, receipt Receipt) {
 	d.receipts.Store(id, receipt)
+	d.indexMember(id, receipt)
+}
+
+// AddReceipt stores a Receipt in the Database unless a Receipt is stored with its id
+// id: the id associated with the given Receipt
+// receipt: the Receipt to store
+// Returns: an error when the id is taken
+func (d *Database) AddReceipt(id string, receipt Receipt) error {
+	if _, loaded := d.receipts.LoadOrStore(id, receipt); loaded {
+		return fmt.Errorf("receipt %s already exists", id)
+	}
+	d.indexMember(id, receipt)
+	return nil
+}
+
+// indexMember adds a stored Receipt to the receipts of its member
+func (d *Database) indexMember(id string, receipt Receipt) {
+	if receipt.MemberId != nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.memberReceipts == nil {
+			d.memberReceipts = map[string][]string{}
+		}
+		d.memberReceipts[*receipt.MemberId] = append(d.memberReceipts[*receipt.MemberId], id)
+	}
+}
+
+// GetReceiptIds retrieves the ids of every Receipt in the Database
+// Returns: the receipt ids, sorted
+func (d *Database) GetReceiptIds() []string {
+	var ids []string
+	d.receipts.Range(func(key, value any) bool {
+		ids = append(ids, key.(string))
+		return true
+	})
+	sort.Strings(ids)
+	return ids
+}
+
+// GetMemberReceiptIds retrieves the ids of the Receipts submitted by a member
+// memberId: the id of the member
+// Returns: the receipt ids, in the order they were stored
+func (d *Database) GetMemberReceiptIds(memberId string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.memberReceipts[memberId]...)
+}
+
+// GetScore retrieves the Score a Receipt is pinned to from the Database
+// id: the uuid string associated with a Receipt
+// Returns: the Score for the given id
+func (d *Database) GetScore(id string) (Score, error) {
+	value, ok := d.scores.Load(id)
+	if !ok {
+		return Score{}, fmt.Errorf("score not found")
+	}
+	score, _ := value.(Score)
+	return score, nil
+}
+
+// PutScore stores the Score a Receipt is pinned to in the Database, replacing
+// its previous Score in the analytics rollups
+// id: the uuid string associated with a Receipt
+// score: the Score to store
+func (d *Database) PutScore(id string, score Score) {
+	value, replaced := d.scores.Swap(id, score)
+	receipt, err := d.GetReceipt(id)
+	if err != nil {
+		return
+	}
+	var previous *Score
+	if replaced {
+		replacedScore, _ := value.(Score)
+		previous = &replacedScore
+	}
+	d.rollupScore(receipt, previous, score)
+}
+
+// GetMemberIds retrieves the ids of every member with a profile or a Receipt
+// Returns: the member ids, sorted
+func (d *Database) GetMemberIds() []string {
+	ids := map[string]bool{}
+	d.members.Range(func(key, value any) bool {
+		ids[key.(string)] = true
+		return true
+	})
+	d.mu.Lock()
+	for id := range d.memberReceipts {
+		ids[id] = true
+	}
+	d.mu.Unlock()
+	var sorted []string
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// GetTier retrieves the TierStatus of a member from the Database
+// id: the id of the member
+// Returns: the TierStatus for the given id
+func (d *Database) GetTier(id string) (TierStatus, error) {
+	value, ok := d.tiers.Load(id)
+	if !ok {
+		return TierStatus{}, fmt.Errorf("tier not found")
+	}
+	status, _ := value.(TierStatus)
+	return status, nil
+}
+
+// PutTier stores the TierStatus of a member in the Database
+// id: the id of the member
+// status: the TierStatus to store
+func (d *Database) PutTier(id string, status TierStatus) {
+	d.tiers.Store(id, status)
+}
+
+// GetReview retrieves the Review of a flagged Receipt from the Database
+// id: the uuid string associated with a Receipt
+// Returns: the Review for the given id
+func (d *Database) GetReview(id string) (Review, error) {
+	value, ok := d.reviews.Load(id)
+	if !ok {
+		return Review{}, fmt.Errorf("review not found")
+	}
+	review, _ := value.(Review)
+	return review, nil
+}
+
+// PutReview stores the Review of a flagged Receipt in the Database
+// id: the uuid string associated with a Receipt
+// review: the Review to store
+func (d *Database) PutReview(id string, review Review) {
+	d.reviews.Store(id, review)
+}
+
+// GetAttachment retrieves the Attachment of a Receipt from the Database
+// id: the uuid string associated with a Receipt
+// Returns: the Attachment for the given id
+func (d *Database) GetAttachment(id string) (Attachment, error) {
+	value, ok := d.attachments.Load(id)
+	if !ok {
+		return Attachment{}, fmt.Errorf("attachment not found")
+	}
+	attachment, _ := value.(Attachment)
+	return attachment, nil
+}
+
+// PutAttachment stores the Attachment of a Receipt in the Database
+// id: the uuid string associated with a Receipt
+// attachment: the Attachment to store
+func (d *Database) PutAttachment(id string, attachment Attachment) {
+	d.attachments.Store(id, attachment)
+}
+
+// GetReviews retrieves every Review in the Database
+// Returns: the reviews, oldest flagged first
+func (d *Database) GetReviews() []Review {
+	var reviews []Review
+	d.reviews.Range(func(key, value any) bool {
+		reviews = append(reviews, value.(Review))
+		return true
+	})
+	sort.Slice(reviews, func(i, j int) bool {
+		if reviews[i].FlaggedAt.Equal(reviews[j].FlaggedAt) {
+			return reviews[i].ReceiptId < reviews[j].ReceiptId
+		}
+		return reviews[i].FlaggedAt.Before(reviews[j].FlaggedAt)
+	})
+	return reviews
+}
+
+// GetMember retrieves a Member from the Database
+// id: the id of the Member
+// Returns: the Member for the given id
+func (d *Database) GetMember(id string) (Member, error) {
+	value, ok := d.members.Load(id)
+	if !ok {
+		return Member{}, fmt.Errorf("member not found")
+	}
+	member, _ := value.(Member)
+	return member, nil
+}
+
+// PutMember stores a Member in the Database
+// id: the id of the Member
+// member: the Member to store
+func (d *Database) PutMember(id string, member Member) {
+	d.members.Store(id, member)
 }
@@ -0,0 +1,905 @@
+/*
+expr.go contains a small, sandboxed expression language over a Receipt, used by
+expression rules. Expressions are parsed and type checked once by CompileExpression,
+and evaluated with a step and time budget, so a rule cannot hang the processor.
+
+	len(items) >= 5 && total > 20 ? 15 : 0
+	count(items, contains(lower(it.shortDescription), "pepsi")) * 5
+	weekday == "saturday" && hour < 10 ? 25 : 0
+	paymentMethod == "store-card" ? 50 : 0
+*/
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exprType is the static type of an expression
+type exprType int
+
+const (
+	numberType exprType = iota
+	stringType
+	boolType
+	itemType
+	itemsType
+)
+
+// String returns the name of the type used in compile errors
+func (t exprType) String() string {
+	return [...]string{"number", "string", "bool", "item", "items"}[t]
+}
+
+// exprVariables are the receipt values available to expressions
+var exprVariables = map[string]struct {
+	typ   exprType
+	value func(e *exprEnv) any
+}{
+	"retailer":     {stringType, func(e *exprEnv) any { return e.receipt.Retailer }},
+	"total":        {numberType, func(e *exprEnv) any { return parseAmount(e.receipt.Total) }},
+	"items":        {itemsType, func(e *exprEnv) any { return e.receipt.Items }},
+	"purchaseDate": {stringType, func(e *exprEnv) any { return e.receipt.PurchaseDate.Format("2006-01-02") }},
+	"purchaseTime": {stringType, func(e *exprEnv) any { return e.receipt.PurchaseTime }},
+	"memberId": {stringType, func(e *exprEnv) any {
+		if e.receipt.MemberId == nil {
+			return ""
+		}
+		return *e.receipt.MemberId
+	}},
+	"storeId": {stringType, func(e *exprEnv) any { return optionalString(e.receipt.StoreId) }},
+	"paymentMethod": {stringType, func(e *exprEnv) any {
+		if e.receipt.PaymentMethod == nil {
+			return ""
+		}
+		return string(*e.receipt.PaymentMethod)
+	}},
+	"discount": {numberType, func(e *exprEnv) any { return float64(receiptDiscount(e.receipt)) / 100 }},
+	"coupons": {numberType, func(e *exprEnv) any {
+		coupons := 0
+		if e.receipt.Discounts != nil {
+			for _, discount := range *e.receipt.Discounts {
+				if discount.Code != nil {
+					coupons++
+				}
+			}
+		}
+		return float64(coupons)
+	}},
+	"tax":     {numberType, func(e *exprEnv) any { return float64(receiptTax(e.receipt)) / 100 }},
+	"tip":     {numberType, func(e *exprEnv) any { return float64(receiptTip(e.receipt)) / 100 }},
+	"year":    {numberType, func(e *exprEnv) any { return float64(e.receipt.PurchaseDate.Year()) }},
+	"month":   {numberType, func(e *exprEnv) any { return float64(e.receipt.PurchaseDate.Month()) }},
+	"day":     {numberType, func(e *exprEnv) any { return float64(e.receipt.PurchaseDate.Day()) }},
+	"weekday": {stringType, func(e *exprEnv) any { return strings.ToLower(e.receipt.PurchaseDate.Weekday().String()) }},
+	"hour":    {numberType, func(e *exprEnv) any { return float64(e.purchased().Hour()) }},
+	"minute":  {numberType, func(e *exprEnv) any { return float64(e.purchased().Minute()) }},
+}
+
+// exprItemFields are the item values available to expressions, e.g. it.price
+var exprItemFields = map[string]struct {
+	typ   exprType
+	value func(item Item) any
+}{
+	"shortDescription": {stringType, func(item Item) any { return item.ShortDescription }},
+	"price":            {numberType, func(item Item) any { return parseAmount(item.Price) }},
+	"quantity":         {numberType, func(item Item) any { return itemQuantity(item) }},
+	"unitPrice": {numberType, func(item Item) any {
+		if item.UnitPrice == nil {
+			return (parseAmount(item.Price) + parseAmount(optionalString(item.Discount))) / itemQuantity(item)
+		}
+		return parseAmount(*item.UnitPrice)
+	}},
+	"discount": {numberType, func(item Item) any { return parseAmount(optionalString(item.Discount)) }},
+}
+
+// exprFunction is a builtin function. Functions taking items and a predicate
+// evaluate the predicate for each item, with the item bound to it.
+type exprFunction struct {
+	// args are the types of the arguments
+	args []exprType
+	// result is the type of the result
+	result exprType
+	// lambda is the type of the expression evaluated for each item, if any
+	lambda *exprType
+	// call evaluates the function with the evaluated arguments
+	call func(args []any) (any, error)
+	// each evaluates the function over items, evaluating fn for each item
+	each func(items []Item, fn func(item Item) (any, error)) (any, error)
+}
+
+var boolLambda, numberLambda = boolType, numberType
+
+// exprFunctions are the builtin functions available to expressions
+var exprFunctions = map[string]exprFunction{
+	"len":        {args: []exprType{itemsType}, result: numberType, call: func(a []any) (any, error) { return float64(len(a[0].([]Item))), nil }},
+	"strlen":     {args: []exprType{stringType}, result: numberType, call: func(a []any) (any, error) { return float64(len(a[0].(string))), nil }},
+	"lower":      {args: []exprType{stringType}, result: stringType, call: func(a []any) (any, error) { return strings.ToLower(a[0].(string)), nil }},
+	"upper":      {args: []exprType{stringType}, result: stringType, call: func(a []any) (any, error) { return strings.ToUpper(a[0].(string)), nil }},
+	"trim":       {args: []exprType{stringType}, result: stringType, call: func(a []any) (any, error) { return strings.TrimSpace(a[0].(string)), nil }},
+	"contains":   {args: []exprType{stringType, stringType}, result: boolType, call: func(a []any) (any, error) { return strings.Contains(a[0].(string), a[1].(string)), nil }},
+	"startsWith": {args: []exprType{stringType, stringType}, result: boolType, call: func(a []any) (any, error) { return strings.HasPrefix(a[0].(string), a[1].(string)), nil }},
+	"endsWith":   {args: []exprType{stringType, stringType}, result: boolType, call: func(a []any) (any, error) { return strings.HasSuffix(a[0].(string), a[1].(string)), nil }},
+	"floor":      {args: []exprType{numberType}, result: numberType, call: func(a []any) (any, error) { return math.Floor(a[0].(float64)), nil }},
+	"ceil":       {args: []exprType{numberType}, result: numberType, call: func(a []any) (any, error) { return math.Ceil(a[0].(float64)), nil }},
+	"round":      {args: []exprType{numberType}, result: numberType, call: func(a []any) (any, error) { return math.Round(a[0].(float64)), nil }},
+	"abs":        {args: []exprType{numberType}, result: numberType, call: func(a []any) (any, error) { return math.Abs(a[0].(float64)), nil }},
+	"min":        {args: []exprType{numberType, numberType}, result: numberType, call: func(a []any) (any, error) { return math.Min(a[0].(float64), a[1].(float64)), nil }},
+	"max":        {args: []exprType{numberType, numberType}, result: numberType, call: func(a []any) (any, error) { return math.Max(a[0].(float64), a[1].(float64)), nil }},
+	"count": {args: []exprType{itemsType}, result: numberType, lambda: &boolLambda, each: func(items []Item, fn func(Item) (any, error)) (any, error) {
+		count := 0.0
+		for _, item := range items {
+			match, err := fn(item)
+			if err != nil {
+				return nil, err
+			}
+			if match.(bool) {
+				count++
+			}
+		}
+		return count, nil
+	}},
+	"sum": {args: []exprType{itemsType}, result: numberType, lambda: &numberLambda, each: func(items []Item, fn func(Item) (any, error)) (any, error) {
+		sum := 0.0
+		for _, item := range items {
+			value, err := fn(item)
+			if err != nil {
+				return nil, err
+			}
+			sum += value.(float64)
+		}
+		return sum, nil
+	}},
+	"any": {args: []exprType{itemsType}, result: boolType, lambda: &boolLambda, each: func(items []Item, fn func(Item) (any, error)) (any, error) {
+		for _, item := range items {
+			match, err := fn(item)
+			if err != nil || match.(bool) {
+				return match, err
+			}
+		}
+		return false, nil
+	}},
+	"all": {args: []exprType{itemsType}, result: boolType, lambda: &boolLambda, each: func(items []Item, fn func(Item) (any, error)) (any, error) {
+		for _, item := range items {
+			match, err := fn(item)
+			if err != nil || !match.(bool) {
+				return match, err
+			}
+		}
+		return true, nil
+	}},
+}
+
+// maxExpressionLength is the longest expression source accepted
+const maxExpressionLength = 4096
+
+// ErrStepLimit is returned when an expression evaluates more steps than allowed
+var ErrStepLimit = errors.New("expression exceeded its step limit")
+
+// ErrTimeout is returned when an expression evaluates for longer than allowed
+var ErrTimeout = errors.New("expression exceeded its time limit")
+
+// Expression is a compiled expression returning a number
+type Expression struct {
+	// source is the expression as written
+	source string
+	// root is the type checked syntax tree
+	root exprNode
+}
+
+// CompileExpression parses and type checks an expression, which must return a number
+// source: the expression
+// Returns: the compiled Expression, or an error with the line:column of the problem
+func CompileExpression(source string) (*Expression, error) {
+	if len(source) > maxExpressionLength {
+		return nil, fmt.Errorf("expression is longer than %d characters", maxExpressionLength)
+	}
+	tokens, err := lexExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{source: source, tokens: tokens}
+	root, err := p.parse(0)
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.kind != tokenEOF {
+		return nil, p.errorf(token, "unexpected %s", token)
+	}
+	if root.typ() != numberType {
+		return nil, fmt.Errorf("expression must return a number, not a %s", root.typ())
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the expression as written
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Evaluate evaluates the Expression for a Receipt
+// receipt: the Receipt the variables are read from
+// location: the time zone purchase times are read in
+// maxSteps: the most nodes evaluated before ErrStepLimit
+// timeout: the longest evaluation before ErrTimeout
+// Returns: the number the Expression returned
+func (e *Expression) Evaluate(receipt Receipt, location *time.Location, maxSteps int, timeout time.Duration) (float64, error) {
+	env := &exprEnv{
+		receipt:  receipt,
+		location: location,
+		maxSteps: maxSteps,
+		deadline: time.Now().Add(timeout),
+	}
+	value, err := e.root.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	return value.(float64), nil
+}
+
+// exprEnv is the state of an evaluation
+type exprEnv struct {
+	// receipt is the Receipt the variables are read from
+	receipt Receipt
+	// location is the time zone purchase times are read in
+	location *time.Location
+	// it is the item bound while evaluating a predicate
+	it Item
+	// steps is the number of nodes evaluated
+	steps int
+	// maxSteps is the most nodes evaluated
+	maxSteps int
+	// deadline is when the evaluation times out
+	deadline time.Time
+}
+
+// step counts a node evaluation against the budget, checking the deadline periodically
+func (e *exprEnv) step() error {
+	e.steps++
+	if e.steps > e.maxSteps {
+		return ErrStepLimit
+	}
+	if e.steps%64 == 0 && time.Now().After(e.deadline) {
+		return ErrTimeout
+	}
+	return nil
+}
+
+// purchased returns the purchase time in the store time zone
+func (e *exprEnv) purchased() time.Time {
+	location, err := ReceiptLocation(e.receipt, e.location)
+	if err != nil {
+		location = e.location
+	}
+	purchased, _ := PurchaseTime(e.receipt, location)
+	return purchased
+}
+
+// parseAmount parses a dollar amount, zero when invalid
+func parseAmount(amount string) float64 {
+	value, _ := strconv.ParseFloat(amount, 64)
+	return value
+}
+
+// optionalString returns an optional receipt value, empty when not given
+func optionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// tokenKind is the kind of a lexical token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+// exprToken is a lexical token, with its position in the source
+type exprToken struct {
+	kind  tokenKind
+	text  string
+	value any
+	pos   int
+}
+
+// String describes the token in compile errors
+func (t exprToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// exprOperators are the operators, longest first so they lex greedily
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "+", "-", "*", "/", "%", "<", ">", "!", "?", ":", "(", ")", "[", "]", ",", "."}
+
+// lexExpression splits an expression into tokens
+func lexExpression(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	for pos := 0; pos < len(source); {
+		c := source[pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+		case c >= '0' && c <= '9':
+			end := pos
+			for end < len(source) && (source[end] >= '0' && source[end] <= '9' || source[end] == '.') {
+				end++
+			}
+			value, err := strconv.ParseFloat(source[pos:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid number %q", position(source, pos), source[pos:end])
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: source[pos:end], value: value, pos: pos})
+			pos = end
+		case c == '"' || c == '\'':
+			end := pos + 1
+			for end < len(source) && source[end] != c {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, fmt.Errorf("%s: unterminated string", position(source, pos))
+			}
+			quoted := source[pos : end+1]
+			if c == '\'' {
+				quoted = `"` + strings.ReplaceAll(strings.ReplaceAll(quoted[1:len(quoted)-1], `\'`, `'`), `"`, `\"`) + `"`
+			}
+			value, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid string %s", position(source, pos), source[pos:end+1])
+			}
+			tokens = append(tokens, exprToken{kind: tokenString, text: source[pos : end+1], value: value, pos: pos})
+			pos = end + 1
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			end := pos
+			for end < len(source) && (source[end] == '_' || source[end] >= 'a' && source[end] <= 'z' || source[end] >= 'A' && source[end] <= 'Z' || source[end] >= '0' && source[end] <= '9') {
+				end++
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: source[pos:end], pos: pos})
+			pos = end
+		default:
+			found := false
+			for _, operator := range exprOperators {
+				if strings.HasPrefix(source[pos:], operator) {
+					tokens = append(tokens, exprToken{kind: tokenOperator, text: operator, pos: pos})
+					pos += len(operator)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%s: unexpected character %q", position(source, pos), c)
+			}
+		}
+	}
+	return append(tokens, exprToken{kind: tokenEOF, pos: len(source)}), nil
+}
+
+// position formats an offset in the source as line:column
+func position(source string, pos int) string {
+	line, column := 1, 1
+	for _, c := range source[:pos] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return fmt.Sprintf("%d:%d", line, column)
+}
+
+// binaryPrecedence is the precedence of each binary operator, higher binds tighter
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// exprParser is a precedence climbing parser that type checks as it parses
+type exprParser struct {
+	source string
+	tokens []exprToken
+	next   int
+	// inLambda is set while parsing a predicate, where it is bound
+	inLambda bool
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.next]
+}
+
+func (p *exprParser) advance() exprToken {
+	token := p.tokens[p.next]
+	if token.kind != tokenEOF {
+		p.next++
+	}
+	return token
+}
+
+// expect consumes an operator, or fails
+func (p *exprParser) expect(operator string) error {
+	token := p.advance()
+	if token.kind != tokenOperator || token.text != operator {
+		return p.errorf(token, "expected %q, found %s", operator, token)
+	}
+	return nil
+}
+
+// errorf formats a compile error at the position of a token
+func (p *exprParser) errorf(token exprToken, format string, args ...any) error {
+	return fmt.Errorf("%s: %s", position(p.source, token.pos), fmt.Sprintf(format, args...))
+}
+
+// parse parses an expression whose binary operators bind tighter than minPrecedence
+func (p *exprParser) parse(minPrecedence int) (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token := p.peek()
+		if token.kind != tokenOperator {
+			return left, nil
+		}
+		if token.text == "?" && minPrecedence == 0 {
+			return p.parseConditional(left)
+		}
+		precedence, ok := binaryPrecedence[token.text]
+		if !ok || precedence <= minPrecedence {
+			return left, nil
+		}
+		p.advance()
+		right, err := p.parse(precedence)
+		if err != nil {
+			return nil, err
+		}
+		if left, err = p.binary(token, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseConditional parses the branches of cond ? then : else
+func (p *exprParser) parseConditional(cond exprNode) (exprNode, error) {
+	token := p.advance()
+	if cond.typ() != boolType {
+		return nil, p.errorf(token, "condition must be a bool, not a %s", cond.typ())
+	}
+	then, err := p.parse(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parse(0)
+	if err != nil {
+		return nil, err
+	}
+	if then.typ() != otherwise.typ() {
+		return nil, p.errorf(token, "branches must have the same type, not %s and %s", then.typ(), otherwise.typ())
+	}
+	return &conditionalNode{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// binary type checks a binary operator
+func (p *exprParser) binary(token exprToken, left exprNode, right exprNode) (exprNode, error) {
+	operator := token.text
+	node := &binaryNode{operator: operator, left: left, right: right, result: boolType}
+	mismatch := p.errorf(token, "invalid operation %s %s %s", left.typ(), operator, right.typ())
+	switch operator {
+	case "&&", "||":
+		if left.typ() != boolType || right.typ() != boolType {
+			return nil, mismatch
+		}
+	case "==", "!=":
+		if left.typ() != right.typ() || left.typ() == itemType || left.typ() == itemsType {
+			return nil, mismatch
+		}
+	case "<", "<=", ">", ">=":
+		if left.typ() != right.typ() || left.typ() != numberType && left.typ() != stringType {
+			return nil, mismatch
+		}
+	case "+":
+		if left.typ() != right.typ() || left.typ() != numberType && left.typ() != stringType {
+			return nil, mismatch
+		}
+		node.result = left.typ()
+	default:
+		if left.typ() != numberType || right.typ() != numberType {
+			return nil, mismatch
+		}
+		node.result = numberType
+	}
+	return node, nil
+}
+
+// parseUnary parses prefix operators, then a primary expression with its postfix operators
+func (p *exprParser) parseUnary() (exprNode, error) {
+	token := p.peek()
+	if token.kind == tokenOperator && (token.text == "!" || token.text == "-") {
+		p.advance()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if token.text == "!" && operand.typ() != boolType || token.text == "-" && operand.typ() != numberType {
+			return nil, p.errorf(token, "invalid operation %s%s", token.text, operand.typ())
+		}
+		return &unaryNode{operator: token.text, operand: operand}, nil
+	}
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token := p.peek()
+		switch {
+		case token.kind == tokenOperator && token.text == ".":
+			p.advance()
+			field := p.advance()
+			if field.kind != tokenIdent {
+				return nil, p.errorf(field, "expected a field name, found %s", field)
+			}
+			if node.typ() != itemType {
+				return nil, p.errorf(field, "%s has no field %s", node.typ(), field.text)
+			}
+			definition, ok := exprItemFields[field.text]
+			if !ok {
+				return nil, p.errorf(field, "unknown item field %s", field.text)
+			}
+			node = &fieldNode{item: node, result: definition.typ, value: definition.value}
+		case token.kind == tokenOperator && token.text == "[":
+			p.advance()
+			index, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			if node.typ() != itemsType || index.typ() != numberType {
+				return nil, p.errorf(token, "cannot index %s with %s", node.typ(), index.typ())
+			}
+			node = &indexNode{items: node, index: index}
+		default:
+			return node, nil
+		}
+	}
+}
+
+// parsePrimary parses literals, variables, calls and parentheses
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	token := p.advance()
+	switch token.kind {
+	case tokenNumber:
+		return &literalNode{value: token.value, result: numberType}, nil
+	case tokenString:
+		return &literalNode{value: token.value, result: stringType}, nil
+	case tokenIdent:
+		switch token.text {
+		case "true", "false":
+			return &literalNode{value: token.text == "true", result: boolType}, nil
+		case "it":
+			if !p.inLambda {
+				return nil, p.errorf(token, "it is only defined in the predicate of count, sum, any and all")
+			}
+			return &itNode{}, nil
+		}
+		if next := p.peek(); next.kind == tokenOperator && next.text == "(" {
+			return p.parseCall(token)
+		}
+		variable, ok := exprVariables[token.text]
+		if !ok {
+			return nil, p.errorf(token, "unknown variable %s", token.text)
+		}
+		return &variableNode{result: variable.typ, value: variable.value}, nil
+	case tokenOperator:
+		if token.text == "(" {
+			node, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		}
+	}
+	return nil, p.errorf(token, "unexpected %s", token)
+}
+
+// parseCall parses and type checks the arguments of a function call
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	function, ok := exprFunctions[name.text]
+	if !ok {
+		return nil, p.errorf(name, "unknown function %s", name.text)
+	}
+	p.advance()
+	want := len(function.args)
+	if function.lambda != nil {
+		want++
+	}
+	var args []exprNode
+	for {
+		if next := p.peek(); next.kind == tokenOperator && next.text == ")" && len(args) == 0 {
+			break
+		}
+		// the predicate of an items function is parsed with it bound
+		inLambda := p.inLambda
+		p.inLambda = inLambda || function.lambda != nil && len(args) == len(function.args)
+		arg, err := p.parse(0)
+		p.inLambda = inLambda
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if next := p.peek(); next.kind != tokenOperator || next.text != "," {
+			break
+		}
+		p.advance()
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if len(args) != want {
+		return nil, p.errorf(name, "%s takes %d arguments, not %d", name.text, want, len(args))
+	}
+	for i, typ := range function.args {
+		if args[i].typ() != typ {
+			return nil, p.errorf(name, "argument %d of %s must be a %s, not a %s", i+1, name.text, typ, args[i].typ())
+		}
+	}
+	if function.lambda != nil && args[want-1].typ() != *function.lambda {
+		return nil, p.errorf(name, "argument %d of %s must be a %s, not a %s", want, name.text, *function.lambda, args[want-1].typ())
+	}
+	return &callNode{function: function, args: args}, nil
+}
+
+// exprNode is a type checked node of the syntax tree
+type exprNode interface {
+	// typ returns the static type of the node
+	typ() exprType
+	// eval evaluates the node, counting steps
+	eval(env *exprEnv) (any, error)
+}
+
+type literalNode struct {
+	value  any
+	result exprType
+}
+
+func (n *literalNode) typ() exprType { return n.result }
+
+func (n *literalNode) eval(env *exprEnv) (any, error) {
+	return n.value, env.step()
+}
+
+type variableNode struct {
+	result exprType
+	value  func(env *exprEnv) any
+}
+
+func (n *variableNode) typ() exprType { return n.result }
+
+func (n *variableNode) eval(env *exprEnv) (any, error) {
+	if err := env.step(); err != nil {
+		return nil, err
+	}
+	return n.value(env), nil
+}
+
+type itNode struct{}
+
+func (n *itNode) typ() exprType { return itemType }
+
+func (n *itNode) eval(env *exprEnv) (any, error) {
+	return env.it, env.step()
+}
+
+type fieldNode struct {
+	item   exprNode
+	result exprType
+	value  func(item Item) any
+}
+
+func (n *fieldNode) typ() exprType { return n.result }
+
+func (n *fieldNode) eval(env *exprEnv) (any, error) {
+	item, err := n.item.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return n.value(item.(Item)), env.step()
+}
+
+type indexNode struct {
+	items exprNode
+	index exprNode
+}
+
+func (n *indexNode) typ() exprType { return itemType }
+
+func (n *indexNode) eval(env *exprEnv) (any, error) {
+	items, err := n.items.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	i := int(index.(float64))
+	if i < 0 || i >= len(items.([]Item)) || float64(i) != index.(float64) {
+		return nil, fmt.Errorf("index %v out of range for %d items", index, len(items.([]Item)))
+	}
+	return items.([]Item)[i], env.step()
+}
+
+type unaryNode struct {
+	operator string
+	operand  exprNode
+}
+
+func (n *unaryNode) typ() exprType { return n.operand.typ() }
+
+func (n *unaryNode) eval(env *exprEnv) (any, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.operator == "!" {
+		return !value.(bool), env.step()
+	}
+	return -value.(float64), env.step()
+}
+
+type binaryNode struct {
+	operator string
+	left     exprNode
+	right    exprNode
+	result   exprType
+}
+
+func (n *binaryNode) typ() exprType { return n.result }
+
+func (n *binaryNode) eval(env *exprEnv) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if err := env.step(); err != nil {
+		return nil, err
+	}
+	// short circuit
+	switch {
+	case n.operator == "&&" && !left.(bool):
+		return false, nil
+	case n.operator == "||" && left.(bool):
+		return true, nil
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.operator {
+	case "&&", "||":
+		return right.(bool), nil
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	}
+	if l, ok := left.(string); ok {
+		r := right.(string)
+		switch n.operator {
+		case "+":
+			if len(l)+len(r) > maxExpressionLength {
+				return nil, fmt.Errorf("string longer than %d characters", maxExpressionLength)
+			}
+			return l + r, nil
+		case "<":
+			return l < r, nil
+		case "<=":
+			return l <= r, nil
+		case ">":
+			return l > r, nil
+		default:
+			return l >= r, nil
+		}
+	}
+	l, r := left.(float64), right.(float64)
+	switch n.operator {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	default:
+		return l >= r, nil
+	}
+}
+
+type conditionalNode struct {
+	cond      exprNode
+	then      exprNode
+	otherwise exprNode
+}
+
+func (n *conditionalNode) typ() exprType { return n.then.typ() }
+
+func (n *conditionalNode) eval(env *exprEnv) (any, error) {
+	cond, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if err := env.step(); err != nil {
+		return nil, err
+	}
+	if cond.(bool) {
+		return n.then.eval(env)
+	}
+	return n.otherwise.eval(env)
+}
+
+type callNode struct {
+	function exprFunction
+	args     []exprNode
+}
+
+func (n *callNode) typ() exprType { return n.function.result }
+
+func (n *callNode) eval(env *exprEnv) (any, error) {
+	var args []any
+	for _, arg := range n.args[:len(n.function.args)] {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+	if err := env.step(); err != nil {
+		return nil, err
+	}
+	if n.function.each == nil {
+		return n.function.call(args)
+	}
+	lambda := n.args[len(n.args)-1]
+	outer := env.it
+	defer func() { env.it = outer }()
+	return n.function.each(args[0].([]Item), func(item Item) (any, error) {
+		env.it = item
+		return lambda.eval(env)
+	})
+}
@@ -0,0 +1,193 @@
+/*
+expr_test.go contains functions for testing the expression language and expression rules.
+*/
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExpressions verifies expressions evaluate over the Receipt
+func TestExpressions(t *testing.T) {
+	receipt := ParseReceipt(t, `{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-01",
+		"purchaseTime": "13:01",
+		"total": "35.35",
+		"items": [
+			{"shortDescription": "Mountain Dew 12PK", "price": "6.49"},
+			{"shortDescription": "Emils Cheese Pizza", "price": "12.25"},
+			{"shortDescription": "Knorr Creamy Chicken", "price": "1.26"},
+			{"shortDescription": "Doritos Nacho Cheese", "price": "3.35"},
+			{"shortDescription": "   Klarbrunn 12-PK 12 FL OZ  ", "price": "12.00"}
+		]
+	}`)
+	tests := []struct {
+		expression string
+		expected   float64
+	}{
+		{expression: "len(items) >= 5 && total > 20 ? 15 : 0", expected: 15},
+		{expression: "len(items) >= 6 || total > 50 ? 15 : 0", expected: 0},
+		{expression: "1 + 2 * 3 - 4 / 2", expected: 5},
+		{expression: "(1 + 2) * 3 % 4", expected: 1},
+		{expression: "-total + 0.35", expected: -35},
+		{expression: "floor(total) + ceil(0.1) + round(2.5) + abs(-1)", expected: 40},
+		{expression: "min(total, 10) + max(total, 10)", expected: 45.35},
+		{expression: `count(items, contains(lower(it.shortDescription), "cheese")) * 5`, expected: 10},
+		{expression: "sum(items, it.price > 5 ? it.price : 0)", expected: 30.74},
+		{expression: `any(items, startsWith(it.shortDescription, "Doritos")) ? 1 : 0`, expected: 1},
+		{expression: "all(items, it.price > 1.5) ? 1 : 0", expected: 0},
+		{expression: `strlen(trim(items[4].shortDescription)) + strlen(upper("ab"))`, expected: 26},
+		{expression: `retailer == "Target" && retailer + "!" != "Target" ? 1 : 0`, expected: 1},
+		{expression: `purchaseDate == "2022-01-01" && purchaseTime < "14:00" && memberId == "" ? 1 : 0`, expected: 1},
+		{expression: `year * 10000 + month * 100 + day`, expected: 20220101},
+		{expression: `weekday == 'saturday' ? hour * 100 + minute : 0`, expected: 1301},
+		{expression: "!(total > 20) ? 1 : total > 30 ? 2 : 3", expected: 2},
+		{expression: "count(items, any(items, it.price > 10))", expected: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expression, err := CompileExpression(tt.expression)
+			assert.NoError(t, err)
+			value, err := expression.Evaluate(receipt, time.UTC, defaultMaxSteps, time.Second)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, value, 0.000001)
+		})
+	}
+}
+
+// TestExpressionReceiptLines verifies expressions read the quantities, discounts,
+// taxes, tip, payment method and store of a Receipt
+func TestExpressionReceiptLines(t *testing.T) {
+	receipt := ParseReceipt(t, `{
+		"retailer": "Target",
+		"storeId": "store-0042",
+		"paymentMethod": "store-card",
+		"purchaseDate": "2022-01-01",
+		"purchaseTime": "13:01",
+		"total": "18.91",
+		"items": [
+			{"shortDescription": "Mountain Dew 12PK", "price": "6.49", "quantity": 2, "unitPrice": "3.50", "discount": "0.51"},
+			{"shortDescription": "Bananas", "price": "1.50", "quantity": 2.5},
+			{"shortDescription": "Emils Cheese Pizza", "price": "12.25"}
+		],
+		"discounts": [{"code": "SAVE10", "amount": "1.50"}, {"description": "Loyalty", "amount": "1.00"}],
+		"taxes": [{"amount": "1.17"}, {"amount": "0.50"}],
+		"tip": "2.00"
+	}`)
+	tests := []struct {
+		expression string
+		expected   float64
+	}{
+		{expression: `paymentMethod == "store-card" && storeId == "store-0042" ? 50 : 0`, expected: 50},
+		{expression: "discount + tax + tip", expected: 2.5 + 1.67 + 2},
+		{expression: "coupons", expected: 1},
+		{expression: "sum(items, it.quantity)", expected: 5.5},
+		{expression: "items[0].unitPrice + items[0].discount", expected: 4.01},
+		{expression: "items[1].unitPrice + items[2].unitPrice + items[2].discount", expected: 0.6 + 12.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expression, err := CompileExpression(tt.expression)
+			assert.NoError(t, err)
+			value, err := expression.Evaluate(receipt, time.UTC, defaultMaxSteps, time.Second)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, value, 0.000001)
+		})
+	}
+}
+
+// TestExpressionCompileErrors verifies invalid expressions are rejected with their position
+func TestExpressionCompileErrors(t *testing.T) {
+	tests := []struct {
+		expression  string
+		expectedErr string
+	}{
+		{expression: "", expectedErr: "1:1: unexpected end of expression"},
+		{expression: "1 +", expectedErr: "1:4: unexpected end of expression"},
+		{expression: "(1 + 2", expectedErr: `1:7: expected ")", found end of expression`},
+		{expression: "1 2", expectedErr: `1:3: unexpected "2"`},
+		{expression: "1 # 2", expectedErr: `1:3: unexpected character '#'`},
+		{expression: `"abc`, expectedErr: "1:1: unterminated string"},
+		{expression: "1.2.3", expectedErr: `invalid number "1.2.3"`},
+		{expression: "totl * 2", expectedErr: "1:1: unknown variable totl"},
+		{expression: "exec(1)", expectedErr: "1:1: unknown function exec"},
+		{expression: "total > 5", expectedErr: "must return a number, not a bool"},
+		{expression: `total + "1"`, expectedErr: "1:7: invalid operation number + string"},
+		{expression: "total ? 1 : 0", expectedErr: "condition must be a bool"},
+		{expression: `total > 1 ? 1 : "0"`, expectedErr: "branches must have the same type"},
+		{expression: "len(total)", expectedErr: "argument 1 of len must be a items, not a number"},
+		{expression: "len(items, 1)", expectedErr: "len takes 1 arguments, not 2"},
+		{expression: "count(items, it.price)", expectedErr: "argument 2 of count must be a bool"},
+		{expression: "it.price", expectedErr: "it is only defined in the predicate"},
+		{expression: "items[0].sku", expectedErr: "unknown item field sku"},
+		{expression: "total.price", expectedErr: "number has no field price"},
+		{expression: `items["0"].price`, expectedErr: "cannot index items with string"},
+		{expression: "1 +\n  -true", expectedErr: "2:3: invalid operation -bool"},
+		{expression: strings.Repeat("1+", maxExpressionLength) + "1", expectedErr: "longer than"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := CompileExpression(tt.expression)
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
+// TestExpressionRules verifies expression rules score receipts within their limits
+func TestExpressionRules(t *testing.T) {
+	items := strings.Repeat(`{"shortDescription": "Pepsi", "price": "1.00"},`, 200)
+	receipt := ParseReceipt(t, `{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-01",
+		"purchaseTime": "13:01",
+		"total": "200.00",
+		"items": [`+strings.TrimSuffix(items, ",")+`]
+	}`)
+	processor, err := NewRuleProcessorFromConfig(RuleConfig{
+		ExpressionRules: []ExpressionRule{
+			{RuleName: "big-basket", Expression: "len(items) >= 5 && total > 20 ? 15.9 : 0"},
+			{RuleName: "steps", Expression: "count(items, contains(it.shortDescription, \"Pepsi\"))", MaxSteps: 100},
+			{RuleName: "timeout", Expression: "count(items, any(items, all(items, it.price > 0)))", MaxSteps: maxExpressionSteps, Timeout: "1ns"},
+			{RuleName: "negative", Expression: "0 - total"},
+			{RuleName: "division", Expression: "total / (len(items) - 200)"},
+			{RuleName: "index", Expression: "items[200].price"},
+		},
+	}, nil)
+	assert.NoError(t, err)
+	breakdown := processor.Breakdown(receipt)
+	assert.Equal(t, RuleResult{Rule: "big-basket", Points: 15, Uncapped: 15}, FindResult(t, breakdown, "big-basket"))
+	assert.Equal(t, ErrStepLimit.Error(), FindResult(t, breakdown, "steps").Error)
+	assert.Equal(t, ErrTimeout.Error(), FindResult(t, breakdown, "timeout").Error)
+	assert.Contains(t, FindResult(t, breakdown, "negative").Error, "invalid points -200")
+	assert.Contains(t, FindResult(t, breakdown, "division").Error, "division by zero")
+	assert.Contains(t, FindResult(t, breakdown, "index").Error, "out of range")
+	for _, name := range []string{"steps", "timeout", "negative", "division", "index"} {
+		assert.Equal(t, 0, FindResult(t, breakdown, name).Points, name)
+	}
+
+	// compile errors name the rule
+	_, err = NewRuleProcessorFromConfig(RuleConfig{
+		ExpressionRules: []ExpressionRule{{RuleName: "typo", Expression: "totl > 20 ? 15 : 0"}},
+	}, nil)
+	assert.EqualError(t, err, `expression rule "typo": 1:1: unknown variable totl`)
+	_, err = NewRuleProcessorFromConfig(RuleConfig{
+		ExpressionRules: []ExpressionRule{{RuleName: "slow", Expression: "1", Timeout: "soon"}},
+	}, nil)
+	assert.ErrorContains(t, err, `expression rule "slow" has an invalid timeout`)
+	for rule, message := range map[ExpressionRule]string{
+		{RuleName: "many", Expression: "1", MaxSteps: 1e9}: `expression rule "many" has a maxSteps over 100000`,
+		{RuleName: "long", Expression: "1", Timeout: "1h"}: `expression rule "long" has a timeout over 100ms`,
+	} {
+		_, err = NewRuleProcessorFromConfig(RuleConfig{ExpressionRules: []ExpressionRule{rule}}, nil)
+		assert.EqualError(t, err, message)
+	}
+	_, err = NewRuleProcessorFromConfig(RuleConfig{
+		ExpressionRules: []ExpressionRule{{RuleName: "odd-day", Expression: "1"}},
+	}, nil)
+	assert.ErrorContains(t, err, "duplicate rule name")
+}
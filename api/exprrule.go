@@ -0,0 +1,107 @@
+/*
+exprrule.go contains rules that award points with an expression over a Receipt
+*/
+package api
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// defaultMaxSteps and defaultTimeout limit expressions without their own limits
+const (
+	defaultMaxSteps = 10000
+	defaultTimeout  = 10 * time.Millisecond
+)
+
+// maxExpressionSteps and maxExpressionTimeout are the highest limits an expression
+// may set, as receipts are scored one at a time
+const (
+	maxExpressionSteps   = 100000
+	maxExpressionTimeout = 100 * time.Millisecond
+)
+
+// ExpressionRule awards the points returned by an expression over the Receipt,
+// rounded down. The expression is compiled once by Compile, see expr.go for the
+// variables and functions available. An expression that fails, exceeds its limits
+// or returns negative points awards no points, and reports the error in its RuleResult.
+//
+// Example json, 15 points for 5 or more items on a receipt over $20:
+//
+//	{"name": "big-basket", "expression": "len(items) >= 5 && total > 20 ? 15 : 0"}
+type ExpressionRule struct {
+	// RuleName identifies the rule in a Breakdown
+	RuleName string `json:"name"`
+	// Expression returns the points awarded
+	Expression string `json:"expression"`
+	// MaxSteps is the most nodes evaluated per receipt, defaults to 10000, at most 100000
+	MaxSteps int `json:"maxSteps,omitempty"`
+	// Timeout is the longest evaluation per receipt, e.g. 5ms, defaults to 10ms, at most 100ms
+	Timeout string `json:"timeout,omitempty"`
+
+	// compiled is the compiled Expression, set by Compile
+	compiled *Expression
+	// timeout is the parsed Timeout, set by Compile
+	timeout time.Duration
+	// location is the time zone of receipts without one, set by Compile
+	location *time.Location
+}
+
+// Compile validates the rule and compiles its expression
+// location: the time zone of receipts without a time zone or UTC offset
+func (e *ExpressionRule) Compile(location *time.Location) error {
+	if e.RuleName == "" {
+		return fmt.Errorf("expression rule is missing a name")
+	}
+	if e.MaxSteps < 0 {
+		return fmt.Errorf("expression rule %q has a negative maxSteps", e.RuleName)
+	}
+	if e.MaxSteps > maxExpressionSteps {
+		return fmt.Errorf("expression rule %q has a maxSteps over %d", e.RuleName, maxExpressionSteps)
+	}
+	if e.MaxSteps == 0 {
+		e.MaxSteps = defaultMaxSteps
+	}
+	e.timeout = defaultTimeout
+	if e.Timeout != "" {
+		timeout, err := time.ParseDuration(e.Timeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("expression rule %q has an invalid timeout %q", e.RuleName, e.Timeout)
+		}
+		if timeout > maxExpressionTimeout {
+			return fmt.Errorf("expression rule %q has a timeout over %s", e.RuleName, maxExpressionTimeout)
+		}
+		e.timeout = timeout
+	}
+	compiled, err := CompileExpression(e.Expression)
+	if err != nil {
+		return fmt.Errorf("expression rule %q: %w", e.RuleName, err)
+	}
+	e.compiled = compiled
+	e.location = location
+	return nil
+}
+
+// Name returns the name of the rule
+func (e *ExpressionRule) Name() string {
+	return e.RuleName
+}
+
+// Score evaluates the expression for the Receipt
+func (e *ExpressionRule) Score(r Receipt) RuleResult {
+	result := RuleResult{Rule: e.RuleName}
+	value, err := e.compiled.Evaluate(r, e.location, e.MaxSteps, e.timeout)
+	if err == nil && (value < 0 || math.IsNaN(value) || math.IsInf(value, 0)) {
+		err = fmt.Errorf("expression returned invalid points %v", value)
+	}
+	if err != nil {
+		log.Printf("expression rule %q: %v", e.RuleName, err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Points = int(math.Min(math.Floor(value), math.MaxInt32))
+	result.Uncapped = result.Points
+	return result
+}
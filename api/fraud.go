@@ -0,0 +1,337 @@
+/*
+fraud.go contains the detection of duplicate and fraudulent receipts
+*/
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Fraud flag types
+const (
+	// DuplicateFlag marks a receipt with the same fingerprint as another receipt
+	DuplicateFlag = "duplicate"
+	// NearDuplicateFlag marks a receipt like another receipt with small edits
+	NearDuplicateFlag = "near-duplicate"
+	// VelocityFlag marks a receipt submitted after too many receipts from the member
+	VelocityFlag = "velocity"
+	// UnusualTotalFlag marks a receipt whose total is far from the retailer's usual totals
+	UnusualTotalFlag = "unusual-total"
+)
+
+// FraudConfig tunes the fraud checks, zero values use the defaults
+type FraudConfig struct {
+	// Disabled turns off the fraud checks
+	Disabled bool `json:"disabled,omitempty"`
+	// NearDuplicateMinutes is how far apart the purchase times of near-duplicates
+	// may be, defaults to 10
+	NearDuplicateMinutes int `json:"nearDuplicateMinutes,omitempty"`
+	// NearDuplicateSimilarity is how similar the items of near-duplicates must be,
+	// from 0 to 1, defaults to 0.75
+	NearDuplicateSimilarity float64 `json:"nearDuplicateSimilarity,omitempty"`
+	// MaxReceiptsPerHour is the most receipts a member may submit in an hour, defaults to 5
+	MaxReceiptsPerHour int `json:"maxReceiptsPerHour,omitempty"`
+	// UnusualTotalZScore is how many standard deviations from the mean an
+	// unusual total is, defaults to 4
+	UnusualTotalZScore float64 `json:"unusualTotalZScore,omitempty"`
+	// MinSamples is the fewest totals needed before totals are checked, defaults to 30
+	MinSamples int `json:"minSamples,omitempty"`
+}
+
+// withDefaults validates the FraudConfig and fills in the defaults
+func (c FraudConfig) withDefaults() (FraudConfig, error) {
+	if c.NearDuplicateMinutes < 0 || c.MaxReceiptsPerHour < 0 || c.UnusualTotalZScore < 0 || c.MinSamples < 0 {
+		return c, fmt.Errorf("fraud settings must not be negative")
+	}
+	if c.NearDuplicateSimilarity < 0 || c.NearDuplicateSimilarity > 1 {
+		return c, fmt.Errorf("fraud nearDuplicateSimilarity must be from 0 to 1")
+	}
+	if c.NearDuplicateMinutes == 0 {
+		c.NearDuplicateMinutes = 10
+	}
+	if c.NearDuplicateSimilarity == 0 {
+		c.NearDuplicateSimilarity = 0.75
+	}
+	if c.MaxReceiptsPerHour == 0 {
+		c.MaxReceiptsPerHour = 5
+	}
+	if c.UnusualTotalZScore == 0 {
+		c.UnusualTotalZScore = 4
+	}
+	if c.MinSamples == 0 {
+		c.MinSamples = 30
+	}
+	return c, nil
+}
+
+// FraudFlag is a reason to review a receipt
+type FraudFlag struct {
+	// Type is one of duplicate, near-duplicate, velocity or unusual-total
+	Type string `json:"type"`
+	// Reason describes why the receipt was flagged
+	Reason string `json:"reason"`
+	// ReceiptId is the receipt the flagged receipt duplicates, if any
+	ReceiptId string `json:"receiptId,omitempty"`
+}
+
+// fingerprinted is a receipt normalized for comparison
+type fingerprinted struct {
+	id       string
+	retailer string
+	minutes  int
+	cents    int64
+	items    map[string]int
+}
+
+// totalStats is a running mean and variance of totals, by Welford's algorithm
+type totalStats struct {
+	count int
+	mean  float64
+	m2    float64
+}
+
+// add adds a total to the stats
+func (s *totalStats) add(total float64) {
+	s.count++
+	delta := total - s.mean
+	s.mean += delta / float64(s.count)
+	s.m2 += delta * (total - s.mean)
+}
+
+// zScore returns how many standard deviations a total is from the mean
+func (s *totalStats) zScore(total float64) float64 {
+	if s.count < 2 {
+		return 0
+	}
+	stddev := math.Sqrt(s.m2 / float64(s.count-1))
+	if stddev == 0 {
+		return 0
+	}
+	return math.Abs(total-s.mean) / stddev
+}
+
+// FraudDetector flags duplicate and fraudulent receipts, remembering every receipt checked
+type FraudDetector struct {
+	// mu guards the fields below
+	mu sync.Mutex
+	// fingerprints is a map of fingerprint->first receipt id
+	fingerprints map[string]string
+	// byDate is a map of purchase date->receipts, for finding near-duplicates
+	byDate map[string][]fingerprinted
+	// submissions is a map of member id->submission times
+	submissions map[string][]time.Time
+	// totals is a map of normalized retailer->total stats
+	totals map[string]*totalStats
+}
+
+// NewFraudDetector initializes an empty FraudDetector
+func NewFraudDetector() *FraudDetector {
+	return &FraudDetector{
+		fingerprints: map[string]string{},
+		byDate:       map[string][]fingerprinted{},
+		submissions:  map[string][]time.Time{},
+		totals:       map[string]*totalStats{},
+	}
+}
+
+// Fingerprint identifies a receipt by its normalized retailer, date, time, total
+// and item multiset, so reformatted copies of a receipt share a fingerprint
+// receipt: the Receipt to fingerprint
+// Returns: the hex sha256 fingerprint
+func Fingerprint(receipt Receipt) string {
+	normalized := normalize(receipt, "")
+	var items []string
+	for item, count := range normalized.items {
+		items = append(items, fmt.Sprintf("%s*%d", item, count))
+	}
+	sort.Strings(items)
+	digest := sha256.Sum256([]byte(strings.Join([]string{
+		normalized.retailer,
+		receipt.PurchaseDate.Format(time.DateOnly),
+		strconv.Itoa(normalized.minutes),
+		strconv.FormatInt(normalized.cents, 10),
+		strings.Join(items, "|"),
+	}, "\n")))
+	return hex.EncodeToString(digest[:])
+}
+
+// normalize normalizes a receipt for comparison
+func normalize(receipt Receipt, id string) fingerprinted {
+	normalized := fingerprinted{
+		id:       id,
+		retailer: normalizeRetailer(receipt.Retailer),
+		cents:    parseCents(receipt.Total),
+		items:    map[string]int{},
+	}
+	if purchased, err := time.Parse("15:04", receipt.PurchaseTime); err == nil {
+		normalized.minutes = purchased.Hour()*60 + purchased.Minute()
+	}
+	for _, item := range receipt.Items {
+		key := normalizeDescription(item.ShortDescription) + "@" + strconv.FormatInt(parseCents(item.Price), 10)
+		normalized.items[key]++
+	}
+	return normalized
+}
+
+// normalizeRetailer keeps only the lower case letters and digits of a retailer name
+func normalizeRetailer(retailer string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, retailer)
+}
+
+// parseCents parses a dollar amount as cents, zero when invalid
+func parseCents(amount string) int64 {
+	return int64(math.Round(parseAmount(amount) * 100))
+}
+
+// Check flags a receipt, then remembers it for checking later receipts
+// id: the uuid string associated with the Receipt
+// receipt: the Receipt to check
+// config: the fraud settings
+// now: when the Receipt was submitted
+// Returns: the flags, empty when the Receipt looks legitimate
+func (d *FraudDetector) Check(id string, receipt Receipt, config FraudConfig, now time.Time) []FraudFlag {
+	if config.Disabled {
+		return nil
+	}
+	config, _ = config.withDefaults()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var flags []FraudFlag
+	fingerprint := Fingerprint(receipt)
+	normalized := normalize(receipt, id)
+	date := receipt.PurchaseDate.Format(time.DateOnly)
+	if original, ok := d.fingerprints[fingerprint]; ok {
+		flags = append(flags, FraudFlag{
+			Type:      DuplicateFlag,
+			Reason:    "same retailer, date, time, total and items as another receipt",
+			ReceiptId: original,
+		})
+	} else {
+		d.fingerprints[fingerprint] = id
+		for _, other := range d.byDate[date] {
+			if similarity, ok := nearDuplicate(normalized, other, config); ok {
+				flags = append(flags, FraudFlag{
+					Type:      NearDuplicateFlag,
+					Reason:    fmt.Sprintf("%.0f%% of items match a receipt from the same retailer and date", similarity*100),
+					ReceiptId: other.id,
+				})
+				break
+			}
+		}
+	}
+	d.byDate[date] = append(d.byDate[date], normalized)
+
+	if receipt.MemberId != nil {
+		hourAgo := now.Add(-time.Hour)
+		recent := []time.Time{now}
+		for _, submitted := range d.submissions[*receipt.MemberId] {
+			if submitted.After(hourAgo) {
+				recent = append(recent, submitted)
+			}
+		}
+		d.submissions[*receipt.MemberId] = recent
+		if len(recent) > config.MaxReceiptsPerHour {
+			flags = append(flags, FraudFlag{
+				Type:   VelocityFlag,
+				Reason: fmt.Sprintf("%d receipts from the member in the last hour, more than %d", len(recent), config.MaxReceiptsPerHour),
+			})
+		}
+	}
+
+	total := float64(normalized.cents) / 100
+	stats := d.totals[normalized.retailer]
+	if stats == nil {
+		stats = &totalStats{}
+		d.totals[normalized.retailer] = stats
+	}
+	if stats.count >= config.MinSamples {
+		if z := stats.zScore(total); z > config.UnusualTotalZScore {
+			flags = append(flags, FraudFlag{
+				Type:   UnusualTotalFlag,
+				Reason: fmt.Sprintf("total %.2f is %.1f standard deviations from the retailer's mean of %.2f", total, z, stats.mean),
+			})
+		}
+	}
+	stats.add(total)
+	return flags
+}
+
+// nearDuplicate compares two receipts purchased on the same date, which are
+// near-duplicates when their retailers are alike, their items are similar, and
+// either their purchase times are close or their totals are within a dollar
+// Returns: the similarity of the items, and whether they are near-duplicates
+func nearDuplicate(a fingerprinted, b fingerprinted, config FraudConfig) (float64, bool) {
+	if levenshtein(a.retailer, b.retailer) > 2 {
+		return 0, false
+	}
+	similarity := itemSimilarity(a.items, b.items)
+	if similarity < config.NearDuplicateSimilarity {
+		return similarity, false
+	}
+	closeTimes := abs(a.minutes-b.minutes) <= config.NearDuplicateMinutes
+	closeTotals := math.Abs(float64(a.cents-b.cents)) <= 100
+	return similarity, closeTimes || closeTotals
+}
+
+// itemSimilarity is the Jaccard similarity of two item multisets, ignoring prices
+func itemSimilarity(a map[string]int, b map[string]int) float64 {
+	descriptions := func(items map[string]int) map[string]int {
+		counts := map[string]int{}
+		for key, count := range items {
+			counts[key[:strings.LastIndex(key, "@")]] += count
+		}
+		return counts
+	}
+	countsA, countsB := descriptions(a), descriptions(b)
+	intersection, union := 0, 0
+	for description, countA := range countsA {
+		countB := countsB[description]
+		intersection += min(countA, countB)
+		union += max(countA, countB)
+	}
+	for description, countB := range countsB {
+		if _, ok := countsA[description]; !ok {
+			union += countB
+		}
+	}
+	if union == 0 {
+		return 1
+	}
+	return float64(intersection) / float64(union)
+}
+
+// levenshtein is the number of single character edits between two strings
+func levenshtein(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,187 @@
+/*
+fraud_test.go contains functions for testing fraud detection and the review queue.
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFingerprint verifies reformatted copies of a receipt share a fingerprint
+func TestFingerprint(t *testing.T) {
+	receipt := ParseReceipt(t, `{"retailer": "M&M Corner Market", "purchaseDate": "2022-03-20", "purchaseTime": "14:33", "total": "9.00", "items": [
+		{"shortDescription": "Gatorade", "price": "2.25"},
+		{"shortDescription": "Gatorade", "price": "2.25"},
+		{"shortDescription": "Doritos", "price": "4.50"}
+	]}`)
+	reformatted := ParseReceipt(t, `{"retailer": "m & m corner market", "purchaseDate": "2022-03-20", "purchaseTime": "14:33", "total": "9.0", "items": [
+		{"shortDescription": "  DORITOS ", "price": "4.5"},
+		{"shortDescription": "gatorade", "price": "2.25"},
+		{"shortDescription": "Gatorade", "price": "2.250"}
+	]}`)
+	assert.Equal(t, Fingerprint(receipt), Fingerprint(reformatted))
+
+	oneItem := ParseReceipt(t, `{"retailer": "M&M Corner Market", "purchaseDate": "2022-03-20", "purchaseTime": "14:33", "total": "9.00", "items": [
+		{"shortDescription": "Gatorade", "price": "2.25"},
+		{"shortDescription": "Doritos", "price": "4.50"}
+	]}`)
+	assert.NotEqual(t, Fingerprint(receipt), Fingerprint(oneItem))
+}
+
+// TestFraudDetector verifies each kind of flag
+func TestFraudDetector(t *testing.T) {
+	now := time.Date(2024, 8, 20, 12, 0, 0, 0, time.UTC)
+	receipt := func(retailer string, date string, clock string, total string, items ...string) Receipt {
+		itemsJson := ""
+		for i, item := range items {
+			if i > 0 {
+				itemsJson += ","
+			}
+			itemsJson += fmt.Sprintf(`{"shortDescription": %q, "price": "1.00"}`, item)
+		}
+		return ParseReceipt(t, fmt.Sprintf(`{"retailer": %q, "purchaseDate": %q, "purchaseTime": %q, "total": %q, "items": [%s]}`,
+			retailer, date, clock, total, itemsJson))
+	}
+	types := func(flags []FraudFlag) []string {
+		var types []string
+		for _, flag := range flags {
+			types = append(types, flag.Type)
+		}
+		return types
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		detector := NewFraudDetector()
+		original := receipt("Target", "2022-01-01", "13:01", "3.00", "Pepsi", "Chips", "Salsa")
+		assert.Empty(t, detector.Check("a", original, FraudConfig{}, now))
+		flags := detector.Check("b", original, FraudConfig{}, now)
+		assert.Equal(t, []FraudFlag{{
+			Type:      DuplicateFlag,
+			Reason:    "same retailer, date, time, total and items as another receipt",
+			ReceiptId: "a",
+		}}, flags)
+	})
+
+	t.Run("near duplicate", func(t *testing.T) {
+		detector := NewFraudDetector()
+		detector.Check("a", receipt("Target", "2022-01-01", "13:01", "4.00", "Pepsi", "Chips", "Salsa", "Dip"), FraudConfig{}, now)
+		// a retailer typo, a changed time and a dropped item
+		flags := detector.Check("b", receipt("Targt", "2022-01-01", "13:05", "3.00", "Pepsi", "Chips", "Salsa"), FraudConfig{}, now)
+		assert.Equal(t, []string{NearDuplicateFlag}, types(flags))
+		assert.Equal(t, "a", flags[0].ReceiptId)
+		assert.Contains(t, flags[0].Reason, "75%")
+
+		// another day, another store, or other items are not near-duplicates
+		assert.Empty(t, detector.Check("c", receipt("Target", "2022-01-02", "13:01", "4.00", "Pepsi", "Chips", "Salsa", "Dip"), FraudConfig{}, now))
+		assert.Empty(t, detector.Check("d", receipt("Walgreens", "2022-01-01", "13:01", "4.00", "Pepsi", "Chips", "Salsa", "Dip"), FraudConfig{}, now))
+		assert.Empty(t, detector.Check("e", receipt("Target", "2022-01-01", "13:01", "4.00", "Milk", "Eggs", "Salsa", "Dip"), FraudConfig{}, now))
+		// far apart in time and total
+		assert.Empty(t, detector.Check("f", receipt("Target", "2022-01-01", "18:00", "9.00", "Pepsi", "Chips", "Salsa", "Dip"), FraudConfig{}, now))
+	})
+
+	t.Run("velocity", func(t *testing.T) {
+		detector := NewFraudDetector()
+		config := FraudConfig{MaxReceiptsPerHour: 2}
+		member := "member-1"
+		for i := 0; i < 2; i++ {
+			r := receipt("Target", fmt.Sprintf("2022-01-%02d", i+1), "13:01", "1.00", "Pepsi")
+			r.MemberId = &member
+			assert.Empty(t, detector.Check(fmt.Sprint(i), r, config, now.Add(time.Duration(i)*time.Minute)))
+		}
+		r := receipt("Target", "2022-01-10", "13:01", "1.00", "Pepsi")
+		r.MemberId = &member
+		flags := detector.Check("2", r, config, now.Add(2*time.Minute))
+		assert.Equal(t, []string{VelocityFlag}, types(flags))
+
+		// the first submissions fall out of the hour
+		r = receipt("Target", "2022-01-11", "13:01", "1.00", "Pepsi")
+		r.MemberId = &member
+		assert.Empty(t, detector.Check("3", r, config, now.Add(62*time.Minute)))
+	})
+
+	t.Run("unusual total", func(t *testing.T) {
+		detector := NewFraudDetector()
+		config := FraudConfig{MinSamples: 10}
+		for i := 0; i < 10; i++ {
+			total := fmt.Sprintf("%d.00", 10+i%3)
+			assert.Empty(t, detector.Check(fmt.Sprint(i), receipt("Target", fmt.Sprintf("2022-01-%02d", i+1), "13:01", total, "Pepsi"), config, now))
+		}
+		assert.Empty(t, detector.Check("usual", receipt("Target", "2022-02-01", "13:01", "12.00", "Pepsi"), config, now))
+		flags := detector.Check("unusual", receipt("Target", "2022-02-02", "13:01", "950.00", "Pepsi"), config, now)
+		assert.Equal(t, []string{UnusualTotalFlag}, types(flags))
+		// other retailers have their own totals
+		assert.Empty(t, detector.Check("other", receipt("Walgreens", "2022-02-02", "13:01", "950.00", "Pepsi"), config, now))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		detector := NewFraudDetector()
+		original := receipt("Target", "2022-01-01", "13:01", "3.00", "Pepsi")
+		detector.Check("a", original, FraudConfig{}, now)
+		assert.Empty(t, detector.Check("b", original, FraudConfig{Disabled: true}, now))
+	})
+}
+
+// TestFraudConfigErrors verifies invalid fraud settings are rejected
+func TestFraudConfigErrors(t *testing.T) {
+	for expectedErr, invalid := range map[string]FraudConfig{
+		"must not be negative": {MaxReceiptsPerHour: -1},
+		"must be from 0 to 1":  {NearDuplicateSimilarity: 1.5},
+	} {
+		_, err := NewRuleProcessorFromConfig(RuleConfig{Fraud: invalid}, nil)
+		assert.ErrorContains(t, err, expectedErr)
+	}
+}
+
+// TestReviewQueue verifies flagged receipts are held at zero points and
+// listed in the review queue
+func TestReviewQueue(t *testing.T) {
+	router := GetRouter()
+	receipt := `{
+		"retailer": "Target",
+		"memberId": "member-1",
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"total": "1.25",
+		"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}]
+	}`
+	submit := func() string {
+		recorder := ProcessRequest(router, BuildRequest(receipt))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		id := &PostReceiptsProcessResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &id)
+		return id.Id
+	}
+	original := submit()
+	duplicate := submit()
+
+	breakdown := GetBreakdown(t, router, "/receipts/"+original+"/breakdown")
+	assert.Equal(t, 31, breakdown.Points)
+	assert.False(t, breakdown.Held)
+
+	breakdown = GetBreakdown(t, router, "/receipts/"+duplicate+"/breakdown")
+	assert.Equal(t, 0, breakdown.Points)
+	assert.True(t, breakdown.Held)
+	assert.Equal(t, 31, breakdown.HeldPoints)
+	assert.Equal(t, 0, GetBreakdown(t, router, "/receipts/"+duplicate+"/points").Points)
+
+	recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/reviews", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	reviews := &GetReviewsResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &reviews)
+	assert.Len(t, reviews.Reviews, 1)
+	review := reviews.Reviews[0]
+	assert.Equal(t, duplicate, review.Id)
+	assert.Equal(t, "Target", review.Retailer)
+	assert.Equal(t, "member-1", *review.MemberId)
+	assert.Equal(t, PendingReview, review.Status)
+	assert.Equal(t, 31, review.HeldPoints)
+	assert.Equal(t, DuplicateFlag, review.Flags[0].Type)
+	assert.Equal(t, original, review.Flags[0].ReceiptId)
+}
@@ -0,0 +1,525 @@
+/*
+graphql.go contains the GraphQL endpoint, fetching receipts, their items, points
+breakdown and member in one request, with limits on the depth and complexity of queries
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// graphQLPage is the number of receipts listed for a member when first is not given
+const graphQLPage = 10
+
+// maxGraphQLPage is the most receipts listed for a member in one page
+const maxGraphQLPage = 100
+
+// GraphQLHandler handles GraphQL queries, resolved from the receipt storage and rule sets
+type GraphQLHandler struct {
+	// Receipts is the handler shared with the REST API
+	Receipts ReceiptHandler
+	// MaxDepth is the deepest nesting of fields in a query
+	MaxDepth int
+	// MaxComplexity is the most fields a query may resolve, counting the fields
+	// under a list of receipts once per receipt requested
+	MaxComplexity int
+	// schema is the GraphQL schema
+	schema graphql.Schema
+}
+
+// graphReceipt is the source of the GraphQL Receipt type
+type graphReceipt struct {
+	id      string
+	receipt Receipt
+}
+
+// graphMember is the source of the GraphQL Member type
+type graphMember struct {
+	id string
+}
+
+// NewGraphQLHandler initializes GraphQLHandler with a depth limit of 6 and a complexity limit of 200
+// receipts: the handler shared with the REST API
+func NewGraphQLHandler(receipts ReceiptHandler) *GraphQLHandler {
+	h := &GraphQLHandler{
+		Receipts:      receipts,
+		MaxDepth:      6,
+		MaxComplexity: 200,
+	}
+	h.schema = h.newSchema()
+	return h
+}
+
+// newSchema builds the GraphQL schema for Receipt, Item, PointsBreakdown and Member
+func (h *GraphQLHandler) newSchema() graphql.Schema {
+	item := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Item",
+		Fields: graphql.Fields{
+			"shortDescription": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"price":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"quantity":         &graphql.Field{Type: graphql.Float},
+			"unitPrice":        &graphql.Field{Type: graphql.String},
+			"discount":         &graphql.Field{Type: graphql.String},
+		},
+	})
+	exchangeRate := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "ExchangeRate",
+		Description: "The exchange rate the receipt amounts are converted with before rules evaluate them",
+		Fields: graphql.Fields{
+			"currency": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"base":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"rate":     &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"date":     &graphql.Field{Type: graphql.String},
+		},
+	})
+	discount := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Discount",
+		Fields: graphql.Fields{
+			"description": &graphql.Field{Type: graphql.String},
+			"code":        &graphql.Field{Type: graphql.String},
+			"amount":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	taxLine := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TaxLine",
+		Fields: graphql.Fields{
+			"description": &graphql.Field{Type: graphql.String},
+			"rate":        &graphql.Field{Type: graphql.Float},
+			"amount":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	rulePoints := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "RulePoints",
+		Description: "The points earned from a rule",
+		Fields: graphql.Fields{
+			"rule":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"points":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"uncapped":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"items":       &graphql.Field{Type: graphql.NewList(graphql.NewNonNull(graphql.Int)), Description: "The indexes of the items that triggered the rule"},
+			"explanation": &graphql.Field{Type: graphql.String},
+			"error":       &graphql.Field{Type: graphql.String},
+			"suppressed":  &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		},
+	})
+	breakdown := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "PointsBreakdown",
+		Description: "The points earned for a receipt from each rule",
+		Fields: graphql.Fields{
+			"ruleset":    &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resolveScore(func(score Score) any { return score.Version })},
+			"points":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: resolveScore(func(score Score) any { return score.Breakdown.Points })},
+			"uncapped":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: resolveScore(func(score Score) any { return score.Breakdown.Uncapped })},
+			"capped":     &graphql.Field{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Resolve: resolveScore(func(score Score) any { return score.Breakdown.Capped })},
+			"rules":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(rulePoints))), Resolve: resolveScore(func(score Score) any { return score.Breakdown.Rules })},
+			"tier":       &graphql.Field{Type: graphql.String, Resolve: resolveScore(func(score Score) any { return optional(score.Breakdown.Tier) })},
+			"multiplier": &graphql.Field{Type: graphql.Float, Resolve: resolveScore(func(score Score) any { return score.Breakdown.Multiplier })},
+			"tierBonus":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: resolveScore(func(score Score) any { return score.Breakdown.TierBonus })},
+			"held":       &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: resolveScore(func(score Score) any { return score.Breakdown.Held })},
+			"heldPoints": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: resolveScore(func(score Score) any { return score.Breakdown.HeldPoints })},
+			"review":     &graphql.Field{Type: graphql.String, Resolve: resolveScore(func(score Score) any { return optional(score.Breakdown.Review) })},
+		},
+	})
+	member := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Member",
+		Fields: graphql.Fields{},
+	})
+	receipt := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Receipt",
+		Fields: graphql.Fields{
+			"id":           &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: resolveReceipt(func(r graphReceipt) any { return r.id })},
+			"retailer":     &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.Retailer })},
+			"purchaseDate": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.PurchaseDate.Format("2006-01-02") })},
+			"purchaseTime": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.PurchaseTime })},
+			"total":        &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.Total })},
+			"timeZone":     &graphql.Field{Type: graphql.String, Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.TimeZone })},
+			"utcOffset":    &graphql.Field{Type: graphql.String, Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.UtcOffset })},
+			"items":        &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(item))), Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.Items })},
+			"storeId":      &graphql.Field{Type: graphql.String, Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.StoreId })},
+			"paymentMethod": &graphql.Field{Type: graphql.String, Resolve: resolveReceipt(func(r graphReceipt) any {
+				if r.receipt.PaymentMethod == nil {
+					return nil
+				}
+				return string(*r.receipt.PaymentMethod)
+			})},
+			"discounts": &graphql.Field{Type: graphql.NewList(graphql.NewNonNull(discount)), Resolve: resolveReceipt(func(r graphReceipt) any {
+				if r.receipt.Discounts == nil {
+					return nil
+				}
+				return *r.receipt.Discounts
+			})},
+			"taxes": &graphql.Field{Type: graphql.NewList(graphql.NewNonNull(taxLine)), Resolve: resolveReceipt(func(r graphReceipt) any {
+				if r.receipt.Taxes == nil {
+					return nil
+				}
+				return *r.receipt.Taxes
+			})},
+			"tip":          &graphql.Field{Type: graphql.String, Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.Tip })},
+			"currency":     &graphql.Field{Type: graphql.String, Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.Currency })},
+			"exchangeRate": &graphql.Field{Type: exchangeRate, Resolve: resolveReceipt(func(r graphReceipt) any { return r.receipt.ExchangeRate })},
+			"points": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "The points earned with the pinned rule set",
+				Resolve: resolveReceipt(func(r graphReceipt) any {
+					return h.Receipts.pinnedScore(r.id, r.receipt).Breakdown.Points
+				}),
+			},
+			"breakdown": &graphql.Field{
+				Type:        graphql.NewNonNull(breakdown),
+				Description: "The points earned from each rule with the pinned rule set, or previewed with another rule set",
+				Args: graphql.FieldConfigArgument{
+					"ruleset": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: h.resolveBreakdown,
+			},
+			"member": &graphql.Field{
+				Type: member,
+				Resolve: resolveReceipt(func(r graphReceipt) any {
+					if r.receipt.MemberId == nil {
+						return nil
+					}
+					return graphMember{id: *r.receipt.MemberId}
+				}),
+			},
+		},
+	})
+	member.AddFieldConfig("id", &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: resolveMember(func(m graphMember) any { return m.id })})
+	member.AddFieldConfig("birthday", &graphql.Field{
+		Type: graphql.String,
+		Resolve: resolveMember(func(m graphMember) any {
+			if profile, err := h.Receipts.Database.GetMember(m.id); err == nil && profile.Birthday != nil {
+				return profile.Birthday.Format("2006-01-02")
+			}
+			return nil
+		}),
+	})
+	member.AddFieldConfig("tier", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: resolveMember(func(m graphMember) any {
+			if status, err := h.Receipts.Database.GetTier(m.id); err == nil {
+				return status.Tier
+			}
+			return h.Receipts.RuleSets.Active().Processor.tiers[0].Name
+		}),
+	})
+	member.AddFieldConfig("balance", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.Int),
+		Description: "The points earned from every receipt of the member",
+		Resolve: resolveMember(func(m graphMember) any {
+			points, _ := h.balance(m.id)
+			return points
+		}),
+	})
+	member.AddFieldConfig("heldBalance", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.Int),
+		Description: "The points held pending review",
+		Resolve: resolveMember(func(m graphMember) any {
+			_, held := h.balance(m.id)
+			return held
+		}),
+	})
+	member.AddFieldConfig("receipts", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(receipt))),
+		Description: "The receipts of the member, in the order submitted",
+		Args: graphql.FieldConfigArgument{
+			"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: graphQLPage, Description: "The most receipts to list, at most 100"},
+			"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+		},
+		Resolve: h.resolveMemberReceipts,
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"receipt": &graphql.Field{
+				Type: receipt,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, _ := p.Args["id"].(string)
+					stored, err := h.Receipts.Database.GetReceipt(id)
+					if err != nil {
+						return nil, nil
+					}
+					return graphReceipt{id: id, receipt: stored}, nil
+				},
+			},
+			"member": &graphql.Field{
+				Type: member,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, _ := p.Args["id"].(string)
+					if _, err := h.Receipts.Database.GetMember(id); err != nil && len(h.Receipts.Database.GetMemberReceiptIds(id)) == 0 {
+						return nil, nil
+					}
+					return graphMember{id: id}, nil
+				},
+			},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// resolveReceipt resolves a field of the Receipt type
+func resolveReceipt(field func(r graphReceipt) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return field(p.Source.(graphReceipt)), nil
+	}
+}
+
+// resolveMember resolves a field of the Member type
+func resolveMember(field func(m graphMember) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return field(p.Source.(graphMember)), nil
+	}
+}
+
+// resolveScore resolves a field of the PointsBreakdown type
+func resolveScore(field func(score Score) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return field(p.Source.(Score)), nil
+	}
+}
+
+// optional resolves empty strings as null
+func optional(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// resolveBreakdown resolves the Score of a Receipt, pinned or previewed with the ruleset argument
+func (h *GraphQLHandler) resolveBreakdown(p graphql.ResolveParams) (any, error) {
+	r := p.Source.(graphReceipt)
+	if version, _ := p.Args["ruleset"].(string); version != "" {
+		ruleset, err := h.Receipts.RuleSets.Get(version)
+		if err != nil {
+			return nil, fmt.Errorf("Ruleset not found")
+		}
+		return h.Receipts.score(r.id, r.receipt, ruleset, false), nil
+	}
+	return h.Receipts.pinnedScore(r.id, r.receipt), nil
+}
+
+// resolveMemberReceipts resolves a page of the receipts of a Member
+func (h *GraphQLHandler) resolveMemberReceipts(p graphql.ResolveParams) (any, error) {
+	first, _ := p.Args["first"].(int)
+	offset, _ := p.Args["offset"].(int)
+	if first < 0 || offset < 0 {
+		return nil, fmt.Errorf("first and offset must not be negative")
+	}
+	if first > maxGraphQLPage {
+		return nil, fmt.Errorf("first must be at most %d", maxGraphQLPage)
+	}
+	ids := h.Receipts.Database.GetMemberReceiptIds(p.Source.(graphMember).id)
+	ids = ids[min(offset, len(ids)):]
+	ids = ids[:min(first, len(ids))]
+	receipts := []graphReceipt{}
+	for _, id := range ids {
+		if stored, err := h.Receipts.Database.GetReceipt(id); err == nil {
+			receipts = append(receipts, graphReceipt{id: id, receipt: stored})
+		}
+	}
+	return receipts, nil
+}
+
+// balance sums the points a member earned with the pinned rule sets
+// memberId: the id of the member
+// Returns: the points earned, and the points held pending review
+func (h *GraphQLHandler) balance(memberId string) (int, int) {
+	points, held := 0, 0
+	for _, id := range h.Receipts.Database.GetMemberReceiptIds(memberId) {
+		if stored, err := h.Receipts.Database.GetReceipt(id); err == nil {
+			score := h.Receipts.pinnedScore(id, stored)
+			points += score.Breakdown.Points
+			held += score.Breakdown.HeldPoints
+		}
+	}
+	return points, held
+}
+
+// measure finds the depth and complexity of the fields in a selection set, counting
+// the fields under a list of receipts once per receipt requested. Introspection
+// fields are not counted.
+// set: the selection set
+// fragments: the fragments of the document, by name
+// variables: the variables of the request
+// Returns: the depth, and the complexity
+func measure(set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, variables map[string]any) (int, int) {
+	depth, complexity := 0, 0
+	if set == nil {
+		return depth, complexity
+	}
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			fieldDepth, fieldComplexity := measure(selection.SelectionSet, fragments, variables)
+			depth = max(depth, fieldDepth+1)
+			complexity = saturatingAdd(complexity, saturatingAdd(1, saturatingMul(pageSize(selection, variables), fieldComplexity)))
+		case *ast.InlineFragment:
+			fragmentDepth, fragmentComplexity := measure(selection.SelectionSet, fragments, variables)
+			depth = max(depth, fragmentDepth)
+			complexity = saturatingAdd(complexity, fragmentComplexity)
+		case *ast.FragmentSpread:
+			if fragment, ok := fragments[selection.Name.Value]; ok {
+				fragmentDepth, fragmentComplexity := measure(fragment.SelectionSet, fragments, variables)
+				depth = max(depth, fragmentDepth)
+				complexity = saturatingAdd(complexity, fragmentComplexity)
+			}
+		}
+	}
+	return depth, complexity
+}
+
+// pageSize finds the number of receipts a field lists, from its first argument,
+// 1 for fields that are not lists of receipts
+func pageSize(field *ast.Field, variables map[string]any) int {
+	if field.Name.Value != "receipts" {
+		return 1
+	}
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			var first int
+			if _, err := fmt.Sscan(value.Value, &first); err != nil {
+				return math.MaxInt
+			}
+			return max(first, 0)
+		case *ast.Variable:
+			if first, ok := variables[value.Name.Value].(float64); ok {
+				return int(min(max(first, 0), math.MaxInt32))
+			}
+			// a variable without a value is costed as a full page
+			return maxGraphQLPage
+		}
+	}
+	return graphQLPage
+}
+
+// withDefaults returns the variables of a request with the default values of the
+// variables an operation defines and the request does not give
+// operation: the operation of the request
+// variables: the variables of the request
+func withDefaults(operation *ast.OperationDefinition, variables map[string]any) map[string]any {
+	merged := map[string]any{}
+	for name, value := range variables {
+		merged[name] = value
+	}
+	for _, definition := range operation.VariableDefinitions {
+		name := definition.Variable.Name.Value
+		if _, ok := merged[name]; ok {
+			continue
+		}
+		if value, ok := definition.DefaultValue.(*ast.IntValue); ok {
+			var first float64
+			if _, err := fmt.Sscan(value.Value, &first); err == nil {
+				merged[name] = first
+			}
+		}
+	}
+	return merged
+}
+
+// saturatingAdd adds two complexities, stopping at math.MaxInt instead of wrapping
+func saturatingAdd(a int, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// saturatingMul multiplies two complexities, stopping at math.MaxInt instead of wrapping
+func saturatingMul(a int, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
+
+// PostGraphql handles POST requests to run a GraphQL query, rejecting queries
+// over the depth or complexity limits before resolving them
+// Response example: {"data":{"receipt":{"retailer":"Target","points":31,"member":{"balance":31}}}}
+func (h *GraphQLHandler) PostGraphql(w http.ResponseWriter, r *http.Request) {
+	var request PostGraphqlRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(request.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		writeGraphQL(w, http.StatusBadRequest, &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(err)}})
+		return
+	}
+	if validation := graphql.ValidateDocument(&h.schema, document, nil); !validation.IsValid {
+		writeGraphQL(w, http.StatusBadRequest, &graphql.Result{Errors: validation.Errors})
+		return
+	}
+
+	fragments := map[string]*ast.FragmentDefinition{}
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if request.OperationName == "" || (definition.Name != nil && definition.Name.Value == request.OperationName) {
+				operation = definition
+			}
+		}
+	}
+	if operation != nil {
+		depth, complexity := measure(operation.SelectionSet, fragments, withDefaults(operation, request.Variables))
+		if depth > h.MaxDepth {
+			writeGraphQL(w, http.StatusBadRequest, &graphql.Result{Errors: []gqlerrors.FormattedError{
+				gqlerrors.NewFormattedError(fmt.Sprintf("Query depth %d is over the limit of %d", depth, h.MaxDepth)),
+			}})
+			return
+		}
+		if complexity > h.MaxComplexity {
+			writeGraphQL(w, http.StatusBadRequest, &graphql.Result{Errors: []gqlerrors.FormattedError{
+				gqlerrors.NewFormattedError(fmt.Sprintf("Query complexity %d is over the limit of %d", complexity, h.MaxComplexity)),
+			}})
+			return
+		}
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           document,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       r.Context(),
+	})
+	writeGraphQL(w, http.StatusOK, result)
+}
+
+// writeGraphQL writes a GraphQL result
+func writeGraphQL(w http.ResponseWriter, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,164 @@
+/*
+graphql_test.go contains functions for testing the GraphQL endpoint.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// Query runs a GraphQL query, returning the status code and decoded result
+func Query(t *testing.T, router chi.Router, query string, variables map[string]any) (int, map[string]any) {
+	body, _ := json.Marshal(PostGraphqlRequest{Query: query, Variables: variables})
+	request := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := ProcessRequest(router, request)
+	result := map[string]any{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	return recorder.Code, result
+}
+
+// TestGraphQL verifies a receipt, its items, points breakdown and member balance
+// are fetched in one query
+func TestGraphQL(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	router := NewReceiptRouter(receipts)
+	request := httptest.NewRequest(http.MethodPut, "/members/member-1", strings.NewReader(`{"birthday": "1990-05-14"}`))
+	request.Header.Set("Content-Type", "application/json")
+	ProcessRequest(router, request)
+	var ids []string
+	for _, retailer := range []string{"Target", "Walgreens"} {
+		recorder := ProcessRequest(router, BuildRequest(`{
+			"retailer": "`+retailer+`",
+			"purchaseDate": "2022-01-02",
+			"purchaseTime": "13:13",
+			"total": "1.25",
+			"memberId": "member-1",
+			"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}]
+		}`))
+		id := &PostReceiptsProcessResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &id)
+		ids = append(ids, id.Id)
+	}
+
+	code, result := Query(t, router, `query Receipt($id: ID!) {
+		receipt(id: $id) {
+			id retailer purchaseDate total
+			items { shortDescription price }
+			breakdown { ruleset points rules { rule points } }
+			member { id birthday tier balance receipts { retailer points } }
+		}
+	}`, map[string]any{"id": ids[0]})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, result["errors"])
+	receipt := result["data"].(map[string]any)["receipt"].(map[string]any)
+	assert.Equal(t, ids[0], receipt["id"])
+	assert.Equal(t, "2022-01-02", receipt["purchaseDate"])
+	assert.Equal(t, []any{map[string]any{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}}, receipt["items"])
+	breakdown := receipt["breakdown"].(map[string]any)
+	assert.Equal(t, "v1", breakdown["ruleset"])
+	assert.Equal(t, float64(31), breakdown["points"])
+	assert.Contains(t, breakdown["rules"], map[string]any{"rule": "retailer-name", "points": float64(6)})
+	member := receipt["member"].(map[string]any)
+	assert.Equal(t, "1990-05-14", member["birthday"])
+	assert.Equal(t, "bronze", member["tier"])
+	assert.Equal(t, float64(31+34), member["balance"])
+	assert.Equal(t, []any{
+		map[string]any{"retailer": "Target", "points": float64(31)},
+		map[string]any{"retailer": "Walgreens", "points": float64(34)},
+	}, member["receipts"])
+
+	// previewed with another rule set, not found as null
+	code, result = Query(t, router, `{ receipt(id: "`+ids[0]+`") { breakdown(ruleset: "v9") { points } } missing: receipt(id: "missing") { id } }`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Ruleset not found", result["errors"].([]any)[0].(map[string]any)["message"])
+	code, result = Query(t, router, `{ receipt(id: "missing") { id } member(id: "member-2") { id } }`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]any{"receipt": nil, "member": nil}, result["data"])
+}
+
+// TestGraphQLItemizedReceipt verifies the quantities, discounts, taxes, tip, payment
+// method and store of a receipt are fetched
+func TestGraphQLItemizedReceipt(t *testing.T) {
+	router := GetRouter()
+	recorder := ProcessRequest(router, BuildRequest(`{
+		"retailer": "Target",
+		"storeId": "store-0042",
+		"paymentMethod": "store-card",
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"total": "1.25",
+		"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25", "quantity": 2, "unitPrice": "0.75", "discount": "0.25"}],
+		"discounts": [{"code": "SAVE10", "amount": "0.20"}],
+		"taxes": [{"description": "State Tax", "rate": 6.25, "amount": "0.10"}],
+		"tip": "0.10"
+	}`))
+	id := &PostReceiptsProcessResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &id)
+
+	code, result := Query(t, router, `query Receipt($id: ID!) {
+		receipt(id: $id) {
+			storeId paymentMethod tip
+			items { quantity unitPrice discount }
+			discounts { description code amount }
+			taxes { description rate amount }
+		}
+	}`, map[string]any{"id": id.Id})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, result["errors"])
+	assert.Equal(t, map[string]any{
+		"storeId":       "store-0042",
+		"paymentMethod": "store-card",
+		"tip":           "0.10",
+		"items":         []any{map[string]any{"quantity": float64(2), "unitPrice": "0.75", "discount": "0.25"}},
+		"discounts":     []any{map[string]any{"description": nil, "code": "SAVE10", "amount": "0.20"}},
+		"taxes":         []any{map[string]any{"description": "State Tax", "rate": 6.25, "amount": "0.10"}},
+	}, result["data"].(map[string]any)["receipt"])
+}
+
+// TestGraphQLLimits verifies invalid queries, and queries over the depth or complexity
+// limits are rejected before they are resolved
+func TestGraphQLLimits(t *testing.T) {
+	router := GetRouter()
+	rejected := map[string]string{
+		`{ receipt(id: "1") { member { receipts { member { receipts { member { id } } } } } } }`:                                      "Query depth 7 is over the limit of 6",
+		`{ member(id: "1") { receipts(first: 100) { id retailer total } } }`:                                                          "Query complexity 302 is over the limit of 200",
+		`query Page($first: Int) { member(id: "1") { receipts(first: $first) { id retailer total } } }`:                               "Query complexity 302 is over the limit of 200",
+		`query Page($n: Int = 100) { member(id: "1") { receipts(first: $n) { id retailer total } } }`:                                 "Query complexity 302 is over the limit of 200",
+		`query Page($n: Int) { member(id: "1") { receipts(first: $n) { id retailer total } } }`:                                       "Query complexity 302 is over the limit of 200",
+		`{ receipt(id: "1") { ...deep } } fragment deep on Receipt { member { receipts { member { receipts { member { id } } } } } }`: "Query depth 7 is over the limit of 6",
+		`{ member(id: "1") { receipts(first: 2147483647) { member { receipts(first: 2147483647) { member { id tier } } } } } }`:       "is over the limit of 200",
+		`{ receipt(id: "1") { unknown } }`: `Cannot query field "unknown" on type "Receipt".`,
+		`{ receipt(id: "1") { `:            "Syntax Error GraphQL request (1:22) Expected Name, found EOF",
+	}
+	for query, message := range rejected {
+		code, result := Query(t, router, query, map[string]any{"first": 100})
+		assert.Equal(t, http.StatusBadRequest, code, query)
+		assert.Contains(t, result["errors"].([]any)[0].(map[string]any)["message"], message, query)
+	}
+
+	// pages over the maximum are rejected even when the member has few receipts
+	request := httptest.NewRequest(http.MethodPut, "/members/1", strings.NewReader(`{}`))
+	request.Header.Set("Content-Type", "application/json")
+	ProcessRequest(router, request)
+	code, result := Query(t, router, `{ member(id: "1") { receipts(first: 101) { id } } }`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "first must be at most 100", result["errors"].([]any)[0].(map[string]any)["message"])
+
+	// defaults within the limits are costed at their value
+	code, result = Query(t, router, `query Page($n: Int = 50) { member(id: "1") { receipts(first: $n) { id retailer total } } }`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, result["errors"])
+
+	// within the limits, and introspection is not counted
+	code, result = Query(t, router, `{ member(id: "1") { receipts(first: 50) { id retailer total } } __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, result["errors"])
+}
@@ -0,0 +1,265 @@
+/*
+group.go contains rule groups, which combine the points of rules by a strategy
+instead of summing them
+*/
+package api
+
+import (
+	"fmt"
+)
+
+// Group strategies
+const (
+	// SumStrategy awards the points of every member
+	SumStrategy = "sum"
+	// MaxStrategy awards the points of the member with the most points
+	MaxStrategy = "max"
+	// FirstStrategy awards the points of the first member with points
+	FirstStrategy = "first"
+)
+
+// RuleGroup combines the points of its member rules and groups by a Strategy.
+// Members not awarded by the strategy are suppressed, earning no points.
+// Groups may be members of other groups, each rule or group belongs to at most one group.
+// When a group earns points, the rules and groups it is ExclusiveWith are suppressed,
+// in the order the groups are defined, before max and first groups choose their
+// member, so a runner-up wins when the best member is excluded.
+//
+// Example json, the round dollar and quarter multiple bonuses do not stack:
+//
+//	{"name": "total-bonus", "strategy": "max", "rules": ["round-dollar", "quarter-multiple"]}
+type RuleGroup struct {
+	// GroupName identifies the group in a Breakdown
+	GroupName string `json:"name"`
+	// Strategy is one of sum, max or first
+	Strategy string `json:"strategy"`
+	// Rules are the names of the member rules and groups, in priority order
+	Rules []string `json:"rules"`
+	// ExclusiveWith are the names of rules and groups suppressed when the group earns points
+	ExclusiveWith []string `json:"exclusiveWith,omitempty"`
+
+	// parent is the group the group belongs to, set by compileGroups
+	parent *RuleGroup
+}
+
+// GroupResult is the points earned from a RuleGroup
+type GroupResult struct {
+	// Group is the name of the group
+	Group string `json:"group"`
+	// Strategy is the strategy of the group
+	Strategy string `json:"strategy"`
+	// Points earned from the members of the group
+	Points int `json:"points"`
+	// Rules are the names of the member rules and groups
+	Rules []string `json:"rules"`
+	// Parent is the name of the group the group belongs to, if any
+	Parent string `json:"parent,omitempty"`
+	// Suppressed is set when a parent group or an exclusive group suppressed the group
+	Suppressed bool `json:"suppressed,omitempty"`
+}
+
+// compileGroups validates groups against the rules of the RuleProcessor,
+// and adds them to it
+// groups: the groups to add
+func (p *RuleProcessor) compileGroups(groups []RuleGroup) error {
+	byName := map[string]*RuleGroup{}
+	for i := range groups {
+		group := &groups[i]
+		if group.GroupName == "" {
+			return fmt.Errorf("rule group is missing a name")
+		}
+		if p.hasRule(group.GroupName) || byName[group.GroupName] != nil {
+			return fmt.Errorf("duplicate rule group name %q", group.GroupName)
+		}
+		switch group.Strategy {
+		case SumStrategy, MaxStrategy, FirstStrategy:
+		default:
+			return fmt.Errorf("rule group %q has an invalid strategy %q, must be sum, max or first", group.GroupName, group.Strategy)
+		}
+		if len(group.Rules) == 0 {
+			return fmt.Errorf("rule group %q has no rules", group.GroupName)
+		}
+		byName[group.GroupName] = group
+	}
+
+	// every member belongs to one group
+	member := map[string]string{}
+	for _, group := range groups {
+		for _, name := range group.Rules {
+			if !p.hasRule(name) && byName[name] == nil {
+				return fmt.Errorf("rule group %q references unknown rule %q", group.GroupName, name)
+			}
+			if other, ok := member[name]; ok {
+				return fmt.Errorf("rule %q is in both rule groups %q and %q", name, other, group.GroupName)
+			}
+			member[name] = group.GroupName
+			if child := byName[name]; child != nil {
+				child.parent = byName[group.GroupName]
+			}
+		}
+	}
+	for _, group := range groups {
+		depth := 0
+		for ancestor := group.parent; ancestor != nil; ancestor = ancestor.parent {
+			if depth++; ancestor.GroupName == group.GroupName || depth > len(groups) {
+				return fmt.Errorf("rule group %q contains itself", group.GroupName)
+			}
+		}
+	}
+	for _, group := range groups {
+		for _, name := range group.ExclusiveWith {
+			if !p.hasRule(name) && byName[name] == nil {
+				return fmt.Errorf("rule group %q is exclusive with unknown rule %q", group.GroupName, name)
+			}
+			if p.contains(&group, name, byName) {
+				return fmt.Errorf("rule group %q cannot be exclusive with its own rule %q", group.GroupName, name)
+			}
+		}
+	}
+	for i := range groups {
+		p.groups = append(p.groups, &groups[i])
+	}
+	return nil
+}
+
+// contains reports whether a rule or group is the group, or one of its descendants
+func (p *RuleProcessor) contains(group *RuleGroup, name string, byName map[string]*RuleGroup) bool {
+	if group.GroupName == name {
+		return true
+	}
+	for _, member := range group.Rules {
+		if member == name {
+			return true
+		}
+		if child := byName[member]; child != nil && p.contains(child, name, byName) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyGroups combines the rule results of a Breakdown by the groups, suppressing
+// the rules not awarded, and recalculates the points
+// breakdown: the Breakdown with every rule result, after rule caps
+func (p *RuleProcessor) applyGroups(breakdown *Breakdown) {
+	if len(p.groups) == 0 {
+		return
+	}
+	rules := map[string]*RuleResult{}
+	for i := range breakdown.Rules {
+		rules[breakdown.Rules[i].Rule] = &breakdown.Rules[i]
+	}
+	results := map[string]*GroupResult{}
+	byName := map[string]*RuleGroup{}
+	for _, group := range p.groups {
+		byName[group.GroupName] = group
+		result := &GroupResult{Group: group.GroupName, Strategy: group.Strategy, Rules: group.Rules}
+		if group.parent != nil {
+			result.Parent = group.parent.GroupName
+		}
+		results[group.GroupName] = result
+	}
+
+	// suppress a rule or group along with its members
+	var suppress func(name string)
+	suppress = func(name string) {
+		if rule, ok := rules[name]; ok {
+			rule.Points = 0
+			rule.Suppressed = true
+			return
+		}
+		results[name].Points = 0
+		results[name].Suppressed = true
+		for _, member := range byName[name].Rules {
+			suppress(member)
+		}
+	}
+
+	// evaluate the groups from the top, so each group sees the points of its members,
+	// excluded rules and groups earning no points so the next member can win
+	var evaluate func(group *RuleGroup) int
+	evaluate = func(group *RuleGroup) int {
+		if results[group.GroupName].Suppressed {
+			return 0
+		}
+		points := make([]int, len(group.Rules))
+		for i, name := range group.Rules {
+			if rule, ok := rules[name]; ok {
+				points[i] = rule.Points
+			} else {
+				points[i] = evaluate(byName[name])
+			}
+		}
+		winner := -1
+		for i := range group.Rules {
+			switch group.Strategy {
+			case MaxStrategy:
+				if points[i] > 0 && (winner < 0 || points[i] > points[winner]) {
+					winner = i
+				}
+			case FirstStrategy:
+				if points[i] > 0 && winner < 0 {
+					winner = i
+				}
+			}
+		}
+		total := 0
+		for i, name := range group.Rules {
+			if group.Strategy != SumStrategy && i != winner {
+				if points[i] > 0 {
+					suppress(name)
+				}
+				continue
+			}
+			total += points[i]
+		}
+		results[group.GroupName].Points = total
+		return total
+	}
+	// run evaluates the groups from the rule results with the excluded rules and groups suppressed
+	original := make([]int, len(breakdown.Rules))
+	for i := range breakdown.Rules {
+		original[i] = breakdown.Rules[i].Points
+	}
+	run := func(excluded []string) {
+		for i := range breakdown.Rules {
+			breakdown.Rules[i].Points, breakdown.Rules[i].Suppressed = original[i], false
+		}
+		for _, result := range results {
+			result.Points, result.Suppressed = 0, false
+		}
+		for _, name := range excluded {
+			suppress(name)
+		}
+		for _, group := range p.groups {
+			if group.parent == nil {
+				evaluate(group)
+			}
+		}
+	}
+
+	// exclusions, in the order the groups are defined, each decided with the
+	// exclusions of the groups before it applied
+	excluded := []string{}
+	for _, group := range p.groups {
+		if len(group.ExclusiveWith) == 0 {
+			continue
+		}
+		run(excluded)
+		if result := results[group.GroupName]; !result.Suppressed && result.Points > 0 {
+			excluded = append(excluded, group.ExclusiveWith...)
+		}
+	}
+	run(excluded)
+
+	breakdown.Points, breakdown.Uncapped = 0, 0
+	for _, rule := range breakdown.Rules {
+		breakdown.Points += rule.Points
+		if !rule.Suppressed {
+			breakdown.Uncapped += rule.Uncapped
+		}
+	}
+	for _, group := range p.groups {
+		breakdown.Groups = append(breakdown.Groups, *results[group.GroupName])
+	}
+}
@@ -0,0 +1,229 @@
+/*
+group_test.go contains functions for testing rule groups.
+*/
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRuleGroups verifies group strategies, nesting and exclusions
+func TestRuleGroups(t *testing.T) {
+	// retailer-name 6, round-dollar 50, quarter-multiple 25, odd-day 6, afternoon 10
+	odd := ParseReceipt(t, `{"retailer": "Target", "purchaseDate": "2022-01-01", "purchaseTime": "14:30", "total": "100.00", "items": []}`)
+	even := ParseReceipt(t, `{"retailer": "Target", "purchaseDate": "2022-01-02", "purchaseTime": "14:30", "total": "100.00", "items": []}`)
+
+	tests := []struct {
+		name       string
+		groups     []RuleGroup
+		receipt    Receipt
+		expected   int
+		suppressed []string
+		group      GroupResult
+	}{
+		{
+			name:     "no groups",
+			receipt:  odd,
+			expected: 97,
+		},
+		{
+			name:       "max",
+			groups:     []RuleGroup{{GroupName: "total-bonus", Strategy: "max", Rules: []string{"quarter-multiple", "round-dollar"}}},
+			receipt:    odd,
+			expected:   72,
+			suppressed: []string{"quarter-multiple"},
+			group:      GroupResult{Group: "total-bonus", Strategy: "max", Points: 50, Rules: []string{"quarter-multiple", "round-dollar"}},
+		},
+		{
+			name:       "first",
+			groups:     []RuleGroup{{GroupName: "total-bonus", Strategy: "first", Rules: []string{"quarter-multiple", "round-dollar"}}},
+			receipt:    odd,
+			expected:   47,
+			suppressed: []string{"round-dollar"},
+			group:      GroupResult{Group: "total-bonus", Strategy: "first", Points: 25, Rules: []string{"quarter-multiple", "round-dollar"}},
+		},
+		{
+			name:     "first skips members without points",
+			groups:   []RuleGroup{{GroupName: "date-bonus", Strategy: "first", Rules: []string{"odd-day", "afternoon"}}},
+			receipt:  even,
+			expected: 91,
+			group:    GroupResult{Group: "date-bonus", Strategy: "first", Points: 10, Rules: []string{"odd-day", "afternoon"}},
+		},
+		{
+			name: "nested",
+			groups: []RuleGroup{
+				{GroupName: "bonus", Strategy: "max", Rules: []string{"total-bonus", "afternoon"}},
+				{GroupName: "total-bonus", Strategy: "sum", Rules: []string{"quarter-multiple", "odd-day"}},
+			},
+			receipt:    odd,
+			expected:   87,
+			suppressed: []string{"afternoon"},
+			group:      GroupResult{Group: "total-bonus", Strategy: "sum", Points: 31, Rules: []string{"quarter-multiple", "odd-day"}, Parent: "bonus"},
+		},
+		{
+			name: "suppressed nested group",
+			groups: []RuleGroup{
+				{GroupName: "bonus", Strategy: "first", Rules: []string{"round-dollar", "total-bonus"}},
+				{GroupName: "total-bonus", Strategy: "sum", Rules: []string{"quarter-multiple", "odd-day"}},
+			},
+			receipt:    odd,
+			expected:   66,
+			suppressed: []string{"quarter-multiple", "odd-day"},
+			group:      GroupResult{Group: "total-bonus", Strategy: "sum", Points: 0, Rules: []string{"quarter-multiple", "odd-day"}, Parent: "bonus", Suppressed: true},
+		},
+		{
+			name:       "exclusive with",
+			groups:     []RuleGroup{{GroupName: "odd", Strategy: "sum", Rules: []string{"odd-day"}, ExclusiveWith: []string{"afternoon", "round-dollar"}}},
+			receipt:    odd,
+			expected:   37,
+			suppressed: []string{"afternoon", "round-dollar"},
+			group:      GroupResult{Group: "odd", Strategy: "sum", Points: 6, Rules: []string{"odd-day"}},
+		},
+		{
+			name:     "exclusive without points",
+			groups:   []RuleGroup{{GroupName: "odd", Strategy: "sum", Rules: []string{"odd-day"}, ExclusiveWith: []string{"afternoon"}}},
+			receipt:  even,
+			expected: 91,
+			group:    GroupResult{Group: "odd", Strategy: "sum", Points: 0, Rules: []string{"odd-day"}},
+		},
+		{
+			name: "exclusive with a group",
+			groups: []RuleGroup{
+				{GroupName: "odd", Strategy: "sum", Rules: []string{"odd-day"}, ExclusiveWith: []string{"total-bonus"}},
+				{GroupName: "total-bonus", Strategy: "sum", Rules: []string{"quarter-multiple", "round-dollar"}},
+			},
+			receipt:    odd,
+			expected:   22,
+			suppressed: []string{"quarter-multiple", "round-dollar"},
+			group:      GroupResult{Group: "total-bonus", Strategy: "sum", Points: 0, Rules: []string{"quarter-multiple", "round-dollar"}, Suppressed: true},
+		},
+		{
+			name: "exclusive with the winner of a group",
+			groups: []RuleGroup{
+				{GroupName: "total-bonus", Strategy: "max", Rules: []string{"round-dollar", "quarter-multiple"}},
+				{GroupName: "odd", Strategy: "sum", Rules: []string{"odd-day"}, ExclusiveWith: []string{"round-dollar"}},
+			},
+			receipt:    odd,
+			expected:   47,
+			suppressed: []string{"round-dollar"},
+			group:      GroupResult{Group: "total-bonus", Strategy: "max", Points: 25, Rules: []string{"round-dollar", "quarter-multiple"}},
+		},
+		{
+			name: "excluded group does not exclude",
+			groups: []RuleGroup{
+				{GroupName: "odd", Strategy: "sum", Rules: []string{"odd-day"}, ExclusiveWith: []string{"afternoon-bonus"}},
+				{GroupName: "afternoon-bonus", Strategy: "sum", Rules: []string{"afternoon"}, ExclusiveWith: []string{"odd"}},
+			},
+			receipt:    odd,
+			expected:   87,
+			suppressed: []string{"afternoon"},
+			group:      GroupResult{Group: "odd", Strategy: "sum", Points: 6, Rules: []string{"odd-day"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, err := NewRuleProcessorFromConfig(RuleConfig{Groups: tt.groups}, nil)
+			assert.NoError(t, err)
+			breakdown := processor.Breakdown(tt.receipt)
+			assert.Equal(t, tt.expected, breakdown.Points)
+			sum := 0
+			for _, result := range breakdown.Rules {
+				assert.Equal(t, contains(tt.suppressed, result.Rule), result.Suppressed, result.Rule)
+				sum += result.Points
+			}
+			assert.Equal(t, tt.expected, sum)
+			assert.Equal(t, tt.expected, breakdown.Uncapped)
+			if tt.group.Group != "" {
+				assert.Contains(t, breakdown.Groups, tt.group)
+			}
+		})
+	}
+}
+
+// TestRuleGroupConfigErrors verifies invalid rule groups are rejected
+func TestRuleGroupConfigErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		groups      []RuleGroup
+		expectedErr string
+	}{
+		{
+			name:        "missing name",
+			groups:      []RuleGroup{{Strategy: "sum", Rules: []string{"odd-day"}}},
+			expectedErr: "missing a name",
+		},
+		{
+			name:        "rule name",
+			groups:      []RuleGroup{{GroupName: "odd-day", Strategy: "sum", Rules: []string{"afternoon"}}},
+			expectedErr: "duplicate rule group name",
+		},
+		{
+			name:        "invalid strategy",
+			groups:      []RuleGroup{{GroupName: "g", Strategy: "min", Rules: []string{"odd-day"}}},
+			expectedErr: "invalid strategy",
+		},
+		{
+			name:        "no rules",
+			groups:      []RuleGroup{{GroupName: "g", Strategy: "sum"}},
+			expectedErr: "has no rules",
+		},
+		{
+			name:        "unknown rule",
+			groups:      []RuleGroup{{GroupName: "g", Strategy: "sum", Rules: []string{"even-day"}}},
+			expectedErr: `references unknown rule "even-day"`,
+		},
+		{
+			name: "two groups",
+			groups: []RuleGroup{
+				{GroupName: "a", Strategy: "sum", Rules: []string{"odd-day"}},
+				{GroupName: "b", Strategy: "sum", Rules: []string{"odd-day"}},
+			},
+			expectedErr: `rule "odd-day" is in both rule groups "a" and "b"`,
+		},
+		{
+			name: "cycle",
+			groups: []RuleGroup{
+				{GroupName: "a", Strategy: "sum", Rules: []string{"b"}},
+				{GroupName: "b", Strategy: "sum", Rules: []string{"a"}},
+			},
+			expectedErr: "contains itself",
+		},
+		{
+			name: "under a cycle",
+			groups: []RuleGroup{
+				{GroupName: "a", Strategy: "sum", Rules: []string{"odd-day"}},
+				{GroupName: "b", Strategy: "sum", Rules: []string{"a", "c"}},
+				{GroupName: "c", Strategy: "sum", Rules: []string{"b"}},
+			},
+			expectedErr: "contains itself",
+		},
+		{
+			name:        "exclusive with unknown",
+			groups:      []RuleGroup{{GroupName: "g", Strategy: "sum", Rules: []string{"odd-day"}, ExclusiveWith: []string{"even-day"}}},
+			expectedErr: "exclusive with unknown rule",
+		},
+		{
+			name:        "exclusive with own rule",
+			groups:      []RuleGroup{{GroupName: "g", Strategy: "sum", Rules: []string{"odd-day"}, ExclusiveWith: []string{"odd-day"}}},
+			expectedErr: "cannot be exclusive with its own rule",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRuleProcessorFromConfig(RuleConfig{Groups: tt.groups}, nil)
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
+// contains reports whether names contains name
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
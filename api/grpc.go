@@ -0,0 +1,216 @@
+/*
+grpc.go contains the gRPC service mirroring the receipt endpoints, sharing the
+storage and rules of the REST API
+*/
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"receiptprocessor/api/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// maxBatch is the most receipts processed by one ProcessReceipts call
+const maxBatch = 100
+
+// ReceiptServer serves the gRPC ReceiptService with a ReceiptHandler
+type ReceiptServer struct {
+	pb.UnimplementedReceiptServiceServer
+	// Receipts is the handler shared with the REST API
+	Receipts ReceiptHandler
+}
+
+// NewReceiptServer initializes a ReceiptServer
+// receipts: the handler shared with the REST API
+func NewReceiptServer(receipts ReceiptHandler) *ReceiptServer {
+	return &ReceiptServer{
+		Receipts: receipts,
+	}
+}
+
+// ServeGRPC serves the ReceiptService on an address until the listener fails
+// address: the address to listen on, e.g. :9090
+// receipts: the handler shared with the REST API
+// Returns: the error the listener failed with
+func ServeGRPC(address string, receipts ReceiptHandler) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer()
+	pb.RegisterReceiptServiceServer(server, NewReceiptServer(receipts))
+	return server.Serve(listener)
+}
+
+// ProcessReceipt processes a Receipt, like POST /receipts/process
+// Returns: the id of the Receipt, or InvalidArgument for an invalid Receipt
+func (s *ReceiptServer) ProcessReceipt(ctx context.Context, request *pb.ProcessReceiptRequest) (*pb.ProcessReceiptResponse, error) {
+	receipt, err := s.validate(request.GetReceipt())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pb.ProcessReceiptResponse{Id: s.Receipts.process(receipt, grpcActor(ctx))}, nil
+}
+
+// GetReceiptPoints returns the points of a Receipt with its pinned rule set, like GET /receipts/{id}/points
+// Returns: the points of the Receipt, or NotFound
+func (s *ReceiptServer) GetReceiptPoints(ctx context.Context, request *pb.GetReceiptPointsRequest) (*pb.GetReceiptPointsResponse, error) {
+	receipt, err := s.Receipts.Database.GetReceipt(request.GetId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "Receipt not found")
+	}
+	score := s.Receipts.pinnedScore(request.GetId(), receipt)
+	return &pb.GetReceiptPointsResponse{Points: int64(score.Breakdown.Points)}, nil
+}
+
+// ProcessReceipts processes a batch of receipts, processing the valid ones
+// and reporting why the others are invalid
+// Returns: a result for each Receipt, or InvalidArgument for a batch over maxBatch
+func (s *ReceiptServer) ProcessReceipts(ctx context.Context, request *pb.ProcessReceiptsRequest) (*pb.ProcessReceiptsResponse, error) {
+	if len(request.GetReceipts()) > maxBatch {
+		return nil, status.Errorf(codes.InvalidArgument, "Batch over %d receipts", maxBatch)
+	}
+	actor := grpcActor(ctx)
+	response := &pb.ProcessReceiptsResponse{}
+	for index, receipt := range request.GetReceipts() {
+		response.Results = append(response.Results, s.result(index, receipt, actor))
+	}
+	return response, nil
+}
+
+// StreamReceipts processes a stream of receipts, sending a result for each as it is processed
+// Returns: when the client closes the stream, or the error receiving or sending
+func (s *ReceiptServer) StreamReceipts(stream pb.ReceiptService_StreamReceiptsServer) error {
+	actor := grpcActor(stream.Context())
+	for index := 0; ; index++ {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(s.result(index, request.GetReceipt(), actor)); err != nil {
+			return err
+		}
+	}
+}
+
+// result processes one receipt of a batch or stream
+// index: the position of the receipt
+// receipt: the receipt to validate and process
+// actor: who submitted the receipt
+// Returns: the id of the processed Receipt, or why it is invalid
+func (s *ReceiptServer) result(index int, receipt *pb.Receipt, actor string) *pb.ProcessResult {
+	valid, err := s.validate(receipt)
+	if err != nil {
+		return &pb.ProcessResult{Index: int32(index), Error: err.Error()}
+	}
+	return &pb.ProcessResult{Index: int32(index), Id: s.Receipts.process(valid, actor)}
+}
+
+// validate converts a protobuf receipt to a Receipt, validating it against the
+// Receipt schema in api.yml like the request validator, then like PostReceiptsProcess
+// receipt: the protobuf receipt
+// Returns: the Receipt, or an error describing the invalid field
+func (s *ReceiptServer) validate(receipt *pb.Receipt) (Receipt, error) {
+	if receipt == nil {
+		return Receipt{}, fmt.Errorf("Missing receipt")
+	}
+	items := []any{}
+	for _, item := range receipt.GetItems() {
+		value := map[string]any{
+			"shortDescription": item.GetShortDescription(),
+			"price":            item.GetPrice(),
+		}
+		if item.Quantity != nil {
+			value["quantity"] = item.GetQuantity()
+		}
+		if item.UnitPrice != nil {
+			value["unitPrice"] = item.GetUnitPrice()
+		}
+		if item.Discount != nil {
+			value["discount"] = item.GetDiscount()
+		}
+		items = append(items, value)
+	}
+	value := map[string]any{
+		"retailer":     receipt.GetRetailer(),
+		"purchaseDate": receipt.GetPurchaseDate(),
+		"purchaseTime": receipt.GetPurchaseTime(),
+		"items":        items,
+		"total":        receipt.GetTotal(),
+	}
+	if receipt.MemberId != nil {
+		value["memberId"] = receipt.GetMemberId()
+	}
+	if receipt.TimeZone != nil {
+		value["timeZone"] = receipt.GetTimeZone()
+	}
+	if receipt.UtcOffset != nil {
+		value["utcOffset"] = receipt.GetUtcOffset()
+	}
+	if receipt.StoreId != nil {
+		value["storeId"] = receipt.GetStoreId()
+	}
+	if receipt.PaymentMethod != nil {
+		value["paymentMethod"] = receipt.GetPaymentMethod()
+	}
+	if receipt.Tip != nil {
+		value["tip"] = receipt.GetTip()
+	}
+	if len(receipt.GetDiscounts()) > 0 {
+		discounts := []any{}
+		for _, discount := range receipt.GetDiscounts() {
+			line := map[string]any{"amount": discount.GetAmount()}
+			if discount.Description != nil {
+				line["description"] = discount.GetDescription()
+			}
+			if discount.Code != nil {
+				line["code"] = discount.GetCode()
+			}
+			discounts = append(discounts, line)
+		}
+		value["discounts"] = discounts
+	}
+	if len(receipt.GetTaxes()) > 0 {
+		taxes := []any{}
+		for _, tax := range receipt.GetTaxes() {
+			line := map[string]any{"amount": tax.GetAmount()}
+			if tax.Description != nil {
+				line["description"] = tax.GetDescription()
+			}
+			if tax.Rate != nil {
+				line["rate"] = tax.GetRate()
+			}
+			taxes = append(taxes, line)
+		}
+		value["taxes"] = taxes
+	}
+	if receipt.Currency != nil {
+		value["currency"] = receipt.GetCurrency()
+	}
+	decoded, err := DecodeReceipt(value)
+	if err != nil {
+		return Receipt{}, err
+	}
+	return s.Receipts.Rates.Record(decoded)
+}
+
+// grpcActor identifies who made a call, from the x-forwarded-user metadata set
+// by the authentication proxy
+func grpcActor(ctx context.Context) string {
+	if users := metadata.ValueFromIncomingContext(ctx, "x-forwarded-user"); len(users) > 0 && users[0] != "" {
+		return users[0]
+	}
+	return "anonymous"
+}
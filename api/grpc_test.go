@@ -0,0 +1,174 @@
+/*
+grpc_test.go contains functions for testing the gRPC ReceiptService.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"receiptprocessor/api/pb"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// GRPCClient serves the ReceiptService for a ReceiptHandler in memory, returning a client
+// until the test ends
+func GRPCClient(t *testing.T, receipts ReceiptHandler) pb.ReceiptServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterReceiptServiceServer(server, NewReceiptServer(receipts))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	connection, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { connection.Close() })
+	return pb.NewReceiptServiceClient(connection)
+}
+
+// pepsi returns a valid receipt worth 31 points
+func pepsi(retailer string) *pb.Receipt {
+	return &pb.Receipt{
+		Retailer:     retailer,
+		PurchaseDate: "2022-01-02",
+		PurchaseTime: "13:13",
+		Total:        "1.25",
+		Items:        []*pb.Item{{ShortDescription: "Pepsi - 12-oz", Price: "1.25"}},
+	}
+}
+
+// TestGRPCProcessReceipt verifies receipts processed over gRPC share storage with the REST API
+func TestGRPCProcessReceipt(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	router := NewReceiptRouter(receipts)
+	client := GRPCClient(t, receipts)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-forwarded-user", "alice")
+
+	processed, err := client.ProcessReceipt(ctx, &pb.ProcessReceiptRequest{Receipt: pepsi("Target")})
+	assert.NoError(t, err)
+	points, err := client.GetReceiptPoints(ctx, &pb.GetReceiptPointsRequest{Id: processed.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(31), points.Points)
+
+	recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id+"/points", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"points":31}`, recorder.Body.String())
+	entries := receipts.Database.GetAuditEntries(processed.Id)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "alice", entries[0].Actor)
+
+	_, err = client.GetReceiptPoints(ctx, &pb.GetReceiptPointsRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// TestGRPCValidation verifies receipts are validated like the api.yml Receipt schema
+func TestGRPCValidation(t *testing.T) {
+	client := GRPCClient(t, NewReceiptHandler(&Database{}))
+	invalid := map[string]func(receipt *pb.Receipt){
+		"Invalid retailer: string doesn't match the regular expression \"^[\\w\\s\\-&]+$\"":   func(receipt *pb.Receipt) { receipt.Retailer = "Target!" },
+		"Invalid total: string doesn't match the regular expression \"^\\d+(\\.\\d{1,3})?$\"": func(receipt *pb.Receipt) { receipt.Total = "1,25" },
+		"Invalid total: USD amounts have 2 decimal places":                                    func(receipt *pb.Receipt) { receipt.Total = "1.2" },
+		"Invalid items: minimum number of items is 1":                                         func(receipt *pb.Receipt) { receipt.Items = nil },
+		"Invalid purchaseDate": func(receipt *pb.Receipt) { receipt.PurchaseDate = "2022-13-02" },
+		"Invalid purchaseTime": func(receipt *pb.Receipt) { receipt.PurchaseTime = "25:00" },
+		"Invalid paymentMethod": func(receipt *pb.Receipt) {
+			method := "bitcoin"
+			receipt.PaymentMethod = &method
+		},
+		"Invalid items.0.price": func(receipt *pb.Receipt) {
+			unitPrice := "1.00"
+			receipt.Items[0].UnitPrice = &unitPrice
+		},
+	}
+	for message, change := range invalid {
+		receipt := pepsi("Target")
+		change(receipt)
+		_, err := client.ProcessReceipt(context.Background(), &pb.ProcessReceiptRequest{Receipt: receipt})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), message)
+		assert.Contains(t, status.Convert(err).Message(), message)
+	}
+	_, err := client.ProcessReceipt(context.Background(), &pb.ProcessReceiptRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestGRPCItemizedReceipt verifies the quantities, discounts, taxes, tip, payment method
+// and store of receipts processed over gRPC are stored
+func TestGRPCItemizedReceipt(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	client := GRPCClient(t, receipts)
+	receipt := pepsi("Target")
+	quantity, unitPrice, discount := 2.0, "0.75", "0.25"
+	receipt.Items[0].Quantity, receipt.Items[0].UnitPrice, receipt.Items[0].Discount = &quantity, &unitPrice, &discount
+	storeId, method, code, tip := "store-0042", "store-card", "SAVE10", "0.10"
+	receipt.StoreId, receipt.PaymentMethod, receipt.Tip = &storeId, &method, &tip
+	receipt.Discounts = []*pb.Discount{{Code: &code, Amount: "0.20"}}
+	receipt.Taxes = []*pb.TaxLine{{Amount: "0.10"}}
+
+	processed, err := client.ProcessReceipt(context.Background(), &pb.ProcessReceiptRequest{Receipt: receipt})
+	assert.NoError(t, err)
+	stored, _ := receipts.Database.GetReceipt(processed.Id)
+	assert.Equal(t, 2.0, *stored.Items[0].Quantity)
+	assert.Equal(t, "0.25", *stored.Items[0].Discount)
+	assert.Equal(t, StoreCard, *stored.PaymentMethod)
+	assert.Equal(t, "store-0042", *stored.StoreId)
+	assert.Equal(t, []Discount{{Code: &code, Amount: "0.20"}}, *stored.Discounts)
+	assert.Equal(t, []TaxLine{{Amount: "0.10"}}, *stored.Taxes)
+	_, err = receipts.Database.GetReview(processed.Id)
+	assert.Error(t, err)
+}
+
+// TestGRPCBatchAndStream verifies batches and streams process the valid receipts
+// and report the invalid ones by position
+func TestGRPCBatchAndStream(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	client := GRPCClient(t, receipts)
+	invalid := pepsi("Target")
+	invalid.Total = "1"
+
+	batch, err := client.ProcessReceipts(context.Background(), &pb.ProcessReceiptsRequest{
+		Receipts: []*pb.Receipt{pepsi("Target"), invalid, pepsi("Walgreens")},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, batch.Results, 3)
+	assert.NotEmpty(t, batch.Results[0].Id)
+	assert.Equal(t, int32(1), batch.Results[1].Index)
+	assert.Empty(t, batch.Results[1].Id)
+	assert.Contains(t, batch.Results[1].Error, "Invalid total")
+	assert.NotEmpty(t, batch.Results[2].Id)
+	_, err = receipts.Database.GetReceipt(batch.Results[2].Id)
+	assert.NoError(t, err)
+
+	oversized := &pb.ProcessReceiptsRequest{}
+	for range maxBatch + 1 {
+		oversized.Receipts = append(oversized.Receipts, pepsi("Target"))
+	}
+	_, err = client.ProcessReceipts(context.Background(), oversized)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stream, err := client.StreamReceipts(context.Background())
+	assert.NoError(t, err)
+	for index, receipt := range []*pb.Receipt{pepsi("Costco"), invalid} {
+		assert.NoError(t, stream.Send(&pb.ProcessReceiptRequest{Receipt: receipt}))
+		result, err := stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, int32(index), result.Index)
+	}
+	assert.NoError(t, stream.CloseSend())
+
+	recorder := ProcessRequest(NewReceiptRouter(receipts), httptest.NewRequest(http.MethodGet, "/receipts/"+batch.Results[0].Id+"/breakdown", nil))
+	breakdown := map[string]any{}
+	json.Unmarshal(recorder.Body.Bytes(), &breakdown)
+	assert.Equal(t, "v1", breakdown["ruleset"])
+}
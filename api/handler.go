@@ -4,71 +4,422 @@ handler.go contains methods for handling receipt requests
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
+// maxTextReceipt is the largest plain-text receipt accepted, in bytes
+const maxTextReceipt = 64 * 1024
+
+// defaultAttachmentLimit is the largest attachment accepted by default, in bytes
+const defaultAttachmentLimit = 10 << 20
+
 // ReceiptHandler handles the access of stored receipts, and the calculation
 // of points earned for a Receipt
 type ReceiptHandler struct {
 	// Database is the receipt storage
-	Database Database
-	// RuleProcessor processes a receipt to determine points earned
-	RuleProcessor RuleProcessor
+	Database *Database
+	// RuleSets are the versioned rules receipts are scored with
+	RuleSets *RuleSets
+	// Fraud flags suspicious receipts, which are held for review
+	Fraud *FraudDetector
+	// Webhooks are notified of receipt events
+	Webhooks *Webhooks
+	// Stream streams processed and scored receipts
+	Stream *ReceiptStream
+	// Text extracts receipts submitted as plain text
+	Text *TextParser
+	// Rates convert the amounts of receipts in other currencies to the base currency
+	Rates *ExchangeRates
+	// Blobs stores the photos and PDFs attached to receipts
+	Blobs BlobStore
+	// AttachmentLimit is the largest attachment accepted, in bytes
+	AttachmentLimit int64
+	// scoring serializes scoring, so member caps account for every other score
+	scoring *sync.Mutex
 }
 
-// NewReceiptHandler initializes ReceiptHandler with defaults
-func NewReceiptHandler() ReceiptHandler {
+// NewReceiptHandler initializes ReceiptHandler with the default rules as v1
+// database: the receipt storage
+func NewReceiptHandler(database *Database) ReceiptHandler {
+	rulesets := NewRuleSets(database)
+	rulesets.Register(RuleConfig{})
+	text, _ := NewTextParser(DefaultLayouts())
 	return ReceiptHandler{
-		Database:      Database{},
-		RuleProcessor: NewRuleProcessor(),
+		Database:        database,
+		RuleSets:        rulesets,
+		Fraud:           NewFraudDetector(),
+		Webhooks:        NewWebhooks(),
+		Stream:          NewReceiptStream(1000),
+		Text:            text,
+		Rates:           &ExchangeRates{Base: defaultCurrency},
+		Blobs:           LocalBlobStore{Dir: filepath.Join(os.TempDir(), "receiptprocessor", "attachments")},
+		AttachmentLimit: defaultAttachmentLimit,
+		scoring:         &sync.Mutex{},
 	}
 }
 
 // PostReceiptsProcess handles POST requests to process a Receipt,
-// storing the Receipt along with an associated UUID
+// storing the Receipt along with an associated UUID, checking whether it needs review,
+// and scoring it with the active rule set. Flagged receipts are held for review.
+// Receipts submitted as text/plain are extracted with the text layouts, and receipts
+// submitted as multipart/form-data may attach a photo or PDF of the receipt.
 // Response example: {"id":"7d4d837b-ef5e-47c0-89a9-889657b66eb9"}
 func (h *ReceiptHandler) PostReceiptsProcess(w http.ResponseWriter, r *http.Request) {
 	var receipt Receipt
-	if err := json.NewDecoder(r.Body).Decode(&receipt); err != nil {
+	var attachment *Attachment
+	var content []byte
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		var status int
+		var err error
+		if receipt, attachment, content, status, err = h.readMultipart(r); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	} else if mediaType == "text/plain" {
+		text, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxTextReceipt))
+		if err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		if receipt, err = h.Text.Parse(string(text)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&receipt); err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
 
-	// validate purchaseTime
-	_, err := time.Parse("15:04", receipt.PurchaseTime)
+	if err := validateReceipt(receipt); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	receipt, err := h.Rates.Record(receipt)
 	if err != nil {
-		http.Error(w, "Invalid purchaseTime", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	id := uuid.New()
-	h.Database.PutReceipt(id.String(), receipt)
+	if attachment != nil {
+		if err := h.Blobs.Put(attachment.Hash, bytes.NewReader(content)); err != nil {
+			http.Error(w, "Attachment could not be stored", http.StatusInternalServerError)
+			return
+		}
+	}
+	id := h.process(receipt, actor(r))
+	if attachment != nil {
+		h.Database.PutAttachment(id, *attachment)
+	}
 	response := PostReceiptsProcessResponse{
-		Id: id.String(),
+		Id: id,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetReceiptsIdPoints handles GET requests to get points earned for a Receipt
+// GetReceiptsIdPoints handles GET requests to get points earned for a Receipt,
+// as scored with its pinned rule set, or previewed with the ruleset query parameter
 // Response example: {"points":31}
 func (h *ReceiptHandler) GetReceiptsIdPoints(w http.ResponseWriter, r *http.Request) {
+	score, ok := h.requestScore(w, r)
+	if !ok {
+		return
+	}
+	response := GetReceiptsIdPointsResponse{
+		Points: score.Breakdown.Points,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetReceiptsIdBreakdown handles GET requests to get the points earned for a Receipt
+// from each rule, including the items that triggered item rules, as scored with
+// its pinned rule set, or previewed with the ruleset query parameter
+// Response example: {"ruleset":"v1","points":31,"uncapped":31,"rules":[{"rule":"retailer-name","points":6,"uncapped":6}]}
+func (h *ReceiptHandler) GetReceiptsIdBreakdown(w http.ResponseWriter, r *http.Request) {
+	score, ok := h.requestScore(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(breakdownResponse(score))
+}
+
+// PostReceiptsIdRescore handles POST requests to rescore a Receipt with the rule set
+// in the ruleset query parameter, or the active rule set, pinning the Receipt to it
+// Response example: {"ruleset":"v2","points":41,"uncapped":41,"rules":[{"rule":"retailer-name","points":6,"uncapped":6}]}
+func (h *ReceiptHandler) PostReceiptsIdRescore(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	receipt, err := h.Database.GetReceipt(id)
 	if err != nil {
 		http.Error(w, "Receipt not found", http.StatusNotFound)
 		return
 	}
-	response := GetReceiptsIdPointsResponse{
-		Points: h.RuleProcessor.Points(receipt),
+	ruleset := h.RuleSets.Active()
+	if version := r.URL.Query().Get("ruleset"); version != "" {
+		if ruleset, err = h.RuleSets.Get(version); err != nil {
+			http.Error(w, "Ruleset not found", http.StatusNotFound)
+			return
+		}
 	}
+	before, _ := h.Database.GetScore(id)
+	score := h.score(id, receipt, ruleset, true)
+	h.Database.RecordAudit(actor(r), ReceiptRescored, "receipt", id, before, score)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(breakdownResponse(score))
+}
+
+// process stores a valid Receipt along with an associated UUID, checks whether
+// it needs review, and scores it with the active rule set, holding flagged receipts
+// receipt: the validated Receipt
+// actor: who submitted the Receipt, for the audit log
+// Returns: the uuid string associated with the Receipt
+func (h *ReceiptHandler) process(receipt Receipt, actor string) string {
+	id := uuid.New().String()
+	ruleset := h.RuleSets.Active()
+	h.Database.PutReceipt(id, receipt)
+	h.publish(ReceiptProcessedEvent, ReceiptEvent{ReceiptId: id, Retailer: receipt.Retailer, MemberId: receipt.MemberId})
+	h.flag(id, receipt, ruleset)
+	h.score(id, receipt, ruleset, true)
+	h.Database.RecordAudit(actor, ReceiptCreated, "receipt", id, nil, receipt)
+	return id
+}
+
+// flag checks a stored Receipt for fraud and against the review rules of a rule set,
+// holding it for review when flagged, before it is scored
+// id: the id of the Receipt
+// receipt: the Receipt to check
+// ruleset: the RuleSet the Receipt is scored with
+func (h *ReceiptHandler) flag(id string, receipt Receipt, ruleset *RuleSet) {
+	now := time.Now().UTC()
+	// suspicious amounts are checked in the base currency
+	converted := ConvertReceipt(receipt)
+	flags := h.Fraud.Check(id, converted, ruleset.Processor.fraud, now)
+	flags = append(flags, ruleset.Processor.review.Check(converted)...)
+	if len(flags) > 0 {
+		h.Database.PutReview(id, NewReview(id, flags, now))
+		h.publish(ReceiptFlaggedEvent, ReceiptEvent{ReceiptId: id, Retailer: receipt.Retailer, MemberId: receipt.MemberId, Flags: flags})
+	}
+}
+
+// validateReceipt validates the fields of a Receipt the request validator cannot
+// receipt: the Receipt to validate
+// Returns: an error describing the invalid field
+func validateReceipt(receipt Receipt) error {
+	// validate purchaseTime
+	if _, err := time.Parse("15:04", receipt.PurchaseTime); err != nil {
+		return fmt.Errorf("Invalid purchaseTime")
+	}
+
+	// validate timeZone and utcOffset
+	if _, err := ReceiptLocation(receipt, time.UTC); err != nil {
+		return fmt.Errorf("Invalid timeZone or utcOffset: %w", err)
+	}
+
+	// validate currency and the decimal places of its amounts
+	if err := validateAmounts(receipt); err != nil {
+		return err
+	}
+	decimals, _ := CurrencyDecimals(receipt.Currency)
+
+	// validate the price of items with a unit price is the quantity times the unit price, less the discount
+	for i, item := range receipt.Items {
+		if item.UnitPrice == nil {
+			continue
+		}
+		expected := int64(math.Round(itemQuantity(item) * float64(parseMinor(*item.UnitPrice, decimals))))
+		if item.Discount != nil {
+			expected -= parseMinor(*item.Discount, decimals)
+		}
+		if parseMinor(item.Price, decimals) != expected {
+			return fmt.Errorf("Invalid items.%d.price: quantity times unitPrice less discount is %s", i, strconv.FormatFloat(float64(expected)/math.Pow10(decimals), 'f', decimals, 64))
+		}
+	}
+	return nil
+}
+
+// itemQuantity returns the quantity of an Item, 1 when not given
+func itemQuantity(item Item) float64 {
+	if item.Quantity == nil {
+		return 1
+	}
+	return *item.Quantity
+}
+
+// receiptSchema is the Receipt schema in api.yml
+var receiptSchema = sync.OnceValue(func() *openapi3.Schema {
+	spec, _ := GetSwagger()
+	return spec.Components.Schemas["Receipt"].Value
+})
+
+// DecodeReceipt converts a decoded JSON receipt to a Receipt, validating it against
+// the Receipt schema in api.yml like the request validator, then like PostReceiptsProcess,
+// for receipts that do not arrive through the request validator
+// value: the receipt decoded from JSON
+// Returns: the Receipt, or an error describing the invalid field
+func DecodeReceipt(value any) (Receipt, error) {
+	if err := receiptSchema().VisitJSON(value); err != nil {
+		schemaErr := &openapi3.SchemaError{}
+		if errors.As(err, &schemaErr) {
+			return Receipt{}, fmt.Errorf("Invalid %s: %s", strings.Join(schemaErr.JSONPointer(), "."), schemaErr.Reason)
+		}
+		return Receipt{}, fmt.Errorf("Invalid receipt: %w", err)
+	}
+	data, _ := json.Marshal(value)
+	var receipt Receipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return Receipt{}, fmt.Errorf("Invalid purchaseDate")
+	}
+	if err := validateReceipt(receipt); err != nil {
+		return Receipt{}, err
+	}
+	return receipt, nil
+}
+
+// requestScore finds the Score for the receipt id in a request, pinned or previewed
+// with the ruleset query parameter, writing an error response when not found
+// Returns: the Score, and whether it was found
+func (h *ReceiptHandler) requestScore(w http.ResponseWriter, r *http.Request) (Score, bool) {
+	id := chi.URLParam(r, "id")
+	receipt, err := h.Database.GetReceipt(id)
+	if err != nil {
+		http.Error(w, "Receipt not found", http.StatusNotFound)
+		return Score{}, false
+	}
+	if version := r.URL.Query().Get("ruleset"); version != "" {
+		ruleset, err := h.RuleSets.Get(version)
+		if err != nil {
+			http.Error(w, "Ruleset not found", http.StatusNotFound)
+			return Score{}, false
+		}
+		return h.score(id, receipt, ruleset, false), true
+	}
+	return h.pinnedScore(id, receipt), true
+}
+
+// pinnedScore finds the Score a stored Receipt is pinned to, scoring and pinning
+// it with the active rule set when it has none
+// id: the uuid string associated with the Receipt
+// receipt: the stored Receipt
+// Returns: the pinned Score
+func (h *ReceiptHandler) pinnedScore(id string, receipt Receipt) Score {
+	score, err := h.Database.GetScore(id)
+	if err != nil {
+		return h.score(id, receipt, h.RuleSets.Active(), true)
+	}
+	return score
+}
+
+// score scores a stored Receipt with a RuleSet, multiplying the points by the
+// member's tier, and limiting them by the member caps for the points earned
+// from the member's receipts submitted before it, so rescoring a Receipt does not
+// count receipts submitted after it. Receipts pending review are held at zero points,
+// reviewed receipts earn the points of the decision.
+// id: the uuid string associated with the Receipt
+// receipt: the Receipt to score
+// ruleset: the RuleSet to score with
+// pin: whether to store the Score, pinning the Receipt to the RuleSet version
+// and publishing receipt.scored
+// Returns: the Score for the Receipt
+func (h *ReceiptHandler) score(id string, receipt Receipt, ruleset *RuleSet, pin bool) Score {
+	h.scoring.Lock()
+	defer h.scoring.Unlock()
+	var earned []Earned
+	tier := ""
+	if receipt.MemberId != nil {
+		if status, err := h.Database.GetTier(*receipt.MemberId); err == nil {
+			tier = status.Tier
+		}
+		for _, otherId := range h.Database.GetMemberReceiptIds(*receipt.MemberId) {
+			if otherId == id {
+				break
+			}
+			other, err := h.Database.GetScore(otherId)
+			if err != nil {
+				continue
+			}
+			otherReceipt, _ := h.Database.GetReceipt(otherId)
+			earned = append(earned, Earned{Date: otherReceipt.PurchaseDate.Time, Points: other.Breakdown.Points})
+		}
+	}
+	score := Score{
+		Version:   ruleset.Version,
+		Breakdown: ruleset.Processor.MemberBreakdown(receipt, tier, earned),
+		ScoredAt:  time.Now().UTC(),
+	}
+	if review, err := h.Database.GetReview(id); err == nil {
+		score.Breakdown.Review = review.Status
+		switch review.Status {
+		case PendingReview:
+			score.Breakdown.Held = true
+			score.Breakdown.HeldPoints = score.Breakdown.Points
+			score.Breakdown.Points = 0
+		case RejectedReview:
+			score.Breakdown.Points = 0
+		case AdjustedReview:
+			score.Breakdown.Points = *review.Points
+		}
+	}
+	if pin {
+		h.Database.PutScore(id, score)
+		h.publish(ReceiptScoredEvent, ReceiptEvent{
+			ReceiptId: id,
+			Retailer:  receipt.Retailer,
+			MemberId:  receipt.MemberId,
+			Ruleset:   score.Version,
+			Points:    &score.Breakdown.Points,
+			Held:      score.Breakdown.Held,
+		})
+	}
+	return score
+}
+
+// publish notifies webhooks of a receipt event, and streams processed and scored receipts
+// event: the event type
+// data: describes the Receipt
+func (h *ReceiptHandler) publish(event string, data ReceiptEvent) {
+	h.Webhooks.Publish(event, data)
+	if event == ReceiptProcessedEvent || event == ReceiptScoredEvent {
+		h.Stream.Publish(event, data)
+	}
+}
+
+// breakdownResponse converts a Score to the json breakdown response
+func breakdownResponse(score Score) GetReceiptsIdBreakdownResponse {
+	return GetReceiptsIdBreakdownResponse{
+		Ruleset:    score.Version,
+		Points:     score.Breakdown.Points,
+		Uncapped:   score.Breakdown.Uncapped,
+		Capped:     score.Breakdown.Capped,
+		Rules:      score.Breakdown.Rules,
+		Groups:     score.Breakdown.Groups,
+		Tier:       score.Breakdown.Tier,
+		Multiplier: score.Breakdown.Multiplier,
+		TierBonus:  score.Breakdown.TierBonus,
+		Held:       score.Breakdown.Held,
+		HeldPoints: score.Breakdown.HeldPoints,
+		Review:     score.Breakdown.Review,
+	}
 }
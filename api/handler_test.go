@@ -165,6 +165,34 @@ func TestReceiptValidation(t *testing.T) {
 			}`,
 			expectedCode: http.StatusBadRequest,
 			expectedBody: "Invalid purchaseTime",
+		}, {
+			name: "bad timeZone",
+			requestBody: `{
+				"retailer": "Target",
+				"purchaseDate": "2022-01-01",
+				"purchaseTime": "13:01",
+				"items": [
+					{"shortDescription": "Mountain Dew 12PK", "price": "6.49"}
+				],
+				"total": "35.35",
+				"timeZone": "Mars/Olympus_Mons"
+			}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid timeZone or utcOffset",
+		}, {
+			name: "bad utcOffset",
+			requestBody: `{
+				"retailer": "Target",
+				"purchaseDate": "2022-01-01",
+				"purchaseTime": "13:01",
+				"items": [
+					{"shortDescription": "Mountain Dew 12PK", "price": "6.49"}
+				],
+				"total": "35.35",
+				"utcOffset": "0500"
+			}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "string doesn't match the regular expression",
 		}, {
 			name: "OK",
 			requestBody: `{
@@ -244,6 +272,144 @@ func TestReceiptHandlers(t *testing.T) {
 	}`))
 }
 
+// TestReceiptBreakdown verifies the points earned from each rule are reported
+func TestReceiptBreakdown(t *testing.T) {
+
+	router := GetRouter()
+
+	recorder := ProcessRequest(router, BuildRequest(`{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"total": "1.25",
+		"items": [
+			{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}
+		]
+	}`))
+	receiptId := &PostReceiptsProcessResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &receiptId)
+
+	request := httptest.NewRequest(http.MethodGet, "/receipts/"+receiptId.Id+"/breakdown", nil)
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	breakdown := &GetReceiptsIdBreakdownResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &breakdown)
+	assert.Equal(t, 31, breakdown.Points)
+	assert.Equal(t, []RuleResult{
+		{Rule: "retailer-name", Points: 6, Uncapped: 6},
+		{Rule: "round-dollar", Points: 0},
+		{Rule: "quarter-multiple", Points: 25, Uncapped: 25},
+		{Rule: "item-pairs", Points: 0},
+		{Rule: "item-description", Points: 0},
+		{Rule: "odd-day", Points: 0},
+		{Rule: "afternoon", Points: 0},
+	}, breakdown.Rules)
+
+	request = httptest.NewRequest(http.MethodGet, "/receipts/unknown/breakdown", nil)
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestMemberHandlers verifies member profiles can be stored and retrieved
+func TestMemberHandlers(t *testing.T) {
+
+	router := GetRouter()
+
+	request := httptest.NewRequest(http.MethodPut, "/members/member-1", strings.NewReader(`{"birthday": "1990-05"}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := ProcessRequest(router, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "string doesn't match the format \"date\"")
+
+	request = httptest.NewRequest(http.MethodPut, "/members/member-1", strings.NewReader(`{"birthday": "1990-05-14"}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	request = httptest.NewRequest(http.MethodGet, "/members/member-1", nil)
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"birthday": "1990-05-14"}`, recorder.Body.String())
+
+	request = httptest.NewRequest(http.MethodGet, "/members/member-2", nil)
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestItemizedReceipt verifies quantities, discounts, taxes, tip, payment method and store
+// are validated, stored, balanced against the total and available to expression rules
+func TestItemizedReceipt(t *testing.T) {
+	database := &Database{}
+	rulesets := NewRuleSets(database)
+	_, err := rulesets.Register(RuleConfig{ExpressionRules: []ExpressionRule{
+		{RuleName: "store-card", Expression: `paymentMethod == "store-card" ? 50 : 0`},
+	}})
+	assert.NoError(t, err)
+	router := NewRouter(database, rulesets)
+
+	recorder := ProcessRequest(router, BuildRequest(`{
+		"retailer": "Target",
+		"storeId": "store-0042",
+		"paymentMethod": "store-card",
+		"purchaseDate": "2022-01-01",
+		"purchaseTime": "13:01",
+		"total": "18.41",
+		"items": [
+			{"shortDescription": "Mountain Dew 12PK", "price": "6.49", "quantity": 2, "unitPrice": "3.50", "discount": "0.51"},
+			{"shortDescription": "Emils Cheese Pizza", "price": "12.25"}
+		],
+		"discounts": [{"description": "10% off", "code": "SAVE10", "amount": "1.50"}],
+		"taxes": [{"description": "State Tax", "rate": 6.25, "amount": "1.17"}],
+		"tip": "0.00"
+	}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	response := &PostReceiptsProcessResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	receipt, _ := database.GetReceipt(response.Id)
+	assert.Equal(t, "store-0042", *receipt.StoreId)
+	assert.Equal(t, StoreCard, *receipt.PaymentMethod)
+	assert.Equal(t, 2.0, *receipt.Items[0].Quantity)
+	assert.Equal(t, "SAVE10", *(*receipt.Discounts)[0].Code)
+	assert.Equal(t, 6.25, *(*receipt.Taxes)[0].Rate)
+	_, err = database.GetReview(response.Id)
+	assert.Error(t, err, "the total balances with the discounts and taxes")
+
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/"+response.Id+"/breakdown", nil))
+	breakdown := &GetReceiptsIdBreakdownResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &breakdown)
+	assert.Contains(t, breakdown.Rules, RuleResult{Rule: "store-card", Points: 50, Uncapped: 50})
+
+	recorder = ProcessRequest(router, BuildRequest(`{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-01",
+		"purchaseTime": "13:01",
+		"total": "7.00",
+		"items": [{"shortDescription": "Mountain Dew 12PK", "price": "7.00", "quantity": 2, "unitPrice": "3.50", "discount": "0.51"}]
+	}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "Invalid items.0.price: quantity times unitPrice less discount is 6.49\n", recorder.Body.String())
+
+	for _, invalid := range []string{`"paymentMethod": "bitcoin"`, `"tip": "1"`, `"taxes": [{"description": "State Tax"}]`, `"storeId": ""`} {
+		recorder = ProcessRequest(router, BuildRequest(`{
+			"retailer": "Target",
+			"purchaseDate": "2022-01-01",
+			"purchaseTime": "13:01",
+			"total": "1.25",
+			"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}],
+			`+invalid+`
+		}`))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, invalid)
+	}
+	recorder = ProcessRequest(router, BuildRequest(`{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-01",
+		"purchaseTime": "13:01",
+		"total": "1.25",
+		"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25", "quantity": 0}]
+	}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
 // BuildRequest is a helper for wrapping a json body in a request with appropriate header
 func BuildRequest(receipt string) *http.Request {
 	request := httptest.NewRequest(http.MethodPost, "/receipts/process", strings.NewReader(receipt))
@@ -263,7 +429,7 @@ func ProcessRequest(router chi.Router, request *http.Request) *httptest.Response
 // Returns: the points earned for the given receipt
 func GetReceiptPoints(t *testing.T, receipt string) (points int) {
 
-	handler := NewReceiptHandler()
+	handler := NewReceiptHandler(&Database{})
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodPost, "/process", strings.NewReader(receipt))
@@ -0,0 +1,98 @@
+/*
+itemrule.go contains rules that award points for matching items on a Receipt
+*/
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ItemRule awards points for every item on a Receipt whose short description
+// matches a keyword, a regular expression, or a product in the Catalog.
+// Exactly one of Keyword, Pattern or Sku must be set.
+//
+// Example json, 20 points per Gatorade up to 100 points per receipt:
+//
+//	{"name": "gatorade", "keyword": "gatorade", "points": 20, "cap": 100}
+type ItemRule struct {
+	// RuleName identifies the rule in a Breakdown
+	RuleName string `json:"name"`
+	// Keyword matches descriptions containing the keyword, ignoring case
+	Keyword string `json:"keyword,omitempty"`
+	// Pattern matches descriptions with a regular expression
+	Pattern string `json:"pattern,omitempty"`
+	// Sku matches descriptions of the product with this sku in the Catalog
+	Sku string `json:"sku,omitempty"`
+	// Points awarded for each matching item
+	Points int `json:"points"`
+	// Cap is the most points awarded per receipt, zero for no cap
+	Cap int `json:"cap,omitempty"`
+
+	// match reports whether an item matches, set by Compile
+	match func(item Item) bool
+}
+
+// Compile validates the rule and prepares it for scoring
+// catalog: the Catalog used to look up the Sku
+func (i *ItemRule) Compile(catalog Catalog) error {
+	if i.RuleName == "" {
+		return fmt.Errorf("item rule is missing a name")
+	}
+	set := 0
+	for _, field := range []string{i.Keyword, i.Pattern, i.Sku} {
+		if field != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("item rule %q must have exactly one of keyword, pattern or sku", i.RuleName)
+	}
+	switch {
+	case i.Keyword != "":
+		keyword := normalizeDescription(i.Keyword)
+		i.match = func(item Item) bool {
+			return strings.Contains(normalizeDescription(item.ShortDescription), keyword)
+		}
+	case i.Pattern != "":
+		re, err := regexp.Compile(i.Pattern)
+		if err != nil {
+			return fmt.Errorf("item rule %q has an invalid pattern: %w", i.RuleName, err)
+		}
+		i.match = func(item Item) bool {
+			return re.MatchString(strings.TrimSpace(item.ShortDescription))
+		}
+	case i.Sku != "":
+		product, ok := catalog.Product(i.Sku)
+		if !ok {
+			return fmt.Errorf("item rule %q references unknown sku %q", i.RuleName, i.Sku)
+		}
+		i.match = func(item Item) bool {
+			return product.Matches(item.ShortDescription)
+		}
+	}
+	return nil
+}
+
+// Name returns the name of the rule
+func (i *ItemRule) Name() string {
+	return i.RuleName
+}
+
+// Score awards Points for each matching item, up to Cap,
+// reporting the indexes of the matching items
+func (i *ItemRule) Score(r Receipt) RuleResult {
+	result := RuleResult{Rule: i.RuleName}
+	for index, item := range r.Items {
+		if i.match(item) {
+			result.Points += i.Points
+			result.Items = append(result.Items, index)
+		}
+	}
+	result.Uncapped = result.Points
+	if i.Cap > 0 && result.Points > i.Cap {
+		result.Points = i.Cap
+	}
+	return result
+}
@@ -0,0 +1,296 @@
+/*
+leaderboard.go contains the leaderboards ranking members by points and retailers by
+receipts or spend in each period, kept sorted as receipts are scored, and methods
+for handling leaderboard requests
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Leaderboard periods, by purchase date
+const (
+	// PeriodWeek ranks an ISO week, e.g. 2021-W52
+	PeriodWeek = "week"
+	// PeriodMonth ranks a month, e.g. 2022-01
+	PeriodMonth = "month"
+	// PeriodYear ranks a year, e.g. 2022
+	PeriodYear = "year"
+	// PeriodAll ranks every receipt
+	PeriodAll = "all"
+)
+
+// Leaderboards
+const (
+	// MembersByPoints ranks members by the points they earned
+	MembersByPoints = "members"
+	// RetailersByReceipts ranks retailers by their number of receipts
+	RetailersByReceipts = "receipts"
+	// RetailersBySpend ranks retailers by the sum of their receipt totals in the base currency
+	RetailersBySpend = "spend"
+)
+
+// defaultLeaderboardLimit is the page size when no limit is requested
+const defaultLeaderboardLimit = 10
+
+// periodKey returns the key of the period containing a date
+// period: one of the Period values
+// date: the purchase date
+// Returns: the key, e.g. 2022-01 for the month of 2022-01-02
+func periodKey(period string, date time.Time) string {
+	switch period {
+	case PeriodWeek:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	case PeriodMonth:
+		return date.Format("2006-01")
+	case PeriodYear:
+		return date.Format("2006")
+	}
+	return PeriodAll
+}
+
+// ranked is the value of a key on a leaderboard
+type ranked struct {
+	key   string
+	value int64
+}
+
+// before reports whether a ranks before b, by highest value then key
+func (a ranked) before(b ranked) bool {
+	return a.value > b.value || (a.value == b.value && a.key < b.key)
+}
+
+// leaderboard ranks keys by value, kept sorted as the values change, so pages are
+// read without sorting
+type leaderboard struct {
+	// entries are the keys with a value, ranked
+	entries []ranked
+	// values is a map of key->value
+	values map[string]int64
+}
+
+// add adds a delta to the value of a key, moving it to its rank, and removing keys
+// whose value falls to zero
+// key: the member id or normalized retailer
+// delta: the change in value
+func (l *leaderboard) add(key string, delta int64) {
+	if delta == 0 {
+		return
+	}
+	if value, ok := l.values[key]; ok {
+		i := l.position(ranked{key, value})
+		l.entries = slices.Delete(l.entries, i, i+1)
+		delta += value
+	}
+	if delta == 0 {
+		delete(l.values, key)
+		return
+	}
+	entry := ranked{key, delta}
+	l.entries = slices.Insert(l.entries, l.position(entry), entry)
+	l.values[key] = delta
+}
+
+// position returns the index of an entry, or where it is inserted
+func (l *leaderboard) position(entry ranked) int {
+	return sort.Search(len(l.entries), func(i int) bool {
+		return !l.entries[i].before(entry)
+	})
+}
+
+// rank returns the rank of a value, entries with equal values share the rank of the first
+func (l *leaderboard) rank(value int64) int {
+	return sort.Search(len(l.entries), func(i int) bool {
+		return l.entries[i].value <= value
+	}) + 1
+}
+
+// page returns the entries from an offset
+// offset: the number of entries to skip
+// limit: the most entries to return
+func (l *leaderboard) page(offset int, limit int) []ranked {
+	if l == nil || offset >= len(l.entries) {
+		return nil
+	}
+	return l.entries[offset:min(offset+limit, len(l.entries))]
+}
+
+// leaderboard returns a leaderboard for a period, creating it when missing
+// board: one of the leaderboards
+// period: one of the Period values
+// key: the key of the period
+func (d *Database) leaderboard(board string, period string, key string) *leaderboard {
+	name := board + "/" + period + "/" + key
+	if d.leaderboards == nil {
+		d.leaderboards = map[string]*leaderboard{}
+	}
+	if _, ok := d.leaderboards[name]; !ok {
+		d.leaderboards[name] = &leaderboard{values: map[string]int64{}}
+	}
+	return d.leaderboards[name]
+}
+
+// rankScore replaces the previous Score of a stored Receipt on the leaderboards of the
+// periods containing its purchase date, called with analyticsMu held
+// receipt: the scored Receipt
+// previous: the Score the Receipt was pinned to, nil when first scored
+// score: the Score the Receipt is pinned to
+// retailer: the normalized retailer of the Receipt
+// spend: the total of the Receipt, in hundredths of the base currency
+func (d *Database) rankScore(receipt Receipt, previous *Score, score Score, retailer string, spend int64) {
+	for _, period := range []string{PeriodWeek, PeriodMonth, PeriodYear, PeriodAll} {
+		key := periodKey(period, receipt.PurchaseDate.Time)
+		if receipt.MemberId != nil {
+			delta := score.Breakdown.Points
+			if previous != nil {
+				delta -= previous.Breakdown.Points
+			}
+			d.leaderboard(MembersByPoints, period, key).add(*receipt.MemberId, int64(delta))
+		}
+		if previous == nil {
+			d.leaderboard(RetailersByReceipts, period, key).add(retailer, 1)
+			d.leaderboard(RetailersBySpend, period, key).add(retailer, spend)
+		}
+	}
+}
+
+// GetMemberRankings retrieves a page of the members earning the most points in a period
+// period: one of the Period values
+// key: the key of the period
+// offset: the number of members to skip
+// limit: the most members to return
+// Returns: the number of members ranked, and the page of members
+func (d *Database) GetMemberRankings(period string, key string, offset int, limit int) (int, []MemberRanking) {
+	d.analyticsMu.Lock()
+	defer d.analyticsMu.Unlock()
+	board := d.leaderboards[MembersByPoints+"/"+period+"/"+key]
+	rankings := []MemberRanking{}
+	for _, entry := range board.page(offset, limit) {
+		rankings = append(rankings, MemberRanking{Rank: board.rank(entry.value), MemberId: entry.key, Points: entry.value})
+	}
+	if board == nil {
+		return 0, rankings
+	}
+	return len(board.entries), rankings
+}
+
+// GetRetailerRankings retrieves a page of the retailers with the most receipts or spend in a period
+// by: RetailersByReceipts or RetailersBySpend
+// period: one of the Period values
+// key: the key of the period
+// offset: the number of retailers to skip
+// limit: the most retailers to return
+// Returns: the number of retailers ranked, and the page of retailers
+func (d *Database) GetRetailerRankings(by string, period string, key string, offset int, limit int) (int, []RetailerRanking) {
+	d.analyticsMu.Lock()
+	defer d.analyticsMu.Unlock()
+	receipts := d.leaderboards[RetailersByReceipts+"/"+period+"/"+key]
+	spend := d.leaderboards[RetailersBySpend+"/"+period+"/"+key]
+	board := receipts
+	if by == RetailersBySpend {
+		board = spend
+	}
+	rankings := []RetailerRanking{}
+	for _, entry := range board.page(offset, limit) {
+		rankings = append(rankings, RetailerRanking{
+			Rank:     board.rank(entry.value),
+			Retailer: d.retailers[entry.key],
+			Receipts: receipts.values[entry.key],
+			Spend:    strconv.FormatFloat(float64(spend.values[entry.key])/100, 'f', 2, 64),
+		})
+	}
+	if board == nil {
+		return 0, rankings
+	}
+	return len(board.entries), rankings
+}
+
+// LeaderboardHandler handles requests for the leaderboards of members and retailers
+type LeaderboardHandler struct {
+	// Database is the receipt storage, with its leaderboards
+	Database *Database
+}
+
+// NewLeaderboardHandler initializes LeaderboardHandler
+// database: the receipt storage, with its leaderboards
+func NewLeaderboardHandler(database *Database) LeaderboardHandler {
+	return LeaderboardHandler{
+		Database: database,
+	}
+}
+
+// GetLeaderboardsMembers handles GET requests for the members earning the most points
+// in the period query parameter, members with equal points share a rank and are listed by id
+// Response example: {"period":"month","key":"2022-01","total":12,"members":[{"rank":1,"memberId":"alice","points":240},{"rank":2,"memberId":"bob","points":91}]}
+func (h *LeaderboardHandler) GetLeaderboardsMembers(w http.ResponseWriter, r *http.Request) {
+	response := GetLeaderboardsMembersResponse{}
+	offset, limit, ok := h.period(w, r, &response.Period, &response.Key)
+	if !ok {
+		return
+	}
+	response.Total, response.Members = h.Database.GetMemberRankings(response.Period, response.Key, offset, limit)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetLeaderboardsRetailers handles GET requests for the retailers with the most receipts,
+// or the most spend when the by query parameter is spend, in the period query parameter,
+// retailers with equal values share a rank and are listed by name ignoring case
+// Response example: {"period":"month","key":"2022-01","by":"spend","total":2,"retailers":[{"rank":1,"retailer":"Target","receipts":3,"spend":"602.50"},{"rank":2,"retailer":"Walgreens","receipts":1,"spend":"2.00"}]}
+func (h *LeaderboardHandler) GetLeaderboardsRetailers(w http.ResponseWriter, r *http.Request) {
+	response := GetLeaderboardsRetailersResponse{By: r.URL.Query().Get("by")}
+	if response.By == "" {
+		response.By = RetailersByReceipts
+	}
+	offset, limit, ok := h.period(w, r, &response.Period, &response.Key)
+	if !ok {
+		return
+	}
+	response.Total, response.Retailers = h.Database.GetRetailerRankings(response.By, response.Period, response.Key, offset, limit)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// period reads the period containing the date query parameter, the current month
+// by default, and the offset and limit query parameters
+// period: set to the period query parameter
+// key: set to the key of the period
+// Returns: the offset and limit, or false after responding to an invalid request
+func (h *LeaderboardHandler) period(w http.ResponseWriter, r *http.Request, period *string, key *string) (int, int, bool) {
+	query := r.URL.Query()
+	*period = query.Get("period")
+	if *period == "" {
+		*period = PeriodMonth
+	}
+	date := time.Now().UTC()
+	if value := query.Get("date"); value != "" {
+		var err error
+		if date, err = time.Parse("2006-01-02", value); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid date: %s is not a date", value), http.StatusBadRequest)
+			return 0, 0, false
+		}
+	}
+	*key = periodKey(*period, date)
+	offset, limit := 0, defaultLeaderboardLimit
+	for name, value := range map[string]*int{"offset": &offset, "limit": &limit} {
+		if query.Has(name) {
+			parsed, err := strconv.Atoi(query.Get(name))
+			if err != nil || parsed < 0 {
+				http.Error(w, fmt.Sprintf("Invalid %s: %s", name, query.Get(name)), http.StatusBadRequest)
+				return 0, 0, false
+			}
+			*value = parsed
+		}
+	}
+	return offset, limit, true
+}
@@ -0,0 +1,132 @@
+/*
+leaderboard_test.go contains functions for testing the leaderboards of members and retailers
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// Rank fetches a leaderboard through the router into its response
+func Rank(t *testing.T, router chi.Router, path string, response any) {
+	recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, path, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code, path)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), response))
+}
+
+// TestLeaderboards verifies members are ranked by points and retailers by receipts or
+// spend in each period, with ties sharing a rank, and the ranks follow review decisions
+func TestLeaderboards(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	// members submit the same receipt
+	_, err := receipts.RuleSets.Register(RuleConfig{Fraud: FraudConfig{Disabled: true}})
+	assert.NoError(t, err)
+	router := NewReceiptRouter(receipts)
+	ids := []string{}
+	for _, receipt := range []struct{ member, retailer, date, description, total string }{
+		// 31 points
+		{"alice", "Target", "2022-01-02", "Pepsi - 12-oz", "1.25"},
+		// 37 points
+		{"bob", "target", "2022-01-03", "Pepsi - 12-oz", "1.25"},
+		// 31 points
+		{"carol", "Target", "2022-01-02", "Pepsi - 12-oz", "1.25"},
+		// held for review, 81 points if approved
+		{"dave", "Target", "2022-01-10", "Television", "600.00"},
+		// 91 points
+		{"bob", "Walgreens", "2022-02-01", "Gum", "2.00"},
+	} {
+		recorder := ProcessRequest(router, BuildRequest(`{
+			"memberId": "`+receipt.member+`",
+			"retailer": "`+receipt.retailer+`",
+			"purchaseDate": "`+receipt.date+`",
+			"purchaseTime": "13:13",
+			"total": "`+receipt.total+`",
+			"items": [{"shortDescription": "`+receipt.description+`", "price": "`+receipt.total+`"}]
+		}`))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		response := PostReceiptsProcessResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		ids = append(ids, response.Id)
+	}
+
+	members := GetLeaderboardsMembersResponse{}
+	Rank(t, router, "/leaderboards/members?date=2022-01-31", &members)
+	assert.Equal(t, GetLeaderboardsMembersResponse{Period: "month", Key: "2022-01", Total: 3, Members: []MemberRanking{
+		{Rank: 1, MemberId: "bob", Points: 37},
+		{Rank: 2, MemberId: "alice", Points: 31},
+		{Rank: 2, MemberId: "carol", Points: 31},
+	}}, members)
+
+	Rank(t, router, "/leaderboards/members?date=2022-01-31&offset=2&limit=1", &members)
+	assert.Equal(t, 3, members.Total)
+	assert.Equal(t, []MemberRanking{{Rank: 2, MemberId: "carol", Points: 31}}, members.Members)
+
+	Rank(t, router, "/leaderboards/members?period=week&date=2022-01-02", &members)
+	assert.Equal(t, "2021-W52", members.Key)
+	assert.Len(t, members.Members, 2)
+
+	// approving the held receipt moves its member to the top
+	for _, path := range []string{"claim", "decision"} {
+		request := httptest.NewRequest(http.MethodPost, "/reviews/"+ids[3]+"/"+path, strings.NewReader(`{"reviewer": "alice", "decision": "approved"}`))
+		request.Header.Set("Content-Type", "application/json")
+		assert.Equal(t, http.StatusOK, ProcessRequest(router, request).Code)
+	}
+	Rank(t, router, "/leaderboards/members?date=2022-01-31&limit=2", &members)
+	assert.Equal(t, 4, members.Total)
+	assert.Equal(t, []MemberRanking{{Rank: 1, MemberId: "dave", Points: 81}, {Rank: 2, MemberId: "bob", Points: 37}}, members.Members)
+
+	Rank(t, router, "/leaderboards/members?period=year&date=2022-06-01&limit=1", &members)
+	assert.Equal(t, []MemberRanking{{Rank: 1, MemberId: "bob", Points: 128}}, members.Members)
+
+	// retailers are grouped ignoring case, under the name first scored
+	retailers := GetLeaderboardsRetailersResponse{}
+	Rank(t, router, "/leaderboards/retailers?period=all", &retailers)
+	assert.Equal(t, GetLeaderboardsRetailersResponse{Period: "all", Key: "all", By: "receipts", Total: 2, Retailers: []RetailerRanking{
+		{Rank: 1, Retailer: "Target", Receipts: 4, Spend: "603.75"},
+		{Rank: 2, Retailer: "Walgreens", Receipts: 1, Spend: "2.00"},
+	}}, retailers)
+
+	Rank(t, router, "/leaderboards/retailers?by=spend&date=2022-02-14", &retailers)
+	assert.Equal(t, []RetailerRanking{{Rank: 1, Retailer: "Walgreens", Receipts: 1, Spend: "2.00"}}, retailers.Retailers)
+
+	Rank(t, router, "/leaderboards/retailers?date=2023-01-01", &retailers)
+	assert.Equal(t, 0, retailers.Total)
+	assert.Equal(t, []RetailerRanking{}, retailers.Retailers)
+}
+
+// TestLeaderboardsInvalid verifies invalid periods and pages are rejected
+func TestLeaderboardsInvalid(t *testing.T) {
+	router := GetRouter()
+	for _, path := range []string{
+		"/leaderboards/members?period=day",
+		"/leaderboards/members?limit=0",
+		"/leaderboards/members?limit=101",
+		"/leaderboards/members?offset=-1",
+		"/leaderboards/retailers?by=points",
+		"/leaderboards/retailers?date=yesterday",
+	} {
+		recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, path)
+	}
+}
+
+// TestLeaderboard verifies entries move to their rank as their values change
+func TestLeaderboard(t *testing.T) {
+	board := &leaderboard{values: map[string]int64{}}
+	board.add("b", 5)
+	board.add("a", 5)
+	board.add("c", 3)
+	assert.Equal(t, []ranked{{"a", 5}, {"b", 5}, {"c", 3}}, board.entries)
+	board.add("c", 4)
+	board.add("a", -5)
+	assert.Equal(t, []ranked{{"c", 7}, {"b", 5}}, board.entries)
+	assert.Equal(t, map[string]int64{"c": 7, "b": 5}, board.values)
+	assert.Equal(t, 2, board.rank(5))
+}
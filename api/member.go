@@ -0,0 +1,65 @@
+/*
+member.go contains methods for handling member profile requests
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// MemberDirectory looks up member profiles, used by rules that depend on the
+// member submitting a Receipt
+type MemberDirectory interface {
+	GetMember(id string) (Member, error)
+}
+
+// MemberHandler handles the access of stored member profiles
+type MemberHandler struct {
+	// Database is the member storage
+	Database *Database
+}
+
+// NewMemberHandler initializes MemberHandler
+// database: the member storage
+func NewMemberHandler(database *Database) MemberHandler {
+	return MemberHandler{
+		Database: database,
+	}
+}
+
+// GetMembersId handles GET requests for a member profile
+// Response example: {"birthday":"1990-05-14"}
+func (h *MemberHandler) GetMembersId(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	member, err := h.Database.GetMember(id)
+	if err != nil {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(member)
+}
+
+// PutMembersId handles PUT requests to create or replace a member profile
+// Response example: {"birthday":"1990-05-14"}
+func (h *MemberHandler) PutMembersId(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var member Member
+	if err := json.NewDecoder(r.Body).Decode(&member); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	var before any
+	if existing, err := h.Database.GetMember(id); err == nil {
+		before = existing
+	}
+	h.Database.PutMember(id, member)
+	h.Database.RecordAudit(actor(r), MemberUpdated, "member", id, before, member)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(member)
+}
@@ -3,6 +3,13 @@ model.go contains models for helping unmarshal json
 */
 package api
 
+import (
+	"encoding/json"
+	"time"
+
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
 // PostReceiptsProcessResponse
 // Id: UUID string associated with a Receipt
 type PostReceiptsProcessResponse struct {
@@ -14,3 +21,327 @@ type PostReceiptsProcessResponse struct {
 type GetReceiptsIdPointsResponse struct {
 	Points int `json:"points"`
 }
+
+// GetReceiptsIdBreakdownResponse, also the response to rescoring a Receipt
+// Ruleset: the version of the rule set the Receipt was scored with
+// Points: points earned for a Receipt, after caps
+// Uncapped: points earned for a Receipt, before caps
+// Capped: the rule, receipt and member caps that limited the points, rule caps as rule:<name>
+// Rules: points earned from each rule
+// Groups: points earned from each rule group
+// Tier: the tier of the member
+// Multiplier: the multiplier of the Tier
+// TierBonus: the points added by the Multiplier
+// Held: whether the Receipt is held at zero points pending review
+// HeldPoints: the points the Receipt earns if approved
+// Review: the status of the review of a flagged Receipt
+type GetReceiptsIdBreakdownResponse struct {
+	Ruleset    string        `json:"ruleset"`
+	Points     int           `json:"points"`
+	Uncapped   int           `json:"uncapped"`
+	Capped     []string      `json:"capped,omitempty"`
+	Rules      []RuleResult  `json:"rules"`
+	Groups     []GroupResult `json:"groups,omitempty"`
+	Tier       string        `json:"tier,omitempty"`
+	Multiplier float64       `json:"multiplier,omitempty"`
+	TierBonus  int           `json:"tierBonus,omitempty"`
+	Held       bool          `json:"held,omitempty"`
+	HeldPoints int           `json:"heldPoints,omitempty"`
+	Review     string        `json:"review,omitempty"`
+}
+
+// GetMembersIdTierResponse
+// Tier: the tier the member qualified for at the last requalification
+// Multiplier: the multiplier of the Tier
+// QualifiedAt: when the member was last requalified, nil before the first requalification
+// Points: the points earned over the trailing 12 months
+// NextTier: the tier above Tier, empty for the highest tier
+// PointsToNextTier: the points needed to qualify for NextTier
+// Progress: the progress from Tier to NextTier, from 0 to 1
+type GetMembersIdTierResponse struct {
+	Tier             string     `json:"tier"`
+	Multiplier       float64    `json:"multiplier"`
+	QualifiedAt      *time.Time `json:"qualifiedAt,omitempty"`
+	Points           int        `json:"points"`
+	NextTier         string     `json:"nextTier,omitempty"`
+	PointsToNextTier int        `json:"pointsToNextTier"`
+	Progress         float64    `json:"progress"`
+}
+
+// PostRulesSimulateRequest
+// Ruleset: the candidate RuleConfig, added to the default rules
+// Receipts: the receipts to score, instead of the stored receipts
+// Filter: selects the stored receipts to score
+// Top: the number of receipts with the largest changes to return, 10 when nil
+// BucketSize: the width of the points distribution buckets, 25 when nil, widened so there are at most 100
+type PostRulesSimulateRequest struct {
+	Ruleset    json.RawMessage   `json:"ruleset"`
+	Receipts   []Receipt         `json:"receipts"`
+	Filter     *SimulationFilter `json:"filter"`
+	Top        *int              `json:"top"`
+	BucketSize *int              `json:"bucketSize"`
+}
+
+// SimulationFilter selects stored receipts, every field is optional
+// Retailer: the name of the retailer
+// MemberId: the id of the member
+// From: the first purchase date
+// To: the last purchase date
+// Ruleset: the rule set version the receipts are pinned to
+type SimulationFilter struct {
+	Retailer *string             `json:"retailer"`
+	MemberId *string             `json:"memberId"`
+	From     *openapi_types.Date `json:"from"`
+	To       *openapi_types.Date `json:"to"`
+	Ruleset  *string             `json:"ruleset"`
+}
+
+// PostRulesSimulateResponse
+// Receipts: the number of receipts scored
+// Old: summary of the current points
+// New: summary of the candidate points
+// Distribution: the number of receipts in each points bucket
+// Rules: the points earned from each rule, across the receipts
+// Changes: the receipts with the largest changes, largest first
+type PostRulesSimulateResponse struct {
+	Receipts     int             `json:"receipts"`
+	Old          PointsSummary   `json:"old"`
+	New          PointsSummary   `json:"new"`
+	Distribution []PointsBucket  `json:"distribution"`
+	Rules        []RuleDelta     `json:"rules"`
+	Changes      []ReceiptChange `json:"changes"`
+}
+
+// PointsSummary
+// Total: the points earned across the receipts
+// Mean: the mean points earned per receipt
+// Min: the fewest points earned for a receipt
+// Max: the most points earned for a receipt
+type PointsSummary struct {
+	Total int     `json:"total"`
+	Mean  float64 `json:"mean"`
+	Min   int     `json:"min"`
+	Max   int     `json:"max"`
+}
+
+// PointsBucket
+// From: the fewest points of the bucket, inclusive
+// To: the most points of the bucket, exclusive
+// Old: the number of receipts in the bucket with the current points
+// New: the number of receipts in the bucket with the candidate points
+type PointsBucket struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	Old  int `json:"old"`
+	New  int `json:"new"`
+}
+
+// RuleDelta
+// Rule: the name of the rule
+// Old: the current points earned from the rule
+// New: the candidate points earned from the rule
+// Delta: New - Old
+type RuleDelta struct {
+	Rule  string `json:"rule"`
+	Old   int    `json:"old"`
+	New   int    `json:"new"`
+	Delta int    `json:"delta"`
+}
+
+// ReceiptChange
+// Id: the id of a stored Receipt
+// Index: the index of a Receipt in the request
+// Retailer: the name of the retailer
+// Ruleset: the rule set version of the current points
+// Old: the current points
+// New: the candidate points
+// Delta: New - Old
+type ReceiptChange struct {
+	Id       string `json:"id,omitempty"`
+	Index    *int   `json:"index,omitempty"`
+	Retailer string `json:"retailer"`
+	Ruleset  string `json:"ruleset"`
+	Old      int    `json:"old"`
+	New      int    `json:"new"`
+	Delta    int    `json:"delta"`
+}
+
+// GetReviewsResponse
+// Reviews: the flagged receipts, oldest flagged first
+type GetReviewsResponse struct {
+	Reviews []ReviewSummary `json:"reviews"`
+}
+
+// ReviewSummary, also the response to getting, claiming, commenting on and deciding a review
+// Id: the id of the flagged Receipt
+// Retailer: the name of the retailer
+// MemberId: the member who submitted the Receipt, if any
+// Status: the status of the review
+// Flags: the reasons the Receipt was flagged
+// HeldPoints: the points the Receipt earns if approved, while pending
+// Points: the points the Receipt earns, once decided
+// FlaggedAt: when the Receipt was flagged
+// Reviewer: who claimed the review
+// ClaimedAt: when the review was claimed
+// DecidedAt: when the review was decided
+// History: the audit trail of the review, omitted from the review queue
+type ReviewSummary struct {
+	Id         string        `json:"id"`
+	Retailer   string        `json:"retailer"`
+	MemberId   *string       `json:"memberId,omitempty"`
+	Status     string        `json:"status"`
+	Flags      []FraudFlag   `json:"flags"`
+	HeldPoints int           `json:"heldPoints"`
+	Points     *int          `json:"points,omitempty"`
+	FlaggedAt  time.Time     `json:"flaggedAt"`
+	Reviewer   string        `json:"reviewer,omitempty"`
+	ClaimedAt  *time.Time    `json:"claimedAt,omitempty"`
+	DecidedAt  *time.Time    `json:"decidedAt,omitempty"`
+	History    []ReviewEvent `json:"history,omitempty"`
+}
+
+// PostReviewsIdClaimRequest
+// Reviewer: who is claiming the review
+// Comment: an optional comment for the audit trail
+type PostReviewsIdClaimRequest struct {
+	Reviewer string `json:"reviewer"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// PostReviewsIdCommentsRequest
+// Reviewer: who is commenting
+// Comment: the comment for the audit trail
+type PostReviewsIdCommentsRequest struct {
+	Reviewer string `json:"reviewer"`
+	Comment  string `json:"comment"`
+}
+
+// PostReviewsIdDecisionRequest
+// Reviewer: who is deciding, must have claimed the review
+// Decision: one of approved, rejected or adjusted
+// Points: the points to award, required to adjust
+// Comment: an optional comment for the audit trail
+type PostReviewsIdDecisionRequest struct {
+	Reviewer string `json:"reviewer"`
+	Decision string `json:"decision"`
+	Points   *int   `json:"points,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// GetAuditResponse
+// Valid: whether the hash chain of the whole audit log is intact
+// Error: describes the first broken entry when not Valid
+// Entries: the audit log entries, oldest first
+type GetAuditResponse struct {
+	Valid   bool         `json:"valid"`
+	Error   string       `json:"error,omitempty"`
+	Entries []AuditEntry `json:"entries"`
+}
+
+// PostGraphqlRequest
+// Query: the GraphQL query
+// OperationName: the operation to run, when the query has several
+// Variables: the values of the query variables
+type PostGraphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// PostReceiptsImportResponse
+// Imported: the number of receipts imported
+// Ids: the ids of the imported receipts, in the order read
+// Errors: why the other receipts were not imported, by line
+type PostReceiptsImportResponse struct {
+	Imported int           `json:"imported"`
+	Ids      []string      `json:"ids"`
+	Errors   []ImportError `json:"errors"`
+}
+
+// ExportedReceipt
+// Id: the id of the Receipt
+// Receipt: the stored Receipt
+// Ruleset: the version of the rule set the Receipt is pinned to
+// Points: the points earned with the pinned rule set
+type ExportedReceipt struct {
+	Id string `json:"id"`
+	Receipt
+	Ruleset string `json:"ruleset"`
+	Points  int    `json:"points"`
+}
+
+// GetAnalyticsResponse, the response to analytics requests
+// GroupBy: how the receipts are grouped, one of retailer, day, week, month or rule
+// From: the first purchase date included, if any
+// To: the last purchase date included, if any
+// Total: the aggregate of every receipt in the date range
+// Groups: the aggregate of each group, ordered by key
+type GetAnalyticsResponse struct {
+	GroupBy string      `json:"groupBy"`
+	From    string      `json:"from,omitempty"`
+	To      string      `json:"to,omitempty"`
+	Total   Aggregate   `json:"total"`
+	Groups  []Aggregate `json:"groups"`
+}
+
+// Aggregate
+// Key: the retailer, day, ISO week, month or rule of the group, omitted from the total
+// Count: the number of receipts
+// Points: the sum of the points earned, the points of the rule when grouped by rule
+// AveragePoints: the average points per receipt, to 2 decimal places
+// Spend: the sum of the receipt totals in the base currency
+type Aggregate struct {
+	Key           string  `json:"key,omitempty"`
+	Count         int     `json:"count"`
+	Points        int64   `json:"points"`
+	AveragePoints float64 `json:"averagePoints"`
+	Spend         string  `json:"spend"`
+}
+
+// GetLeaderboardsMembersResponse
+// Period: the period ranked, one of week, month, year or all
+// Key: the key of the period, e.g. 2022-01 for a month
+// Total: the number of members ranked
+// Members: the page of members, most points first
+type GetLeaderboardsMembersResponse struct {
+	Period  string          `json:"period"`
+	Key     string          `json:"key"`
+	Total   int             `json:"total"`
+	Members []MemberRanking `json:"members"`
+}
+
+// MemberRanking
+// Rank: the rank of the member, members with equal points share a rank
+// MemberId: the id of the member
+// Points: the points the member earned in the period
+type MemberRanking struct {
+	Rank     int    `json:"rank"`
+	MemberId string `json:"memberId"`
+	Points   int64  `json:"points"`
+}
+
+// GetLeaderboardsRetailersResponse
+// Period: the period ranked, one of week, month, year or all
+// Key: the key of the period, e.g. 2022-01 for a month
+// By: what the retailers are ranked by, receipts or spend
+// Total: the number of retailers ranked
+// Retailers: the page of retailers, most receipts or spend first
+type GetLeaderboardsRetailersResponse struct {
+	Period    string            `json:"period"`
+	Key       string            `json:"key"`
+	By        string            `json:"by"`
+	Total     int               `json:"total"`
+	Retailers []RetailerRanking `json:"retailers"`
+}
+
+// RetailerRanking
+// Rank: the rank of the retailer, retailers with equal values share a rank
+// Retailer: the name of the retailer first scored
+// Receipts: the number of receipts in the period
+// Spend: the sum of the receipt totals in the period, in the base currency
+type RetailerRanking struct {
+	Rank     int    `json:"rank"`
+	Retailer string `json:"retailer"`
+	Receipts int64  `json:"receipts"`
+	Spend    string `json:"spend"`
+}
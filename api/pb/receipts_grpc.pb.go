@@ -0,0 +1,246 @@
+// receipts.proto defines the gRPC service mirroring the receipt endpoints in api.yml
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: receipts.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ReceiptService_ProcessReceipt_FullMethodName   = "/receiptprocessor.v1.ReceiptService/ProcessReceipt"
+	ReceiptService_GetReceiptPoints_FullMethodName = "/receiptprocessor.v1.ReceiptService/GetReceiptPoints"
+	ReceiptService_ProcessReceipts_FullMethodName  = "/receiptprocessor.v1.ReceiptService/ProcessReceipts"
+	ReceiptService_StreamReceipts_FullMethodName   = "/receiptprocessor.v1.ReceiptService/StreamReceipts"
+)
+
+// ReceiptServiceClient is the client API for ReceiptService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ReceiptService processes receipts and returns the points they earn, sharing
+// storage and rules with the REST API
+type ReceiptServiceClient interface {
+	// ProcessReceipt submits a receipt for processing, like POST /receipts/process
+	ProcessReceipt(ctx context.Context, in *ProcessReceiptRequest, opts ...grpc.CallOption) (*ProcessReceiptResponse, error)
+	// GetReceiptPoints returns the points awarded for a receipt, like GET /receipts/{id}/points
+	GetReceiptPoints(ctx context.Context, in *GetReceiptPointsRequest, opts ...grpc.CallOption) (*GetReceiptPointsResponse, error)
+	// ProcessReceipts submits a batch of receipts, processing the valid ones
+	ProcessReceipts(ctx context.Context, in *ProcessReceiptsRequest, opts ...grpc.CallOption) (*ProcessReceiptsResponse, error)
+	// StreamReceipts submits a stream of receipts, returning a result for each as it is processed
+	StreamReceipts(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ProcessReceiptRequest, ProcessResult], error)
+}
+
+type receiptServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReceiptServiceClient(cc grpc.ClientConnInterface) ReceiptServiceClient {
+	return &receiptServiceClient{cc}
+}
+
+func (c *receiptServiceClient) ProcessReceipt(ctx context.Context, in *ProcessReceiptRequest, opts ...grpc.CallOption) (*ProcessReceiptResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProcessReceiptResponse)
+	err := c.cc.Invoke(ctx, ReceiptService_ProcessReceipt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *receiptServiceClient) GetReceiptPoints(ctx context.Context, in *GetReceiptPointsRequest, opts ...grpc.CallOption) (*GetReceiptPointsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetReceiptPointsResponse)
+	err := c.cc.Invoke(ctx, ReceiptService_GetReceiptPoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *receiptServiceClient) ProcessReceipts(ctx context.Context, in *ProcessReceiptsRequest, opts ...grpc.CallOption) (*ProcessReceiptsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProcessReceiptsResponse)
+	err := c.cc.Invoke(ctx, ReceiptService_ProcessReceipts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *receiptServiceClient) StreamReceipts(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ProcessReceiptRequest, ProcessResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ReceiptService_ServiceDesc.Streams[0], ReceiptService_StreamReceipts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ProcessReceiptRequest, ProcessResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReceiptService_StreamReceiptsClient = grpc.BidiStreamingClient[ProcessReceiptRequest, ProcessResult]
+
+// ReceiptServiceServer is the server API for ReceiptService service.
+// All implementations must embed UnimplementedReceiptServiceServer
+// for forward compatibility.
+//
+// ReceiptService processes receipts and returns the points they earn, sharing
+// storage and rules with the REST API
+type ReceiptServiceServer interface {
+	// ProcessReceipt submits a receipt for processing, like POST /receipts/process
+	ProcessReceipt(context.Context, *ProcessReceiptRequest) (*ProcessReceiptResponse, error)
+	// GetReceiptPoints returns the points awarded for a receipt, like GET /receipts/{id}/points
+	GetReceiptPoints(context.Context, *GetReceiptPointsRequest) (*GetReceiptPointsResponse, error)
+	// ProcessReceipts submits a batch of receipts, processing the valid ones
+	ProcessReceipts(context.Context, *ProcessReceiptsRequest) (*ProcessReceiptsResponse, error)
+	// StreamReceipts submits a stream of receipts, returning a result for each as it is processed
+	StreamReceipts(grpc.BidiStreamingServer[ProcessReceiptRequest, ProcessResult]) error
+	mustEmbedUnimplementedReceiptServiceServer()
+}
+
+// UnimplementedReceiptServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedReceiptServiceServer struct{}
+
+func (UnimplementedReceiptServiceServer) ProcessReceipt(context.Context, *ProcessReceiptRequest) (*ProcessReceiptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessReceipt not implemented")
+}
+func (UnimplementedReceiptServiceServer) GetReceiptPoints(context.Context, *GetReceiptPointsRequest) (*GetReceiptPointsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReceiptPoints not implemented")
+}
+func (UnimplementedReceiptServiceServer) ProcessReceipts(context.Context, *ProcessReceiptsRequest) (*ProcessReceiptsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessReceipts not implemented")
+}
+func (UnimplementedReceiptServiceServer) StreamReceipts(grpc.BidiStreamingServer[ProcessReceiptRequest, ProcessResult]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamReceipts not implemented")
+}
+func (UnimplementedReceiptServiceServer) mustEmbedUnimplementedReceiptServiceServer() {}
+func (UnimplementedReceiptServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeReceiptServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReceiptServiceServer will
+// result in compilation errors.
+type UnsafeReceiptServiceServer interface {
+	mustEmbedUnimplementedReceiptServiceServer()
+}
+
+func RegisterReceiptServiceServer(s grpc.ServiceRegistrar, srv ReceiptServiceServer) {
+	// If the following call pancis, it indicates UnimplementedReceiptServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ReceiptService_ServiceDesc, srv)
+}
+
+func _ReceiptService_ProcessReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessReceiptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReceiptServiceServer).ProcessReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReceiptService_ProcessReceipt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReceiptServiceServer).ProcessReceipt(ctx, req.(*ProcessReceiptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReceiptService_GetReceiptPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReceiptPointsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReceiptServiceServer).GetReceiptPoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReceiptService_GetReceiptPoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReceiptServiceServer).GetReceiptPoints(ctx, req.(*GetReceiptPointsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReceiptService_ProcessReceipts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessReceiptsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReceiptServiceServer).ProcessReceipts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReceiptService_ProcessReceipts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReceiptServiceServer).ProcessReceipts(ctx, req.(*ProcessReceiptsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReceiptService_StreamReceipts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ReceiptServiceServer).StreamReceipts(&grpc.GenericServerStream[ProcessReceiptRequest, ProcessResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReceiptService_StreamReceiptsServer = grpc.BidiStreamingServer[ProcessReceiptRequest, ProcessResult]
+
+// ReceiptService_ServiceDesc is the grpc.ServiceDesc for ReceiptService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ReceiptService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "receiptprocessor.v1.ReceiptService",
+	HandlerType: (*ReceiptServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ProcessReceipt",
+			Handler:    _ReceiptService_ProcessReceipt_Handler,
+		},
+		{
+			MethodName: "GetReceiptPoints",
+			Handler:    _ReceiptService_GetReceiptPoints_Handler,
+		},
+		{
+			MethodName: "ProcessReceipts",
+			Handler:    _ReceiptService_ProcessReceipts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamReceipts",
+			Handler:       _ReceiptService_StreamReceipts_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "receipts.proto",
+}
@@ -0,0 +1,334 @@
+/*
+plugin.go contains rules that award points with WebAssembly plugins
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	wasmapi "github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// maxPluginOutput is the longest json result read from a plugin
+const maxPluginOutput = 64 * 1024
+
+// PluginLimits limits the resources a plugin may use to score a single receipt
+type PluginLimits struct {
+	// MemoryPages is the most 64KiB pages of memory, defaults to 16 (1MiB)
+	MemoryPages uint32 `json:"memoryPages,omitempty"`
+	// Fuel is the most function calls, defaults to 100000
+	Fuel int `json:"fuel,omitempty"`
+	// Timeout is the longest call, e.g. 50ms, defaults to 50ms.
+	// Loops without function calls are only stopped by the Timeout.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// PluginRule awards the points returned by a WebAssembly module, loaded from a
+// .wasm file in the plugins directory and named after the file. Modules are
+// sandboxed: they may not import anything, and run under PluginLimits.
+//
+// A module exports its memory along with:
+//
+//	alloc(size i32) i32         returns a pointer to size bytes for the input
+//	score(ptr i32, len i32) i64 scores the receipt json at ptr, returning
+//	                            the pointer << 32 | length of the json result
+//
+// The json result is {"points": 10, "explanation": "why"}. A plugin that traps,
+// exceeds its limits or returns an invalid result is disabled, and reports
+// the reason in its RuleResult from then on.
+type PluginRule struct {
+	// RuleName identifies the rule in a Breakdown, the name of the file without .wasm
+	RuleName string `json:"name"`
+	// Path is the location of the .wasm file
+	Path string `json:"path"`
+
+	// runtime compiled the module
+	runtime wazero.Runtime
+	// compiled is the compiled module, instantiated for every receipt
+	compiled wazero.CompiledModule
+	// fuel is the most function calls per receipt
+	fuel int
+	// timeout is the longest call per receipt
+	timeout time.Duration
+	// mu guards disabled
+	mu sync.Mutex
+	// disabled is why the plugin was disabled, if it was
+	disabled *PluginStatus
+}
+
+// PluginStatus reports whether a plugin is enabled
+type PluginStatus struct {
+	// Name of the plugin rule
+	Name string `json:"name"`
+	// Enabled is false once the plugin misbehaved
+	Enabled bool `json:"enabled"`
+	// Error is why the plugin was disabled
+	Error string `json:"error,omitempty"`
+	// DisabledAt is when the plugin was disabled
+	DisabledAt *time.Time `json:"disabledAt,omitempty"`
+}
+
+// pluginFuel is the fuel left for a call, stored in its context
+type pluginFuel struct {
+	remaining int
+	exhausted bool
+	cancel    context.CancelFunc
+}
+
+// pluginFuelKey is the context key of the pluginFuel
+type pluginFuelKey struct{}
+
+// fuelListener burns fuel for every function call, stopping the call when it runs out
+var fuelListener = experimental.FunctionListenerFunc(func(ctx context.Context, _ wasmapi.Module, _ wasmapi.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+	fuel, ok := ctx.Value(pluginFuelKey{}).(*pluginFuel)
+	if !ok {
+		return
+	}
+	if fuel.remaining--; fuel.remaining < 0 && !fuel.exhausted {
+		fuel.exhausted = true
+		fuel.cancel()
+	}
+})
+
+// fuelListenerFactory installs fuelListener on every function
+type fuelListenerFactory struct{}
+
+func (fuelListenerFactory) NewFunctionListener(wasmapi.FunctionDefinition) experimental.FunctionListener {
+	return fuelListener
+}
+
+// LoadPlugins compiles every .wasm file in a directory as a PluginRule
+// dir: the plugins directory
+// limits: the resources each plugin may use per receipt
+// Returns: the plugins, sorted by name
+func LoadPlugins(dir string, limits PluginLimits) ([]*PluginRule, error) {
+	if limits.MemoryPages == 0 {
+		limits.MemoryPages = 16
+	}
+	if limits.Fuel == 0 {
+		limits.Fuel = 100000
+	}
+	if limits.Fuel < 0 {
+		return nil, fmt.Errorf("plugin limits have a negative fuel")
+	}
+	timeout := 50 * time.Millisecond
+	if limits.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(limits.Timeout); err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("plugin limits have an invalid timeout %q", limits.Timeout)
+		}
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.wasm"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("invalid plugins directory: %w", err)
+	}
+	sort.Strings(paths)
+
+	ctx := experimental.WithFunctionListenerFactory(context.Background(), fuelListenerFactory{})
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(limits.MemoryPages).
+		WithCloseOnContextDone(true))
+	var plugins []*PluginRule
+	for _, path := range paths {
+		plugin := &PluginRule{
+			RuleName: strings.TrimSuffix(filepath.Base(path), ".wasm"),
+			Path:     path,
+			runtime:  runtime,
+			fuel:     limits.Fuel,
+			timeout:  timeout,
+		}
+		if err := plugin.compile(ctx); err != nil {
+			runtime.Close(ctx)
+			return nil, fmt.Errorf("plugin %q: %w", plugin.RuleName, err)
+		}
+		plugins = append(plugins, plugin)
+	}
+	return plugins, nil
+}
+
+// compile compiles the module and checks its imports and exports
+func (p *PluginRule) compile(ctx context.Context) error {
+	code, err := os.ReadFile(p.Path)
+	if err != nil {
+		return err
+	}
+	if p.compiled, err = p.runtime.CompileModule(ctx, code); err != nil {
+		return err
+	}
+	if imports := p.compiled.ImportedFunctions(); len(imports) > 0 {
+		module, name, _ := imports[0].Import()
+		return fmt.Errorf("imports %s.%s, plugins may not import functions", module, name)
+	}
+	if len(p.compiled.ImportedMemories()) > 0 {
+		return fmt.Errorf("imports memory, plugins must export their memory")
+	}
+	if _, ok := p.compiled.ExportedMemories()["memory"]; !ok {
+		return fmt.Errorf("does not export memory")
+	}
+	i32, i64 := wasmapi.ValueTypeI32, wasmapi.ValueTypeI64
+	exports := p.compiled.ExportedFunctions()
+	for name, signature := range map[string][2][]wasmapi.ValueType{
+		"alloc": {{i32}, {i32}},
+		"score": {{i32, i32}, {i64}},
+	} {
+		function, ok := exports[name]
+		if !ok {
+			return fmt.Errorf("does not export %s", name)
+		}
+		if fmt.Sprint(function.ParamTypes(), function.ResultTypes()) != fmt.Sprint(signature[0], signature[1]) {
+			return fmt.Errorf("exports %s with the wrong signature", name)
+		}
+	}
+	return nil
+}
+
+// Name returns the name of the rule
+func (p *PluginRule) Name() string {
+	return p.RuleName
+}
+
+// Status reports whether the plugin is enabled
+func (p *PluginRule) Status() PluginStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.disabled != nil {
+		return *p.disabled
+	}
+	return PluginStatus{Name: p.RuleName, Enabled: true}
+}
+
+// disable stops the plugin from scoring receipts, keeping the first reason
+func (p *PluginRule) disable(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.disabled != nil {
+		return
+	}
+	now := time.Now().UTC()
+	p.disabled = &PluginStatus{Name: p.RuleName, Error: err.Error(), DisabledAt: &now}
+	log.Printf("plugin %q disabled: %v", p.RuleName, err)
+}
+
+// Score calls the plugin with the Receipt, disabling the plugin if it misbehaves
+func (p *PluginRule) Score(r Receipt) RuleResult {
+	result := RuleResult{Rule: p.RuleName}
+	if status := p.Status(); !status.Enabled {
+		result.Error = "plugin disabled: " + status.Error
+		return result
+	}
+	points, explanation, err := p.call(r)
+	if err != nil {
+		p.disable(err)
+		result.Error = "plugin disabled: " + err.Error()
+		return result
+	}
+	result.Points = points
+	result.Uncapped = points
+	result.Explanation = explanation
+	return result
+}
+
+// call instantiates the module and scores the Receipt with it
+// Returns: the points and explanation returned by the plugin
+func (p *PluginRule) call(r Receipt) (int, string, error) {
+	input, err := json.Marshal(r)
+	if err != nil {
+		return 0, "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	fuel := &pluginFuel{remaining: p.fuel, cancel: cancel}
+	ctx = context.WithValue(ctx, pluginFuelKey{}, fuel)
+	limitErr := func(err error) error {
+		switch {
+		case fuel.exhausted:
+			return fmt.Errorf("plugin ran out of fuel")
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return fmt.Errorf("plugin exceeded its time limit")
+		}
+		return err
+	}
+
+	// a fresh instance for every receipt, so plugins keep no state between receipts
+	module, err := p.runtime.InstantiateModule(ctx, p.compiled, wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		return 0, "", limitErr(err)
+	}
+	defer module.Close(context.Background())
+
+	results, err := module.ExportedFunction("alloc").Call(ctx, uint64(len(input)))
+	if err != nil {
+		return 0, "", limitErr(err)
+	}
+	ptr := uint32(results[0])
+	if !module.Memory().Write(ptr, input) {
+		return 0, "", fmt.Errorf("plugin alloc returned an invalid pointer")
+	}
+	results, err = module.ExportedFunction("score").Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return 0, "", limitErr(err)
+	}
+	outputPtr, outputLen := uint32(results[0]>>32), uint32(results[0])
+	if outputLen > maxPluginOutput {
+		return 0, "", fmt.Errorf("plugin result is longer than %d bytes", maxPluginOutput)
+	}
+	data, ok := module.Memory().Read(outputPtr, outputLen)
+	if !ok {
+		return 0, "", fmt.Errorf("plugin returned an invalid pointer")
+	}
+	var output struct {
+		Points      *int   `json:"points"`
+		Explanation string `json:"explanation"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return 0, "", fmt.Errorf("plugin returned an invalid result: %w", err)
+	}
+	if output.Points == nil || *output.Points < 0 {
+		return 0, "", fmt.Errorf("plugin returned invalid points")
+	}
+	return *output.Points, output.Explanation, nil
+}
+
+// Plugins returns the PluginRules of the RuleProcessor
+func (p *RuleProcessor) Plugins() []*PluginRule {
+	var plugins []*PluginRule
+	for _, rule := range p.rules {
+		if plugin, ok := rule.(*PluginRule); ok {
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+// Close closes the runtimes of the plugins of the RuleProcessor, releasing their
+// compiled modules, for processors that are no longer used such as simulation
+// candidates. The plugins fail to score from then on.
+func (p *RuleProcessor) Close() {
+	closePlugins(p.Plugins())
+}
+
+// closePlugins closes the runtimes the plugins were compiled with
+func closePlugins(plugins []*PluginRule) {
+	closed := map[wazero.Runtime]bool{}
+	for _, plugin := range plugins {
+		if !closed[plugin.runtime] {
+			plugin.runtime.Close(context.Background())
+			closed[plugin.runtime] = true
+		}
+	}
+}
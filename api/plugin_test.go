@@ -0,0 +1,226 @@
+/*
+plugin_test.go contains functions for testing WebAssembly plugin rules, with small
+modules assembled by hand.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPluginRules verifies plugins score receipts, and are disabled when they misbehave
+func TestPluginRules(t *testing.T) {
+	dir := t.TempDir()
+	result := `{"points": 12, "explanation": "partner bonus"}`
+	// traps unless the input starts with {
+	checkInput := []byte{0x41, 0x80, 0x08, 0x2d, 0x00, 0x00, 0x41, 0xfb, 0x00, 0x47, 0x04, 0x40, 0x00, 0x0b}
+	WriteWasm(t, dir, "bonus", false, result, append(checkInput, ReturnResult(result)...))
+	// loops forever without calls
+	WriteWasm(t, dir, "loop", false, "", []byte{0x03, 0x40, 0x0c, 0x00, 0x0b, 0x00, 0x0b})
+	// loops forever calling a function
+	WriteWasm(t, dir, "burn", false, "", []byte{0x03, 0x40, 0x10, 0x02, 0x0c, 0x00, 0x0b, 0x00, 0x0b})
+	// traps
+	WriteWasm(t, dir, "trap", false, "", []byte{0x00, 0x0b})
+	// grows its memory by 100 pages, trapping if it cannot
+	grow := []byte{0x41, 0xe4, 0x00, 0x40, 0x00, 0x41, 0x7f, 0x46, 0x04, 0x40, 0x00, 0x0b}
+	WriteWasm(t, dir, "grow", false, result, append(grow, ReturnResult(result)...))
+	// returns something other than json
+	WriteWasm(t, dir, "garbage", false, "points: 12", ReturnResult("points: 12"))
+
+	processor, err := NewRuleProcessorFromConfig(RuleConfig{
+		Plugins:      dir,
+		PluginLimits: PluginLimits{Fuel: 1000, Timeout: "100ms"},
+	}, nil)
+	assert.NoError(t, err)
+	receipt := ParseReceipt(t, `{"retailer": "Target", "purchaseDate": "2022-01-01", "purchaseTime": "13:01", "total": "1.00", "items": []}`)
+
+	breakdown := processor.Breakdown(receipt)
+	assert.Equal(t, RuleResult{Rule: "bonus", Points: 12, Uncapped: 12, Explanation: "partner bonus"}, FindResult(t, breakdown, "bonus"))
+	expected := map[string]string{
+		"loop":    "plugin disabled: plugin exceeded its time limit",
+		"burn":    "plugin disabled: plugin ran out of fuel",
+		"trap":    "plugin disabled: wasm error: unreachable",
+		"grow":    "plugin disabled: wasm error: unreachable",
+		"garbage": "plugin disabled: plugin returned an invalid result",
+	}
+	for name, expectedErr := range expected {
+		assert.True(t, strings.HasPrefix(FindResult(t, breakdown, name).Error, expectedErr), FindResult(t, breakdown, name).Error)
+		assert.Equal(t, 0, FindResult(t, breakdown, name).Points, name)
+	}
+
+	// disabled plugins are not called again
+	breakdown = processor.Breakdown(receipt)
+	assert.Equal(t, 12, FindResult(t, breakdown, "bonus").Points)
+	for _, plugin := range processor.Plugins() {
+		status := plugin.Status()
+		_, misbehaved := expected[plugin.Name()]
+		assert.Equal(t, !misbehaved, status.Enabled, plugin.Name())
+		if misbehaved {
+			assert.True(t, strings.HasPrefix(FindResult(t, breakdown, plugin.Name()).Error, expected[plugin.Name()]))
+			assert.NotNil(t, status.DisabledAt)
+		}
+	}
+
+	// more memory lets the grow plugin run
+	processor, err = NewRuleProcessorFromConfig(RuleConfig{
+		Plugins:      dir,
+		PluginLimits: PluginLimits{MemoryPages: 200, Fuel: 1000, Timeout: "100ms"},
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 12, FindResult(t, processor.Breakdown(receipt), "grow").Points)
+
+	// closed processors release their plugins, which fail from then on
+	processor.Close()
+	assert.Equal(t, 0, FindResult(t, processor.Breakdown(receipt), "bonus").Points)
+	assert.False(t, processor.Plugins()[0].Status().Enabled)
+}
+
+// TestPluginConfigErrors verifies invalid plugins are rejected when loaded
+func TestPluginConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	WriteWasm(t, dir, "imports", true, "", []byte{0x00, 0x0b})
+	_, err := NewRuleProcessorFromConfig(RuleConfig{Plugins: dir}, nil)
+	assert.EqualError(t, err, `plugin "imports": imports env.host, plugins may not import functions`)
+
+	dir = t.TempDir()
+	os.WriteFile(filepath.Join(dir, "text.wasm"), []byte("not wasm"), 0o644)
+	_, err = NewRuleProcessorFromConfig(RuleConfig{Plugins: dir}, nil)
+	assert.ErrorContains(t, err, `plugin "text": `)
+
+	_, err = NewRuleProcessorFromConfig(RuleConfig{Plugins: filepath.Join(dir, "missing")}, nil)
+	assert.ErrorContains(t, err, "invalid plugins directory")
+	_, err = NewRuleProcessorFromConfig(RuleConfig{Plugins: t.TempDir(), PluginLimits: PluginLimits{Timeout: "soon"}}, nil)
+	assert.ErrorContains(t, err, "invalid timeout")
+}
+
+// TestPluginHandler verifies plugins are only loaded from the server's plugins
+// directory, and their statuses are reported for the active rule set
+func TestPluginHandler(t *testing.T) {
+	dir := t.TempDir()
+	WriteWasm(t, dir, "trap", false, "", []byte{0x00, 0x0b})
+
+	database := &Database{}
+	rulesets := NewRuleSets(database)
+	_, err := rulesets.Register(RuleConfig{})
+	assert.NoError(t, err)
+	router := NewRouter(database, rulesets)
+	recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/plugins", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `[]`, recorder.Body.String())
+
+	// rule sets submitted over the API may not choose the plugins
+	for _, config := range []RuleConfig{{Plugins: dir}, {PluginLimits: PluginLimits{MemoryPages: 65536}}} {
+		body, _ := json.Marshal(config)
+		request := httptest.NewRequest(http.MethodPost, "/rulesets", strings.NewReader(string(body)))
+		request.Header.Set("Content-Type", "application/json")
+		recorder = ProcessRequest(router, request)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "plugins can only be set in the rules file or PLUGINS_DIR")
+		recorder, _ = Simulate(t, router, `{"ruleset": `+string(body)+`}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	}
+
+	// they are compiled with the server's plugins
+	rulesets.UsePlugins(dir, PluginLimits{})
+	request := httptest.NewRequest(http.MethodPost, "/rulesets", strings.NewReader(`{}`))
+	request.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, http.StatusCreated, ProcessRequest(router, request).Code)
+	recorder = ProcessRequest(router, BuildRequest(`{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-01",
+		"purchaseTime": "13:01",
+		"total": "1.00",
+		"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.00"}]
+	}`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/plugins", nil))
+	statuses := []PluginStatus{}
+	json.Unmarshal(recorder.Body.Bytes(), &statuses)
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, "trap", statuses[0].Name)
+	assert.False(t, statuses[0].Enabled)
+	assert.Contains(t, statuses[0].Error, "unreachable")
+}
+
+// WriteWasm assembles a plugin module with an alloc returning 1024, a score with the
+// given body, an empty function 2, one page of memory holding data, and optionally
+// an import, writing it to dir/name.wasm
+func WriteWasm(t *testing.T, dir string, name string, imports bool, data string, score []byte) {
+	section := func(id byte, contents ...[]byte) []byte {
+		var body []byte
+		for _, content := range contents {
+			body = append(body, content...)
+		}
+		return append(append([]byte{id}, Uleb128(uint64(len(body)))...), body...)
+	}
+	text := func(s string) []byte {
+		return append(Uleb128(uint64(len(s))), s...)
+	}
+	code := func(body []byte) []byte {
+		body = append([]byte{0x00}, body...) // no locals
+		return append(Uleb128(uint64(len(body))), body...)
+	}
+	offset := byte(0)
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// types: (i32) i32, (i32 i32) i64, ()
+	module = append(module, section(1, []byte{0x03,
+		0x60, 0x01, 0x7f, 0x01, 0x7f,
+		0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e,
+		0x60, 0x00, 0x00})...)
+	if imports {
+		module = append(module, section(2, []byte{0x01}, text("env"), text("host"), []byte{0x00, 0x02})...)
+		offset = 1
+	}
+	module = append(module, section(3, []byte{0x03, 0x00, 0x01, 0x02})...)
+	module = append(module, section(5, []byte{0x01, 0x00, 0x01})...)
+	module = append(module, section(7, []byte{0x03},
+		text("memory"), []byte{0x02, 0x00},
+		text("alloc"), []byte{0x00, offset},
+		text("score"), []byte{0x00, offset + 1})...)
+	module = append(module, section(10, []byte{0x03},
+		code([]byte{0x41, 0x80, 0x08, 0x0b}),
+		code(score),
+		code([]byte{0x0b}))...)
+	module = append(module, section(11, []byte{0x01, 0x00, 0x41, 0x00, 0x0b}, text(data))...)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name+".wasm"), module, 0o644))
+}
+
+// ReturnResult assembles the end of a score body returning data at offset 0
+func ReturnResult(data string) []byte {
+	return append(append([]byte{0x42}, Sleb128(int64(len(data)))...), 0x0b)
+}
+
+// Uleb128 encodes an unsigned LEB128 integer
+func Uleb128(n uint64) []byte {
+	var encoded []byte
+	for {
+		b := byte(n & 0x7f)
+		if n >>= 7; n != 0 {
+			encoded = append(encoded, b|0x80)
+			continue
+		}
+		return append(encoded, b)
+	}
+}
+
+// Sleb128 encodes a signed LEB128 integer
+func Sleb128(n int64) []byte {
+	var encoded []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 && b&0x40 == 0 || n == -1 && b&0x40 != 0 {
+			return append(encoded, b)
+		}
+		encoded = append(encoded, b|0x80)
+	}
+}
@@ -0,0 +1,369 @@
+/*
+review.go contains the review workflow for receipts needing a human decision,
+and methods for handling reviewer requests
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Review statuses, a Review moves from pending to one of the decided statuses
+const (
+	// PendingReview is the status of a flagged Receipt awaiting review, held at zero points
+	PendingReview = "pending"
+	// ApprovedReview is the status of a Receipt approved to earn its points
+	ApprovedReview = "approved"
+	// RejectedReview is the status of a Receipt rejected to earn zero points
+	RejectedReview = "rejected"
+	// AdjustedReview is the status of a Receipt approved to earn adjusted points
+	AdjustedReview = "adjusted"
+)
+
+// Review flag types, besides the fraud flags
+const (
+	// TotalMismatchFlag marks a receipt whose item prices, less discounts plus taxes
+	// and tip, do not sum to its total
+	TotalMismatchFlag = "total-mismatch"
+	// HighValueFlag marks a receipt with a total above the high value threshold
+	HighValueFlag = "high-value"
+)
+
+// ReviewConfig sets which receipts besides those flagged as fraud need review
+type ReviewConfig struct {
+	// HighValue is the total above which receipts are reviewed, defaults to 500
+	HighValue float64 `json:"highValue,omitempty"`
+	// TotalTolerance is how far the item prices may sum from the total, defaults to 0
+	TotalTolerance float64 `json:"totalTolerance,omitempty"`
+	// SkipTotalCheck turns off the check that the item prices sum to the total
+	SkipTotalCheck bool `json:"skipTotalCheck,omitempty"`
+}
+
+// validate checks the thresholds are not negative
+func (c ReviewConfig) validate() error {
+	if c.HighValue < 0 || c.TotalTolerance < 0 {
+		return fmt.Errorf("review settings must not be negative")
+	}
+	return nil
+}
+
+// Check flags a receipt failing the total consistency check, or over the high value
+// receipt: the Receipt to check
+// Returns: the flags, empty when the Receipt needs no review
+func (c ReviewConfig) Check(receipt Receipt) []FraudFlag {
+	var flags []FraudFlag
+	total := parseCents(receipt.Total)
+	if !c.SkipTotalCheck {
+		var sum int64
+		for _, item := range receipt.Items {
+			sum += parseCents(item.Price)
+		}
+		summed := "items"
+		if receipt.Discounts != nil || receipt.Taxes != nil || receipt.Tip != nil {
+			sum += receiptTax(receipt) + receiptTip(receipt) - receiptDiscount(receipt)
+			summed = "items less discounts plus taxes and tip"
+		}
+		tolerance := int64(math.Round(c.TotalTolerance * 100))
+		if difference := sum - total; difference > tolerance || -difference > tolerance {
+			flags = append(flags, FraudFlag{
+				Type:   TotalMismatchFlag,
+				Reason: fmt.Sprintf("%s sum to %.2f, not the total %.2f", summed, float64(sum)/100, float64(total)/100),
+			})
+		}
+	}
+	highValue := c.HighValue
+	if highValue == 0 {
+		highValue = 500
+	}
+	if float64(total)/100 > highValue {
+		flags = append(flags, FraudFlag{
+			Type:   HighValueFlag,
+			Reason: fmt.Sprintf("total %.2f is over %.2f", float64(total)/100, highValue),
+		})
+	}
+	return flags
+}
+
+// receiptDiscount returns the discounts and coupons taken off the whole Receipt, in cents
+func receiptDiscount(receipt Receipt) int64 {
+	var sum int64
+	if receipt.Discounts != nil {
+		for _, discount := range *receipt.Discounts {
+			sum += parseCents(discount.Amount)
+		}
+	}
+	return sum
+}
+
+// receiptTax returns the sum of the tax lines of a Receipt, in cents
+func receiptTax(receipt Receipt) int64 {
+	var sum int64
+	if receipt.Taxes != nil {
+		for _, tax := range *receipt.Taxes {
+			sum += parseCents(tax.Amount)
+		}
+	}
+	return sum
+}
+
+// receiptTip returns the tip of a Receipt, in cents
+func receiptTip(receipt Receipt) int64 {
+	if receipt.Tip == nil {
+		return 0
+	}
+	return parseCents(*receipt.Tip)
+}
+
+// Review is the review of a Receipt needing a human decision
+type Review struct {
+	// ReceiptId is the id of the flagged Receipt
+	ReceiptId string `json:"receiptId"`
+	// Status is one of pending, approved, rejected or adjusted
+	Status string `json:"status"`
+	// Flags are the reasons the Receipt was flagged
+	Flags []FraudFlag `json:"flags"`
+	// FlaggedAt is when the Receipt was flagged
+	FlaggedAt time.Time `json:"flaggedAt"`
+	// Reviewer is who claimed the review
+	Reviewer string `json:"reviewer,omitempty"`
+	// ClaimedAt is when the review was claimed
+	ClaimedAt *time.Time `json:"claimedAt,omitempty"`
+	// DecidedAt is when the review was decided
+	DecidedAt *time.Time `json:"decidedAt,omitempty"`
+	// Points are the adjusted points of an adjusted review
+	Points *int `json:"points,omitempty"`
+	// History is the audit trail of the review, oldest first
+	History []ReviewEvent `json:"history"`
+}
+
+// ReviewEvent is an entry in the audit trail of a Review
+type ReviewEvent struct {
+	// Action is one of flagged, claimed, commented, approved, rejected or adjusted
+	Action string `json:"action"`
+	// Reviewer is who took the action, empty when flagged
+	Reviewer string `json:"reviewer,omitempty"`
+	// Comment is the reviewer's comment
+	Comment string `json:"comment,omitempty"`
+	// Points are the adjusted points
+	Points *int `json:"points,omitempty"`
+	// At is when the action was taken
+	At time.Time `json:"at"`
+}
+
+// NewReview initializes a pending Review for a flagged Receipt
+// id: the uuid string associated with the Receipt
+// flags: the reasons the Receipt was flagged
+// now: when the Receipt was flagged
+func NewReview(id string, flags []FraudFlag, now time.Time) Review {
+	return Review{
+		ReceiptId: id,
+		Status:    PendingReview,
+		Flags:     flags,
+		FlaggedAt: now,
+		History:   []ReviewEvent{{Action: "flagged", At: now}},
+	}
+}
+
+// ReviewHandler handles the review queue of flagged receipts
+type ReviewHandler struct {
+	// Receipts scores receipts when their review is decided
+	Receipts ReceiptHandler
+	// mu serializes changes to reviews
+	mu *sync.Mutex
+}
+
+// NewReviewHandler initializes ReviewHandler
+// receipts: the handler of the reviewed receipts
+func NewReviewHandler(receipts ReceiptHandler) ReviewHandler {
+	return ReviewHandler{
+		Receipts: receipts,
+		mu:       &sync.Mutex{},
+	}
+}
+
+// GetReviews handles GET requests for the review queue, the reviews with the
+// status query parameter, pending by default, oldest flagged first
+// Response example: {"reviews":[{"id":"7d4d837b-ef5e-47c0-89a9-889657b66eb9","retailer":"Target","status":"pending","flags":[{"type":"duplicate","reason":"same retailer, date, time, total and items as another receipt","receiptId":"0f5c6b7e-5d2a-4e63-a4c8-8e5b1f3f3c11"}],"heldPoints":31,"flaggedAt":"2024-08-20T15:04:05Z"}]}
+func (h *ReviewHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = PendingReview
+	}
+	response := GetReviewsResponse{Reviews: []ReviewSummary{}}
+	for _, review := range h.Receipts.Database.GetReviews() {
+		if review.Status != status {
+			continue
+		}
+		summary := h.summarize(review)
+		summary.History = nil
+		response.Reviews = append(response.Reviews, summary)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetReviewsId handles GET requests for a review, including its audit trail
+// Response example: {"id":"7d4d837b-ef5e-47c0-89a9-889657b66eb9","retailer":"Target","status":"pending","flags":[{"type":"high-value","reason":"total 950.00 is over 500.00"}],"heldPoints":31,"flaggedAt":"2024-08-20T15:04:05Z","history":[{"action":"flagged","at":"2024-08-20T15:04:05Z"}]}
+func (h *ReviewHandler) GetReviewsId(w http.ResponseWriter, r *http.Request) {
+	review, err := h.Receipts.Database.GetReview(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.summarize(review))
+}
+
+// PostReviewsIdClaim handles POST requests for a reviewer to claim a pending review,
+// so no other reviewer decides it
+// Response example: {"id":"7d4d837b-ef5e-47c0-89a9-889657b66eb9","status":"pending","reviewer":"alice","claimedAt":"2024-08-20T16:00:00Z", ...}
+func (h *ReviewHandler) PostReviewsIdClaim(w http.ResponseWriter, r *http.Request) {
+	var request PostReviewsIdClaimRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	h.update(w, r, request.Reviewer, func(review *Review, now time.Time) (int, error) {
+		if review.Status != PendingReview {
+			return http.StatusConflict, fmt.Errorf("Review already %s", review.Status)
+		}
+		if review.Reviewer != "" && review.Reviewer != request.Reviewer {
+			return http.StatusConflict, fmt.Errorf("Review already claimed by %s", review.Reviewer)
+		}
+		review.Reviewer = request.Reviewer
+		review.ClaimedAt = &now
+		review.History = append(review.History, ReviewEvent{Action: "claimed", Reviewer: request.Reviewer, Comment: request.Comment, At: now})
+		return 0, nil
+	})
+}
+
+// PostReviewsIdComments handles POST requests for a reviewer to comment on a review,
+// adding the comment to its audit trail
+// Response example: {"id":"7d4d837b-ef5e-47c0-89a9-889657b66eb9","status":"pending","history":[..., {"action":"commented","reviewer":"alice","comment":"asked the member for a photo","at":"2024-08-20T16:05:00Z"}]}
+func (h *ReviewHandler) PostReviewsIdComments(w http.ResponseWriter, r *http.Request) {
+	var request PostReviewsIdCommentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	h.update(w, r, request.Reviewer, func(review *Review, now time.Time) (int, error) {
+		review.History = append(review.History, ReviewEvent{Action: "commented", Reviewer: request.Reviewer, Comment: request.Comment, At: now})
+		return 0, nil
+	})
+}
+
+// PostReviewsIdDecision handles POST requests for the reviewer who claimed a review
+// to approve, reject or adjust the points of the Receipt, finalizing its Score
+// Response example: {"id":"7d4d837b-ef5e-47c0-89a9-889657b66eb9","status":"adjusted","points":20,"reviewer":"alice", ...}
+func (h *ReviewHandler) PostReviewsIdDecision(w http.ResponseWriter, r *http.Request) {
+	var request PostReviewsIdDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if (request.Decision == AdjustedReview) != (request.Points != nil) {
+		http.Error(w, "Points are required to adjust, and only to adjust", http.StatusBadRequest)
+		return
+	}
+	h.update(w, r, request.Reviewer, func(review *Review, now time.Time) (int, error) {
+		if review.Status != PendingReview {
+			return http.StatusConflict, fmt.Errorf("Review already %s", review.Status)
+		}
+		if review.Reviewer != request.Reviewer {
+			return http.StatusConflict, fmt.Errorf("Review must be claimed by the reviewer deciding it")
+		}
+		review.Status = request.Decision
+		review.DecidedAt = &now
+		review.Points = request.Points
+		review.History = append(review.History, ReviewEvent{
+			Action:   request.Decision,
+			Reviewer: request.Reviewer,
+			Comment:  request.Comment,
+			Points:   request.Points,
+			At:       now,
+		})
+		return 0, nil
+	})
+}
+
+// update applies a change to the review of the receipt id in a request, storing it,
+// rescoring the Receipt with its pinned rule set once decided, and writing the review
+// reviewer: who is changing the review
+// change: changes the review, returning an error status and error to reject the change
+func (h *ReviewHandler) update(w http.ResponseWriter, r *http.Request, reviewer string, change func(review *Review, now time.Time) (int, error)) {
+	if reviewer == "" {
+		http.Error(w, "Reviewer is required", http.StatusBadRequest)
+		return
+	}
+	id := chi.URLParam(r, "id")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	review, err := h.Receipts.Database.GetReview(id)
+	if err != nil {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	before := review
+	before.History = append([]ReviewEvent(nil), review.History...)
+	if status, err := change(&review, time.Now().UTC()); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	h.Receipts.Database.PutReview(id, review)
+	h.Receipts.Database.RecordAudit(reviewer, ReviewUpdated, "review", id, before, review)
+	if review.Status != PendingReview {
+		h.finalize(id)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.summarize(review))
+}
+
+// finalize rescores a decided Receipt with the rule set it is pinned to
+func (h *ReviewHandler) finalize(id string) {
+	receipt, err := h.Receipts.Database.GetReceipt(id)
+	if err != nil {
+		return
+	}
+	ruleset := h.Receipts.RuleSets.Active()
+	if score, err := h.Receipts.Database.GetScore(id); err == nil {
+		if pinned, err := h.Receipts.RuleSets.Get(score.Version); err == nil {
+			ruleset = pinned
+		}
+	}
+	h.Receipts.score(id, receipt, ruleset, true)
+}
+
+// summarize converts a Review to the json review, with the points of the
+// Receipt's pinned Score
+func (h *ReviewHandler) summarize(review Review) ReviewSummary {
+	receipt, _ := h.Receipts.Database.GetReceipt(review.ReceiptId)
+	summary := ReviewSummary{
+		Id:        review.ReceiptId,
+		Retailer:  receipt.Retailer,
+		MemberId:  receipt.MemberId,
+		Status:    review.Status,
+		Flags:     review.Flags,
+		FlaggedAt: review.FlaggedAt,
+		Reviewer:  review.Reviewer,
+		ClaimedAt: review.ClaimedAt,
+		DecidedAt: review.DecidedAt,
+		History:   review.History,
+	}
+	if score, err := h.Receipts.Database.GetScore(review.ReceiptId); err == nil {
+		summary.HeldPoints = score.Breakdown.HeldPoints
+		if review.Status != PendingReview {
+			summary.Points = &score.Breakdown.Points
+		}
+	}
+	return summary
+}
@@ -0,0 +1,191 @@
+/*
+review_test.go contains functions for testing the review workflow.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReviewConfig verifies receipts failing the total consistency check or
+// over the high value are flagged
+func TestReviewConfig(t *testing.T) {
+	mismatch := ParseReceipt(t, `{"retailer": "Target", "purchaseDate": "2022-01-01", "purchaseTime": "13:01", "total": "10.05", "items": [
+		{"shortDescription": "Pepsi", "price": "10.00"}
+	]}`)
+	expensive := ParseReceipt(t, `{"retailer": "Target", "purchaseDate": "2022-01-01", "purchaseTime": "13:01", "total": "600.00", "items": [
+		{"shortDescription": "Television", "price": "600.00"}
+	]}`)
+	itemized := ParseReceipt(t, `{"retailer": "Target", "purchaseDate": "2022-01-01", "purchaseTime": "13:01", "total": "10.05", "items": [
+		{"shortDescription": "Pepsi", "price": "10.00"}
+	], "discounts": [{"code": "SAVE1", "amount": "1.00"}], "taxes": [{"amount": "0.80"}], "tip": "0.25"}`)
+	tipped := itemized
+	tip := "0.50"
+	tipped.Tip = &tip
+	tests := []struct {
+		name     string
+		config   ReviewConfig
+		receipt  Receipt
+		expected []FraudFlag
+	}{
+		{
+			name:     "total mismatch",
+			receipt:  mismatch,
+			expected: []FraudFlag{{Type: TotalMismatchFlag, Reason: "items sum to 10.00, not the total 10.05"}},
+		},
+		{
+			name:    "itemized total",
+			receipt: itemized,
+		},
+		{
+			name:     "itemized total mismatch",
+			receipt:  tipped,
+			expected: []FraudFlag{{Type: TotalMismatchFlag, Reason: "items less discounts plus taxes and tip sum to 10.30, not the total 10.05"}},
+		},
+		{
+			name:    "within tolerance",
+			config:  ReviewConfig{TotalTolerance: 0.05},
+			receipt: mismatch,
+		},
+		{
+			name:    "total check skipped",
+			config:  ReviewConfig{SkipTotalCheck: true},
+			receipt: mismatch,
+		},
+		{
+			name:     "high value",
+			receipt:  expensive,
+			expected: []FraudFlag{{Type: HighValueFlag, Reason: "total 600.00 is over 500.00"}},
+		},
+		{
+			name:    "higher high value",
+			config:  ReviewConfig{HighValue: 1000},
+			receipt: expensive,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.config.Check(tt.receipt))
+		})
+	}
+
+	_, err := NewRuleProcessorFromConfig(RuleConfig{Review: ReviewConfig{HighValue: -1}}, nil)
+	assert.ErrorContains(t, err, "must not be negative")
+}
+
+// TestReviewWorkflow verifies reviews are claimed, commented on and decided,
+// and scoring finalizes with the decision
+func TestReviewWorkflow(t *testing.T) {
+	router := GetRouter()
+	post := func(path string, body string) (*httptest.ResponseRecorder, *ReviewSummary) {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := ProcessRequest(router, request)
+		review := &ReviewSummary{}
+		json.Unmarshal(recorder.Body.Bytes(), &review)
+		return recorder, review
+	}
+	submit := func() string {
+		recorder := ProcessRequest(router, BuildRequest(`{
+			"retailer": "Target",
+			"purchaseDate": "2022-01-02",
+			"purchaseTime": "13:13",
+			"total": "600.00",
+			"items": [{"shortDescription": "Television", "price": "600.00"}]
+		}`))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		id := &PostReceiptsProcessResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &id)
+		return id.Id
+	}
+
+	// retailer-name 6, round-dollar 50, quarter-multiple 25
+	id := submit()
+	breakdown := GetBreakdown(t, router, "/receipts/"+id+"/breakdown")
+	assert.Equal(t, 0, breakdown.Points)
+	assert.Equal(t, 81, breakdown.HeldPoints)
+	assert.Equal(t, PendingReview, breakdown.Review)
+
+	recorder, review := post("/reviews/"+id+"/claim", `{"reviewer": "alice"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "alice", review.Reviewer)
+	assert.NotNil(t, review.ClaimedAt)
+
+	recorder, _ = post("/reviews/"+id+"/claim", `{"reviewer": "bob"}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	recorder, _ = post("/reviews/"+id+"/decision", `{"reviewer": "bob", "decision": "approved"}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	recorder, _ = post("/reviews/"+id+"/comments", `{"reviewer": "bob", "comment": "looks like a real purchase"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder, _ = post("/reviews/"+id+"/decision", `{"reviewer": "alice", "decision": "adjusted"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder, _ = post("/reviews/"+id+"/decision", `{"reviewer": "alice", "decision": "approved", "points": 5}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder, _ = post("/reviews/"+id+"/decision", `{"reviewer": "alice", "decision": "maybe"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder, _ = post("/reviews/missing/decision", `{"reviewer": "alice", "decision": "approved"}`)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder, review = post("/reviews/"+id+"/decision", `{"reviewer": "alice", "decision": "approved", "comment": "matches the photo"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, ApprovedReview, review.Status)
+	assert.Equal(t, 81, *review.Points)
+	assert.NotNil(t, review.DecidedAt)
+	var actions []string
+	for _, event := range review.History {
+		actions = append(actions, event.Action+" "+event.Reviewer+" "+event.Comment)
+	}
+	assert.Equal(t, []string{
+		"flagged  ",
+		"claimed alice ",
+		"commented bob looks like a real purchase",
+		"approved alice matches the photo",
+	}, actions)
+
+	breakdown = GetBreakdown(t, router, "/receipts/"+id+"/breakdown")
+	assert.Equal(t, 81, breakdown.Points)
+	assert.False(t, breakdown.Held)
+	assert.Equal(t, ApprovedReview, breakdown.Review)
+	recorder, _ = post("/reviews/"+id+"/decision", `{"reviewer": "alice", "decision": "rejected"}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// rejected and adjusted receipts
+	rejected, adjusted := submit(), submit()
+	post("/reviews/"+rejected+"/claim", `{"reviewer": "alice"}`)
+	post("/reviews/"+rejected+"/decision", `{"reviewer": "alice", "decision": "rejected"}`)
+	assert.Equal(t, 0, GetBreakdown(t, router, "/receipts/"+rejected+"/points").Points)
+	post("/reviews/"+adjusted+"/claim", `{"reviewer": "bob"}`)
+	recorder, review = post("/reviews/"+adjusted+"/decision", `{"reviewer": "bob", "decision": "adjusted", "points": 20}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 20, *review.Points)
+	assert.Equal(t, 20, GetBreakdown(t, router, "/receipts/"+adjusted+"/points").Points)
+
+	// the queue lists reviews by status, without their audit trails
+	queue := func(query string) []ReviewSummary {
+		recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/reviews"+query, nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		response := &GetReviewsResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return response.Reviews
+	}
+	assert.Empty(t, queue(""))
+	approved := queue("?status=approved")
+	assert.Len(t, approved, 1)
+	assert.Equal(t, id, approved[0].Id)
+	assert.Nil(t, approved[0].History)
+	assert.Len(t, queue("?status=rejected"), 1)
+
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/reviews/"+adjusted, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	review = &ReviewSummary{}
+	json.Unmarshal(recorder.Body.Bytes(), &review)
+	assert.Equal(t, AdjustedReview, review.Status)
+	assert.Len(t, review.History, 3)
+}
@@ -4,6 +4,7 @@ rule.go contains methods for calculating the points earned from a Receipt
 package api
 
 import (
+	"fmt"
 	"math"
 	"regexp"
 	"strconv"
@@ -22,56 +23,200 @@ import (
 //	}
 type Rule func(r Receipt) int
 
+// Scorer is implemented by anything that can award points for a Receipt,
+// reporting them as a RuleResult
+type Scorer interface {
+	// Name identifies the Scorer in a Breakdown
+	Name() string
+	// Score calculates the points earned from a given Receipt
+	Score(r Receipt) RuleResult
+}
+
+// RuleResult is the points earned from a single rule
+type RuleResult struct {
+	// Rule is the name of the rule
+	Rule string `json:"rule"`
+	// Points earned from the rule, after caps
+	Points int `json:"points"`
+	// Uncapped is the points earned from the rule before caps
+	Uncapped int `json:"uncapped"`
+	// Items are the indexes of the items that triggered the rule, if any
+	Items []int `json:"items,omitempty"`
+	// Explanation is why the rule awarded its points, if the rule explains itself
+	Explanation string `json:"explanation,omitempty"`
+	// Error reports why the rule failed to score, awarding no points
+	Error string `json:"error,omitempty"`
+	// Suppressed is set when a rule group suppressed the rule, awarding no points
+	Suppressed bool `json:"suppressed,omitempty"`
+}
+
+// Breakdown is the points earned for a Receipt along with the points
+// earned from each rule
+type Breakdown struct {
+	// Points is the total points earned, after caps
+	Points int `json:"points"`
+	// Uncapped is the total points earned before caps
+	Uncapped int `json:"uncapped"`
+	// Capped lists the rule, receipt and member caps that limited Points, rule caps as rule:<name>
+	Capped []string `json:"capped,omitempty"`
+	// Rules contains the result of each rule, in the order they were applied
+	Rules []RuleResult `json:"rules"`
+	// Groups contains the result of each rule group, in the order they were defined
+	Groups []GroupResult `json:"groups,omitempty"`
+	// Tier is the tier of the member, whose multiplier was applied to the points
+	Tier string `json:"tier,omitempty"`
+	// Multiplier is the multiplier of the Tier
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// TierBonus is the points added by the Multiplier
+	TierBonus int `json:"tierBonus,omitempty"`
+	// Held is set when the Receipt is held at zero points pending review
+	Held bool `json:"held,omitempty"`
+	// HeldPoints is the points the Receipt earns if approved
+	HeldPoints int `json:"heldPoints,omitempty"`
+	// Review is the status of the Receipt's review, if it was flagged
+	Review string `json:"review,omitempty"`
+}
+
+// NamedRule is a Scorer for a Rule, reporting its points under RuleName
+type NamedRule struct {
+	RuleName string
+	Rule     Rule
+}
+
+// Name returns the name of the rule
+func (n NamedRule) Name() string {
+	return n.RuleName
+}
+
+// Score applies the Rule to the given Receipt
+func (n NamedRule) Score(r Receipt) RuleResult {
+	points := n.Rule(r)
+	return RuleResult{Rule: n.RuleName, Points: points, Uncapped: points}
+}
+
 // RuleProcessor uses rules to determine the points earned from a receipt
 type RuleProcessor struct {
-	// rules contains each Rule function for determining total points
-	rules []Rule
+	// rules contains each Scorer for determining total points
+	rules []Scorer
+	// caps limits the points earned
+	caps Caps
+	// groups combine the points of rules, in the order they were defined
+	groups []*RuleGroup
+	// tiers multiply the points members earn
+	tiers Tiers
+	// fraud tunes the checks that hold suspicious receipts for review
+	fraud FraudConfig
+	// review sets which other receipts are held for review
+	review ReviewConfig
 }
 
 // Points sums the earned points from all rules for a given Receipt
 func (p *RuleProcessor) Points(receipt Receipt) int {
-	points := 0
+	return p.Breakdown(receipt).Points
+}
+
+// Breakdown applies all rules to a given Receipt, keeping the result of each.
+// Rules evaluate the amounts converted to the base currency with the recorded exchange rate.
+// Rule caps, then rule groups, then the receipt cap are applied, member caps are not.
+func (p *RuleProcessor) Breakdown(receipt Receipt) Breakdown {
+	receipt = ConvertReceipt(receipt)
+	breakdown := Breakdown{Rules: make([]RuleResult, 0, len(p.rules))}
+	for _, rule := range p.rules {
+		result := rule.Score(receipt)
+		if limit := p.caps.Rules[result.Rule]; limit > 0 && result.Points > limit {
+			result.Points = limit
+			breakdown.Capped = append(breakdown.Capped, "rule:"+result.Rule)
+		}
+		breakdown.Points += result.Points
+		breakdown.Uncapped += result.Uncapped
+		breakdown.Rules = append(breakdown.Rules, result)
+	}
+	p.applyGroups(&breakdown)
+	if p.caps.Receipt > 0 && breakdown.Points > p.caps.Receipt {
+		breakdown.Points = p.caps.Receipt
+		breakdown.Capped = append(breakdown.Capped, "receipt")
+	}
+	return breakdown
+}
+
+// MemberBreakdown applies all rules to a given Receipt, multiplies the points by the
+// member's tier multiplier, then limits the points by the member caps for the
+// points the member already earned in the same periods
+// receipt: the Receipt to score
+// tier: the name of the member's tier, the lowest tier when empty
+// earned: the points earned from the member's other receipts
+// Returns: the Breakdown for receipt
+func (p *RuleProcessor) MemberBreakdown(receipt Receipt, tier string, earned []Earned) Breakdown {
+	breakdown := p.Breakdown(receipt)
+	if receipt.MemberId != nil {
+		p.tiers.Tier(tier).apply(&breakdown)
+	}
+	p.caps.Member.apply(&breakdown, receipt.PurchaseDate.Time, earned)
+	return breakdown
+}
+
+// hasRule reports whether a rule with the given name is in use
+func (p *RuleProcessor) hasRule(name string) bool {
 	for _, rule := range p.rules {
-		points += rule(receipt)
+		if rule.Name() == name {
+			return true
+		}
 	}
-	return points
+	return false
+}
+
+// AddRule appends a rule to the RuleProcessor
+// rule: the Scorer to add, its name must not already be in use
+func (p *RuleProcessor) AddRule(rule Scorer) error {
+	if p.hasRule(rule.Name()) {
+		return fmt.Errorf("duplicate rule name %q", rule.Name())
+	}
+	p.rules = append(p.rules, rule)
+	return nil
 }
 
 // NewRuleProcessor initializes a RuleProcessor, defining all the rules for
 // calculating total points earned for a Receipt
 func NewRuleProcessor() RuleProcessor {
+	return newRuleProcessor(time.UTC)
+}
+
+// newRuleProcessor initializes a RuleProcessor with the default rules
+// location: the time zone of receipts without a time zone or UTC offset
+func newRuleProcessor(location *time.Location) RuleProcessor {
 	return RuleProcessor{
-		rules: []Rule{
+		tiers: DefaultTiers(),
+		rules: []Scorer{
 			// One point for every alphanumeric character in the retailer name.
-			Rule(func(r Receipt) int {
+			NamedRule{RuleName: "retailer-name", Rule: func(r Receipt) int {
 				re := regexp.MustCompile(`[a-zA-Z0-9]`)
 				return len(re.FindAllString(r.Retailer, -1))
-			}),
+			}},
 			// 50 points if the total is a round dollar amount with no cents.
-			Rule(func(r Receipt) int {
+			NamedRule{RuleName: "round-dollar", Rule: func(r Receipt) int {
 				amount, _ := strconv.ParseFloat(r.Total, 64)
 				cents := int(amount * 100)
 				if cents%100 == 0 {
 					return 50
 				}
 				return 0
-			}),
+			}},
 			// 25 points if the total is a multiple of `0.25`.
-			Rule(func(r Receipt) int {
+			NamedRule{RuleName: "quarter-multiple", Rule: func(r Receipt) int {
 				amount, _ := strconv.ParseFloat(r.Total, 64)
 				cents := int(amount * 100)
 				if cents%25 == 0 {
 					return 25
 				}
 				return 0
-			}),
+			}},
 			// 5 points for every two items on the receipt.
-			Rule(func(r Receipt) int {
+			NamedRule{RuleName: "item-pairs", Rule: func(r Receipt) int {
 				return (len(r.Items) / 2) * 5
-			}),
+			}},
 			// If the trimmed length of the item description is a multiple of 3,
 			// multiply the price by `0.2` and round up to the nearest integer.
-			Rule(func(r Receipt) int {
+			NamedRule{RuleName: "item-description", Rule: func(r Receipt) int {
 				points := 0
 				for _, item := range r.Items {
 					if len(strings.TrimSpace(item.ShortDescription))%3 == 0 {
@@ -80,24 +225,123 @@ func NewRuleProcessor() RuleProcessor {
 					}
 				}
 				return points
-			}),
+			}},
 			// 6 points if the day in the purchase date is odd.
-			Rule(func(r Receipt) int {
+			NamedRule{RuleName: "odd-day", Rule: func(r Receipt) int {
 				if r.PurchaseDate.Day()%2 != 0 {
 					return 6
 				}
 				return 0
-			}),
+			}},
 			// 10 points if the time of purchase is after 14:00 and before 16:00.
-			Rule(func(r Receipt) int {
-				start := time.Date(0, time.January, 1, 14, 0, 0, 0, time.UTC)
-				end := time.Date(0, time.January, 1, 16, 0, 0, 0, time.UTC)
-				purchaseTime, _ := time.Parse("15:04", r.PurchaseTime)
-				if purchaseTime.After(start) && purchaseTime.Before(end) {
-					return 10
-				}
-				return 0
-			}),
+			mustCompile(&TimeWindowRule{RuleName: "afternoon", Start: "14:00", End: "16:00", Points: 10}, location),
 		},
 	}
 }
+
+// NewRuleProcessorFromConfig initializes a RuleProcessor with the default rules
+// followed by the rules defined in a RuleConfig
+// config: the configured rules
+// members: looks up the member of a receipt for member rules, may be nil
+func NewRuleProcessorFromConfig(config RuleConfig, members MemberDirectory) (RuleProcessor, error) {
+	location := time.UTC
+	if config.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(config.TimeZone); err != nil {
+			return RuleProcessor{}, fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+	processor := newRuleProcessor(location)
+	if len(config.Tiers) > 0 {
+		if err := config.Tiers.validate(); err != nil {
+			return processor, err
+		}
+		processor.tiers = config.Tiers
+	}
+	if err := config.Caps.validate(); err != nil {
+		return processor, err
+	}
+	processor.caps = config.Caps
+	if _, err := config.Fraud.withDefaults(); err != nil {
+		return processor, err
+	}
+	processor.fraud = config.Fraud
+	if err := config.Review.validate(); err != nil {
+		return processor, err
+	}
+	processor.review = config.Review
+	for i := range config.ItemRules {
+		rule := &config.ItemRules[i]
+		if err := rule.Compile(config.Catalog); err != nil {
+			return processor, err
+		}
+		if err := processor.AddRule(rule); err != nil {
+			return processor, err
+		}
+	}
+	for i := range config.TimeRules {
+		rule := &config.TimeRules[i]
+		if err := rule.Compile(location); err != nil {
+			return processor, err
+		}
+		if err := processor.AddRule(rule); err != nil {
+			return processor, err
+		}
+	}
+	calendar := DefaultCalendar()
+	if config.Calendar != "" {
+		var err error
+		if calendar, err = LoadCalendar(config.Calendar); err != nil {
+			return processor, err
+		}
+	}
+	for i := range config.CalendarRules {
+		rule := &config.CalendarRules[i]
+		if err := rule.Compile(calendar, members); err != nil {
+			return processor, err
+		}
+		if err := processor.AddRule(rule); err != nil {
+			return processor, err
+		}
+	}
+	for i := range config.ExpressionRules {
+		rule := &config.ExpressionRules[i]
+		if err := rule.Compile(location); err != nil {
+			return processor, err
+		}
+		if err := processor.AddRule(rule); err != nil {
+			return processor, err
+		}
+	}
+	if config.Plugins != "" {
+		plugins, err := LoadPlugins(config.Plugins, config.PluginLimits)
+		if err != nil {
+			return processor, err
+		}
+		for _, plugin := range plugins {
+			if err := processor.AddRule(plugin); err != nil {
+				closePlugins(plugins)
+				return processor, err
+			}
+		}
+	}
+	if err := processor.compileGroups(config.Groups); err != nil {
+		processor.Close()
+		return processor, err
+	}
+	for name := range config.Caps.Rules {
+		if !processor.hasRule(name) {
+			processor.Close()
+			return processor, fmt.Errorf("cap references unknown rule %q", name)
+		}
+	}
+	return processor, nil
+}
+
+// mustCompile compiles a default TimeWindowRule, which cannot fail
+func mustCompile(rule *TimeWindowRule, location *time.Location) *TimeWindowRule {
+	if err := rule.Compile(location); err != nil {
+		panic(err)
+	}
+	return rule
+}
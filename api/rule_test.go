@@ -0,0 +1,198 @@
+/*
+rule_test.go contains functions for testing the RuleProcessor and configurable rules.
+*/
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ParseReceipt is a helper for unmarshaling a Receipt json
+func ParseReceipt(t *testing.T, receipt string) Receipt {
+	var r Receipt
+	if err := json.Unmarshal([]byte(receipt), &r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// FindResult is a helper for finding the RuleResult of a named rule in a Breakdown
+func FindResult(t *testing.T, breakdown Breakdown, name string) RuleResult {
+	for _, result := range breakdown.Rules {
+		if result.Rule == name {
+			return result
+		}
+	}
+	t.Fatalf("rule %s not found in breakdown", name)
+	return RuleResult{}
+}
+
+// TestItemRules verifies item rules match by keyword, pattern and catalog sku,
+// respect their cap, and report the items that triggered them
+func TestItemRules(t *testing.T) {
+	processor, err := NewRuleProcessorFromConfig(RuleConfig{
+		Catalog: Catalog{
+			{Sku: "DEW-12", Name: "Mountain Dew 12 Pack", Descriptions: []string{"Mountain Dew 12PK"}},
+		},
+		ItemRules: []ItemRule{
+			{RuleName: "gatorade", Keyword: "GATORADE", Points: 20, Cap: 50},
+			{RuleName: "twelve-pack", Pattern: `(?i)12-?PK$`, Points: 3},
+			{RuleName: "dew", Sku: "DEW-12", Points: 7},
+		},
+	}, nil)
+	assert.NoError(t, err)
+
+	breakdown := processor.Breakdown(ParseReceipt(t, `{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-01",
+		"purchaseTime": "13:01",
+		"items": [
+			{"shortDescription": "Gatorade", "price": "2.25"},
+			{"shortDescription": "  mountain dew   12pk ", "price": "6.49"},
+			{"shortDescription": "Gatorade Lemon Lime", "price": "2.25"},
+			{"shortDescription": "Klarbrunn 12-PK", "price": "12.00"},
+			{"shortDescription": "Gatorade", "price": "2.25"}
+		],
+		"total": "25.24"
+	}`))
+
+	gatorade := FindResult(t, breakdown, "gatorade")
+	assert.Equal(t, 50, gatorade.Points)
+	assert.Equal(t, []int{0, 2, 4}, gatorade.Items)
+
+	twelvePack := FindResult(t, breakdown, "twelve-pack")
+	assert.Equal(t, 6, twelvePack.Points)
+	assert.Equal(t, []int{1, 3}, twelvePack.Items)
+
+	dew := FindResult(t, breakdown, "dew")
+	assert.Equal(t, 7, dew.Points)
+	assert.Equal(t, []int{1}, dew.Items)
+
+	total := 0
+	for _, result := range breakdown.Rules {
+		total += result.Points
+	}
+	assert.Equal(t, total, breakdown.Points)
+}
+
+// TestItemRuleConfigErrors verifies invalid item rules are rejected
+func TestItemRuleConfigErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        ItemRule
+		expectedErr string
+	}{
+		{
+			name:        "missing name",
+			rule:        ItemRule{Keyword: "gatorade"},
+			expectedErr: "missing a name",
+		},
+		{
+			name:        "no matcher",
+			rule:        ItemRule{RuleName: "empty", Points: 1},
+			expectedErr: "exactly one of keyword, pattern or sku",
+		},
+		{
+			name:        "two matchers",
+			rule:        ItemRule{RuleName: "both", Keyword: "a", Pattern: "b"},
+			expectedErr: "exactly one of keyword, pattern or sku",
+		},
+		{
+			name:        "bad pattern",
+			rule:        ItemRule{RuleName: "bad", Pattern: "("},
+			expectedErr: "invalid pattern",
+		},
+		{
+			name:        "unknown sku",
+			rule:        ItemRule{RuleName: "unknown", Sku: "NOPE"},
+			expectedErr: "unknown sku",
+		},
+		{
+			name:        "duplicate name",
+			rule:        ItemRule{RuleName: "odd-day", Keyword: "a"},
+			expectedErr: "duplicate rule name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRuleProcessorFromConfig(RuleConfig{ItemRules: []ItemRule{tt.rule}}, nil)
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
+// TestCaps verifies rule, receipt and member caps limit points, keeping the uncapped points
+func TestCaps(t *testing.T) {
+	// 6 retailer-name + 50 round-dollar + 25 quarter-multiple + 6 odd-day = 87
+	receipt := func(date string) Receipt {
+		return ParseReceipt(t, `{
+			"retailer": "Target",
+			"purchaseDate": "`+date+`",
+			"purchaseTime": "13:01",
+			"items": [
+				{"shortDescription": "Gatorade", "price": "2.00"}
+			],
+			"total": "2.00",
+			"memberId": "member-1"
+		}`)
+	}
+
+	processor, err := NewRuleProcessorFromConfig(RuleConfig{
+		Caps: Caps{Rules: map[string]int{"round-dollar": 20}},
+	}, nil)
+	assert.NoError(t, err)
+	breakdown := processor.Breakdown(receipt("2022-01-01"))
+	assert.Equal(t, 57, breakdown.Points)
+	assert.Equal(t, 87, breakdown.Uncapped)
+	assert.Equal(t, RuleResult{Rule: "round-dollar", Points: 20, Uncapped: 50}, FindResult(t, breakdown, "round-dollar"))
+	assert.Equal(t, []string{"rule:round-dollar"}, breakdown.Capped)
+
+	// a rule cap of zero is no cap, like the receipt and member caps
+	processor, err = NewRuleProcessorFromConfig(RuleConfig{
+		Caps: Caps{Rules: map[string]int{"round-dollar": 0}},
+	}, nil)
+	assert.NoError(t, err)
+	breakdown = processor.Breakdown(receipt("2022-01-01"))
+	assert.Equal(t, 87, breakdown.Points)
+	assert.Empty(t, breakdown.Capped)
+
+	processor, err = NewRuleProcessorFromConfig(RuleConfig{Caps: Caps{Receipt: 40}}, nil)
+	assert.NoError(t, err)
+	breakdown = processor.Breakdown(receipt("2022-01-01"))
+	assert.Equal(t, 40, breakdown.Points)
+	assert.Equal(t, 87, breakdown.Uncapped)
+	assert.Equal(t, []string{"receipt"}, breakdown.Capped)
+
+	processor, err = NewRuleProcessorFromConfig(RuleConfig{
+		Caps: Caps{Member: MemberCaps{Day: 100, Week: 150, Month: 200}},
+	}, nil)
+	assert.NoError(t, err)
+	earned := []Earned{}
+	expected := []struct {
+		date   string
+		points int
+		capped []string
+	}{
+		{"2022-01-03", 87, nil},                      // Monday, 87 of 100 for the day
+		{"2022-01-03", 13, []string{"member-day"}},   // 13 remaining for the day
+		{"2022-01-05", 50, []string{"member-week"}},  // 50 remaining for the week
+		{"2022-01-10", 50, []string{"member-month"}}, // next week, 50 remaining for the month
+		{"2022-01-12", 0, []string{"member-month"}},  // none remaining for the month
+		{"2022-02-01", 87, nil},                      // next month
+	}
+	for _, e := range expected {
+		r := receipt(e.date)
+		breakdown = processor.MemberBreakdown(r, "", earned)
+		assert.Equal(t, e.points, breakdown.Points, e.date)
+		assert.Equal(t, e.capped, breakdown.Capped, e.date)
+		earned = append(earned, Earned{Date: r.PurchaseDate.Time, Points: breakdown.Points})
+	}
+
+	_, err = NewRuleProcessorFromConfig(RuleConfig{Caps: Caps{Rules: map[string]int{"unknown": 1}}}, nil)
+	assert.ErrorContains(t, err, "unknown rule")
+	_, err = NewRuleProcessorFromConfig(RuleConfig{Caps: Caps{Receipt: -1}}, nil)
+	assert.ErrorContains(t, err, "must not be negative")
+}
@@ -0,0 +1,220 @@
+/*
+ruleset.go contains the versioned rule sets receipts are scored with
+*/
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RuleSet is an immutable version of the rules. Receipts are pinned to the
+// version they were scored with, so changing the rules never rescores them.
+type RuleSet struct {
+	// Version identifies the RuleSet, e.g. v2
+	Version string `json:"version"`
+	// Digest is the sha256 of Config
+	Digest string `json:"digest"`
+	// CreatedAt is when the RuleSet was registered
+	CreatedAt time.Time `json:"createdAt"`
+	// Config is the json RuleConfig the RuleSet was registered with
+	Config json.RawMessage `json:"config"`
+	// Processor applies the rules
+	Processor RuleProcessor `json:"-"`
+}
+
+// Score is the Breakdown a Receipt was scored with, pinned to a RuleSet version
+type Score struct {
+	// Version of the RuleSet used
+	Version string
+	// Breakdown of the points earned
+	Breakdown Breakdown
+	// ScoredAt is when the Receipt was scored
+	ScoredAt time.Time
+}
+
+// RuleSets is the registry of RuleSet versions.
+// The latest version is active and used to score new receipts.
+type RuleSets struct {
+	// mu guards versions
+	mu sync.RWMutex
+	// versions contains every RuleSet, versions[0] is v1
+	versions []*RuleSet
+	// members looks up the member of a receipt for member rules
+	members MemberDirectory
+	// plugins is the server's plugins directory, loaded into every rule set
+	plugins string
+	// pluginLimits limits the resources each of the server's plugins may use
+	pluginLimits PluginLimits
+}
+
+// NewRuleSets initializes an empty RuleSets registry
+// members: looks up the member of a receipt for member rules, may be nil
+func NewRuleSets(members MemberDirectory) *RuleSets {
+	return &RuleSets{members: members}
+}
+
+// UsePlugins sets the server's plugins directory, from the rules file or PLUGINS_DIR,
+// loaded into every rule set compiled from then on. Rule sets submitted over the API
+// may not name a plugins directory, so only the server chooses which .wasm files run.
+// dir: the plugins directory, empty for no plugins
+// limits: the resources each plugin may use
+func (s *RuleSets) UsePlugins(dir string, limits PluginLimits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plugins, s.pluginLimits = dir, limits
+}
+
+// Compile compiles a RuleConfig with the server's plugins, unless it names its own
+// plugins directory, e.g. the rules file
+// config: the rules added to the default rules
+// Returns: the RuleProcessor, to Close when it is not registered
+func (s *RuleSets) Compile(config RuleConfig) (RuleProcessor, error) {
+	s.mu.RLock()
+	if config.Plugins == "" {
+		config.Plugins, config.PluginLimits = s.plugins, s.pluginLimits
+	}
+	s.mu.RUnlock()
+	return NewRuleProcessorFromConfig(config, s.members)
+}
+
+// Register adds a RuleSet for a RuleConfig as the next version and activates it
+// config: the rules added to the default rules
+// Returns: the registered RuleSet
+func (s *RuleSets) Register(config RuleConfig) (*RuleSet, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	// compile a copy, so the RuleSet does not share state with the caller
+	var compiled RuleConfig
+	if err := json.Unmarshal(data, &compiled); err != nil {
+		return nil, err
+	}
+	processor, err := s.Compile(compiled)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ruleset := &RuleSet{
+		Version:   fmt.Sprintf("v%d", len(s.versions)+1),
+		Digest:    hex.EncodeToString(digest[:]),
+		CreatedAt: time.Now().UTC(),
+		Config:    data,
+		Processor: processor,
+	}
+	s.versions = append(s.versions, ruleset)
+	return ruleset, nil
+}
+
+// Get retrieves a RuleSet by version
+// version: the version of the RuleSet, e.g. v2
+// Returns: the RuleSet for the given version
+func (s *RuleSets) Get(version string) (*RuleSet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, ruleset := range s.versions {
+		if ruleset.Version == version {
+			return ruleset, nil
+		}
+	}
+	return nil, fmt.Errorf("ruleset %s not found", version)
+}
+
+// Active returns the latest RuleSet
+func (s *RuleSets) Active() *RuleSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.versions[len(s.versions)-1]
+}
+
+// List returns every RuleSet, oldest first
+func (s *RuleSets) List() []*RuleSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*RuleSet(nil), s.versions...)
+}
+
+// RuleSetHandler handles the access and registration of rule sets
+type RuleSetHandler struct {
+	// Database is the audit log storage
+	Database *Database
+	// RuleSets is the rule set registry
+	RuleSets *RuleSets
+}
+
+// NewRuleSetHandler initializes RuleSetHandler
+// database: the audit log storage
+// rulesets: the rule set registry
+func NewRuleSetHandler(database *Database, rulesets *RuleSets) RuleSetHandler {
+	return RuleSetHandler{
+		Database: database,
+		RuleSets: rulesets,
+	}
+}
+
+// GetRulesets handles GET requests to list every rule set version
+// Response example: [{"version":"v1","digest":"44136fa3...","createdAt":"2024-08-20T05:11:44Z","config":{}}]
+func (h *RuleSetHandler) GetRulesets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.RuleSets.List())
+}
+
+// GetRulesetsVersion handles GET requests for a rule set version
+// Response example: {"version":"v1","digest":"44136fa3...","createdAt":"2024-08-20T05:11:44Z","config":{}}
+func (h *RuleSetHandler) GetRulesetsVersion(w http.ResponseWriter, r *http.Request) {
+	ruleset, err := h.RuleSets.Get(chi.URLParam(r, "version"))
+	if err != nil {
+		http.Error(w, "Ruleset not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ruleset)
+}
+
+// PostRulesets handles POST requests to register a RuleConfig as the next,
+// active, rule set version
+// Response example: {"version":"v2","digest":"5c2b0b1e...","createdAt":"2024-08-20T05:11:44Z","config":{}}
+func (h *RuleSetHandler) PostRulesets(w http.ResponseWriter, r *http.Request) {
+	config, err := DecodeRequestRuleConfig(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	before := h.RuleSets.Active()
+	ruleset, err := h.RuleSets.Register(config)
+	if err != nil {
+		http.Error(w, "Invalid ruleset: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.Database.RecordAudit(actor(r), RuleSetCreated, "ruleset", ruleset.Version, before, ruleset)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ruleset)
+}
+
+// GetPlugins handles GET requests to list the plugins of the active rule set,
+// and whether they were disabled for misbehaving
+// Response example: [{"name":"partner-bonus","enabled":false,"error":"plugin ran out of fuel","disabledAt":"2024-08-20T05:11:44Z"}]
+func (h *RuleSetHandler) GetPlugins(w http.ResponseWriter, r *http.Request) {
+	ruleset := h.RuleSets.Active()
+	statuses := []PluginStatus{}
+	for _, plugin := range ruleset.Processor.Plugins() {
+		statuses = append(statuses, plugin.Status())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(statuses)
+}
@@ -0,0 +1,165 @@
+/*
+ruleset_test.go contains functions for testing rule set versions and score pinning.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRuleSetVersions verifies receipts stay pinned to the rule set version they
+// were scored with, can be previewed under another version, and rescored
+func TestRuleSetVersions(t *testing.T) {
+
+	router := GetRouter()
+
+	recorder := ProcessRequest(router, BuildRequest(`{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"total": "1.25",
+		"items": [
+			{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}
+		]
+	}`))
+	receiptId := &PostReceiptsProcessResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &receiptId)
+
+	// register v2, awarding 10 more points for pepsi
+	request := httptest.NewRequest(http.MethodPost, "/rulesets", strings.NewReader(`{
+		"itemRules": [{"name": "pepsi", "keyword": "pepsi", "points": 10}]
+	}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	ruleset := &RuleSet{}
+	json.Unmarshal(recorder.Body.Bytes(), &ruleset)
+	assert.Equal(t, "v2", ruleset.Version)
+	assert.Len(t, ruleset.Digest, 64)
+
+	// pinned to v1
+	assert.Equal(t, 31, GetBreakdown(t, router, "/receipts/"+receiptId.Id+"/points").Points)
+	breakdown := GetBreakdown(t, router, "/receipts/"+receiptId.Id+"/breakdown")
+	assert.Equal(t, "v1", breakdown.Ruleset)
+	assert.Equal(t, 31, breakdown.Points)
+
+	// preview under v2 without rescoring
+	assert.Equal(t, 41, GetBreakdown(t, router, "/receipts/"+receiptId.Id+"/points?ruleset=v2").Points)
+	assert.Equal(t, "v2", GetBreakdown(t, router, "/receipts/"+receiptId.Id+"/breakdown?ruleset=v2").Ruleset)
+	assert.Equal(t, 31, GetBreakdown(t, router, "/receipts/"+receiptId.Id+"/points").Points)
+
+	// rescore with the active version
+	request = httptest.NewRequest(http.MethodPost, "/receipts/"+receiptId.Id+"/rescore", nil)
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	breakdown = &GetReceiptsIdBreakdownResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &breakdown)
+	assert.Equal(t, "v2", breakdown.Ruleset)
+	assert.Equal(t, 41, GetBreakdown(t, router, "/receipts/"+receiptId.Id+"/points").Points)
+
+	// rescore with an older version
+	request = httptest.NewRequest(http.MethodPost, "/receipts/"+receiptId.Id+"/rescore?ruleset=v1", nil)
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 31, GetBreakdown(t, router, "/receipts/"+receiptId.Id+"/points").Points)
+
+	// unknown versions
+	request = httptest.NewRequest(http.MethodGet, "/receipts/"+receiptId.Id+"/points?ruleset=v9", nil)
+	assert.Equal(t, http.StatusNotFound, ProcessRequest(router, request).Code)
+	request = httptest.NewRequest(http.MethodPost, "/receipts/"+receiptId.Id+"/rescore?ruleset=v9", nil)
+	assert.Equal(t, http.StatusNotFound, ProcessRequest(router, request).Code)
+	request = httptest.NewRequest(http.MethodGet, "/rulesets/v9", nil)
+	assert.Equal(t, http.StatusNotFound, ProcessRequest(router, request).Code)
+	request = httptest.NewRequest(http.MethodGet, "/receipts/"+receiptId.Id+"/points?ruleset=latest", nil)
+	assert.Equal(t, http.StatusBadRequest, ProcessRequest(router, request).Code)
+
+	// every version is listed
+	request = httptest.NewRequest(http.MethodGet, "/rulesets", nil)
+	recorder = ProcessRequest(router, request)
+	rulesets := []RuleSet{}
+	json.Unmarshal(recorder.Body.Bytes(), &rulesets)
+	assert.Equal(t, []string{"v1", "v2"}, []string{rulesets[0].Version, rulesets[1].Version})
+	config := RuleConfig{}
+	json.Unmarshal(rulesets[1].Config, &config)
+	assert.Equal(t, "pepsi", config.ItemRules[0].Keyword)
+
+	// invalid rules are not registered
+	request = httptest.NewRequest(http.MethodPost, "/rulesets", strings.NewReader(`{
+		"itemRules": [{"name": "pepsi", "pattern": "(", "points": 10}]
+	}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid pattern")
+	request = httptest.NewRequest(http.MethodPost, "/rulesets", strings.NewReader(`{"unknown": true}`))
+	request.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, http.StatusBadRequest, ProcessRequest(router, request).Code)
+
+	// only bundled calendars are read for rule sets submitted over the API
+	for _, calendar := range []string{"/etc/passwd", "calendars/../go.mod", "../calendars/us.json"} {
+		request = httptest.NewRequest(http.MethodPost, "/rulesets", strings.NewReader(`{"calendar": "`+calendar+`"}`))
+		request.Header.Set("Content-Type", "application/json")
+		recorder = ProcessRequest(router, request)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, calendar)
+		assert.Contains(t, recorder.Body.String(), "is not a bundled calendar", calendar)
+	}
+
+	// expression limits are capped, as receipts are scored one at a time
+	request = httptest.NewRequest(http.MethodPost, "/rulesets", strings.NewReader(`{
+		"expressionRules": [{"name": "slow", "expression": "count(items, count(items, true) > 0)", "maxSteps": 1000000000, "timeout": "1h"}]
+	}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "has a maxSteps over 100000")
+}
+
+// GetBreakdown is a helper for fetching points or a breakdown through the router
+func GetBreakdown(t *testing.T, router http.Handler, path string) *GetReceiptsIdBreakdownResponse {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code, path)
+	breakdown := &GetReceiptsIdBreakdownResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &breakdown)
+	return breakdown
+}
+
+// TestMemberCapsRescore verifies member caps only count the receipts submitted
+// before a receipt, so rescoring an earlier receipt keeps its points
+func TestMemberCapsRescore(t *testing.T) {
+	receipts := NewReceiptHandler(&Database{})
+	_, err := receipts.RuleSets.Register(RuleConfig{Fraud: FraudConfig{Disabled: true}})
+	assert.NoError(t, err)
+	router := NewReceiptRouter(receipts)
+	ids := []string{}
+	// 31 then 37 points, without caps
+	for _, date := range []string{"2022-01-02", "2022-01-03"} {
+		recorder := ProcessRequest(router, BuildRequest(`{
+			"memberId": "alice",
+			"retailer": "Target",
+			"purchaseDate": "`+date+`",
+			"purchaseTime": "13:13",
+			"total": "1.25",
+			"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}]
+		}`))
+		response := PostReceiptsProcessResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		ids = append(ids, response.Id)
+	}
+
+	_, err = receipts.RuleSets.Register(RuleConfig{Fraud: FraudConfig{Disabled: true}, Caps: Caps{Member: MemberCaps{Month: 40}}})
+	assert.NoError(t, err)
+	for i, points := range []int{31, 9} {
+		recorder := ProcessRequest(router, httptest.NewRequest(http.MethodPost, "/receipts/"+ids[i]+"/rescore", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		response := GetReceiptsIdBreakdownResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, points, response.Points, ids[i])
+	}
+}
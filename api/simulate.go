@@ -0,0 +1,233 @@
+/*
+simulate.go contains methods for simulating a candidate rule set against receipts
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+)
+
+// maxBuckets is the most buckets in the points distribution of a simulation
+const maxBuckets = 100
+
+// simulated is a Receipt scored with its current and the candidate rules
+type simulated struct {
+	// change identifies the Receipt, along with its points
+	change ReceiptChange
+	// old is the current Score
+	old Score
+	// new is the candidate Score
+	new Score
+}
+
+// PostRulesSimulate handles POST requests to compare the points earned with a
+// candidate rule set to the current points. Nothing is registered or rescored.
+// Response example: {"receipts":1,"old":{"total":31,"mean":31,"min":31,"max":31},"new":{"total":41,"mean":41,"min":41,"max":41},"distribution":[{"from":25,"to":50,"old":1,"new":1}],"rules":[{"rule":"pepsi","old":0,"new":10,"delta":10}],"changes":[{"index":0,"retailer":"Target","ruleset":"v1","old":31,"new":41,"delta":10}]}
+func (h *ReceiptHandler) PostRulesSimulate(w http.ResponseWriter, r *http.Request) {
+	var request PostRulesSimulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if request.Receipts != nil && request.Filter != nil {
+		http.Error(w, "Invalid request payload: receipts and filter are exclusive", http.StatusBadRequest)
+		return
+	}
+	config, err := DecodeRequestRuleConfig(bytes.NewReader(request.Ruleset))
+	if err != nil {
+		http.Error(w, "Invalid ruleset: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	processor, err := h.RuleSets.Compile(config)
+	if err != nil {
+		http.Error(w, "Invalid ruleset: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer processor.Close()
+	candidate := &RuleSet{Version: "candidate", Processor: processor}
+
+	var receipts []simulated
+	if request.Receipts != nil {
+		active := h.RuleSets.Active()
+		for i, receipt := range request.Receipts {
+			if err := validateReceipt(receipt); err != nil {
+				http.Error(w, fmt.Sprintf("receipts[%d]: %s", i, err), http.StatusBadRequest)
+				return
+			}
+			index := i
+			receipts = append(receipts, simulated{
+				change: ReceiptChange{Index: &index, Retailer: receipt.Retailer},
+				old:    h.score("", receipt, active, false),
+				new:    h.score("", receipt, candidate, false),
+			})
+		}
+	} else {
+		filter := SimulationFilter{}
+		if request.Filter != nil {
+			filter = *request.Filter
+		}
+		if filter.Ruleset != nil {
+			if _, err := h.RuleSets.Get(*filter.Ruleset); err != nil {
+				http.Error(w, "Ruleset not found", http.StatusNotFound)
+				return
+			}
+		}
+		for _, id := range h.Database.GetReceiptIds() {
+			receipt, err := h.Database.GetReceipt(id)
+			if err != nil || !filter.Matches(receipt) {
+				continue
+			}
+			old, err := h.Database.GetScore(id)
+			if err != nil {
+				old = h.score(id, receipt, h.RuleSets.Active(), false)
+			}
+			if filter.Ruleset != nil && old.Version != *filter.Ruleset {
+				continue
+			}
+			receipts = append(receipts, simulated{
+				change: ReceiptChange{Id: id, Retailer: receipt.Retailer},
+				old:    old,
+				new:    h.score(id, receipt, candidate, false),
+			})
+		}
+	}
+
+	top, bucketSize := 10, 25
+	if request.Top != nil {
+		top = *request.Top
+	}
+	if request.BucketSize != nil {
+		bucketSize = *request.BucketSize
+	}
+	response := simulate(receipts, top, bucketSize)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Matches checks whether a Receipt is selected by the SimulationFilter
+// receipt: the Receipt to check
+// Returns: whether every field of the filter matches
+func (f SimulationFilter) Matches(receipt Receipt) bool {
+	if f.Retailer != nil && receipt.Retailer != *f.Retailer {
+		return false
+	}
+	if f.MemberId != nil && (receipt.MemberId == nil || *receipt.MemberId != *f.MemberId) {
+		return false
+	}
+	if f.From != nil && receipt.PurchaseDate.Time.Before(f.From.Time) {
+		return false
+	}
+	if f.To != nil && receipt.PurchaseDate.Time.After(f.To.Time) {
+		return false
+	}
+	return true
+}
+
+// simulate compares the current and candidate points of the simulated receipts
+// receipts: the receipts scored with the current and candidate rules
+// top: the number of receipts with the largest changes to return
+// bucketSize: the width of the points distribution buckets, widened when more than maxBuckets are needed
+// Returns: the comparison
+func simulate(receipts []simulated, top int, bucketSize int) PostRulesSimulateResponse {
+	response := PostRulesSimulateResponse{
+		Receipts:     len(receipts),
+		Distribution: []PointsBucket{},
+		Rules:        []RuleDelta{},
+		Changes:      []ReceiptChange{},
+	}
+	var old, new []int
+	rules := map[string]*RuleDelta{}
+	for _, receipt := range receipts {
+		old = append(old, receipt.old.Breakdown.Points)
+		new = append(new, receipt.new.Breakdown.Points)
+		for _, result := range receipt.old.Breakdown.Rules {
+			ruleDelta(&response, rules, result.Rule).Old += result.Points
+		}
+		for _, result := range receipt.new.Breakdown.Rules {
+			ruleDelta(&response, rules, result.Rule).New += result.Points
+		}
+		change := receipt.change
+		change.Ruleset = receipt.old.Version
+		change.Old = receipt.old.Breakdown.Points
+		change.New = receipt.new.Breakdown.Points
+		change.Delta = change.New - change.Old
+		if change.Delta != 0 {
+			response.Changes = append(response.Changes, change)
+		}
+	}
+	response.Old = summarize(old)
+	response.New = summarize(new)
+	for i := range response.Rules {
+		response.Rules[i] = *rules[response.Rules[i].Rule]
+		response.Rules[i].Delta = response.Rules[i].New - response.Rules[i].Old
+	}
+
+	// buckets from 0 up to the most points, old or new, widened so there are
+	// at most maxBuckets however many points a receipt earns
+	if len(receipts) > 0 {
+		most := max(response.Old.Max, response.New.Max, 0)
+		width := max(bucketSize, most/maxBuckets+1)
+		for i := 0; i <= most/width; i++ {
+			from := i * width
+			to := math.MaxInt
+			if from <= math.MaxInt-width {
+				to = from + width
+			}
+			response.Distribution = append(response.Distribution, PointsBucket{From: from, To: to})
+		}
+		for i := range receipts {
+			response.Distribution[max(old[i], 0)/width].Old++
+			response.Distribution[max(new[i], 0)/width].New++
+		}
+	}
+
+	// largest changes first, ties in receipt order
+	sort.SliceStable(response.Changes, func(i, j int) bool {
+		return abs(response.Changes[i].Delta) > abs(response.Changes[j].Delta)
+	})
+	if len(response.Changes) > top {
+		response.Changes = response.Changes[:top]
+	}
+	return response
+}
+
+// ruleDelta finds the RuleDelta of a rule, adding it in first seen order
+func ruleDelta(response *PostRulesSimulateResponse, rules map[string]*RuleDelta, rule string) *RuleDelta {
+	if _, ok := rules[rule]; !ok {
+		rules[rule] = &RuleDelta{Rule: rule}
+		response.Rules = append(response.Rules, RuleDelta{Rule: rule})
+	}
+	return rules[rule]
+}
+
+// summarize summarizes the points earned for receipts
+func summarize(points []int) PointsSummary {
+	summary := PointsSummary{}
+	for i, p := range points {
+		summary.Total += p
+		if i == 0 || p < summary.Min {
+			summary.Min = p
+		}
+		if i == 0 || p > summary.Max {
+			summary.Max = p
+		}
+	}
+	if len(points) > 0 {
+		summary.Mean = float64(summary.Total) / float64(len(points))
+	}
+	return summary
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
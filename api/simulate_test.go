@@ -0,0 +1,161 @@
+/*
+simulate_test.go contains functions for testing the simulation of candidate rule sets.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRuleSimulation verifies candidate rules are compared to the current points
+// of stored and given receipts, without persisting anything
+func TestRuleSimulation(t *testing.T) {
+
+	router := GetRouter()
+
+	// 31 points, 41 with the candidate rules
+	recorder := ProcessRequest(router, BuildRequest(`{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"total": "1.25",
+		"items": [
+			{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}
+		]
+	}`))
+	target := &PostReceiptsProcessResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &target)
+
+	// 39 points, 59 with the candidate rules
+	recorder = ProcessRequest(router, BuildRequest(`{
+		"retailer": "Walgreens",
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "08:13",
+		"total": "2.50",
+		"items": [
+			{"shortDescription": "Pepsi - 12-oz", "price": "1.25"},
+			{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}
+		]
+	}`))
+	walgreens := &PostReceiptsProcessResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &walgreens)
+
+	candidate := `"ruleset": {"itemRules": [{"name": "pepsi", "keyword": "pepsi", "points": 10}]}`
+
+	// every stored receipt
+	recorder, response := Simulate(t, router, `{`+candidate+`}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 2, response.Receipts)
+	assert.Equal(t, PointsSummary{Total: 70, Mean: 35, Min: 31, Max: 39}, response.Old)
+	assert.Equal(t, PointsSummary{Total: 100, Mean: 50, Min: 41, Max: 59}, response.New)
+	assert.Equal(t, []PointsBucket{
+		{From: 0, To: 25, Old: 0, New: 0},
+		{From: 25, To: 50, Old: 2, New: 1},
+		{From: 50, To: 75, Old: 0, New: 1},
+	}, response.Distribution)
+	assert.Contains(t, response.Rules, RuleDelta{Rule: "pepsi", Old: 0, New: 30, Delta: 30})
+	assert.Contains(t, response.Rules, RuleDelta{Rule: "retailer-name", Old: 15, New: 15, Delta: 0})
+	assert.Equal(t, []ReceiptChange{
+		{Id: walgreens.Id, Retailer: "Walgreens", Ruleset: "v1", Old: 39, New: 59, Delta: 20},
+		{Id: target.Id, Retailer: "Target", Ruleset: "v1", Old: 31, New: 41, Delta: 10},
+	}, response.Changes)
+
+	// nothing is persisted
+	assert.Equal(t, 31, GetBreakdown(t, router, "/receipts/"+target.Id+"/points").Points)
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/rulesets", nil))
+	rulesets := []RuleSet{}
+	json.Unmarshal(recorder.Body.Bytes(), &rulesets)
+	assert.Len(t, rulesets, 1)
+
+	// filtered, with the top change
+	_, response = Simulate(t, router, `{`+candidate+`, "filter": {"retailer": "Target", "ruleset": "v1"}}`)
+	assert.Equal(t, 1, response.Receipts)
+	_, response = Simulate(t, router, `{`+candidate+`, "filter": {"from": "2022-01-03"}}`)
+	assert.Equal(t, 0, response.Receipts)
+	assert.Empty(t, response.Distribution)
+	_, response = Simulate(t, router, `{`+candidate+`, "top": 1}`)
+	assert.Len(t, response.Changes, 1)
+	assert.Equal(t, walgreens.Id, response.Changes[0].Id)
+
+	// given receipts
+	_, response = Simulate(t, router, `{`+candidate+`, "receipts": [{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"total": "1.25",
+		"items": [
+			{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}
+		]
+	}]}`)
+	index := 0
+	assert.Equal(t, []ReceiptChange{
+		{Index: &index, Retailer: "Target", Ruleset: "v1", Old: 31, New: 41, Delta: 10},
+	}, response.Changes)
+
+	// invalid requests
+	recorder, _ = Simulate(t, router, `{"ruleset": {"itemRules": [{"name": "bad", "pattern": "("}]}}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid pattern")
+	recorder, _ = Simulate(t, router, `{"ruleset": {"unknown": true}}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder, _ = Simulate(t, router, `{`+candidate+`, "receipts": [], "filter": {}}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder, _ = Simulate(t, router, `{`+candidate+`, "filter": {"ruleset": "v9"}}`)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder, _ = Simulate(t, router, `{`+candidate+`, "receipts": [{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"timeZone": "Mars/Olympus_Mons",
+		"total": "1.25",
+		"items": [
+			{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}
+		]
+	}]}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "receipts[0]: Invalid timeZone")
+}
+
+// TestRuleSimulationHugeTotal verifies the points distribution of a receipt earning
+// a huge number of points is widened to at most maxBuckets buckets
+func TestRuleSimulationHugeTotal(t *testing.T) {
+	router := GetRouter()
+	recorder, response := Simulate(t, router, `{"ruleset": {}, "receipts": [{
+		"retailer": "Target",
+		"purchaseDate": "2022-01-02",
+		"purchaseTime": "13:13",
+		"total": "999999999999999999.99",
+		"items": [
+			{"shortDescription": "Gum", "price": "999999999999999999.99"}
+		]
+	}]}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.LessOrEqual(t, len(response.Distribution), maxBuckets)
+	last := response.Distribution[len(response.Distribution)-1]
+	assert.Greater(t, response.New.Max, 1000000)
+	assert.Greater(t, last.To, response.New.Max)
+	old, new := 0, 0
+	for _, bucket := range response.Distribution {
+		old += bucket.Old
+		new += bucket.New
+	}
+	assert.Equal(t, 1, old)
+	assert.Equal(t, 1, new)
+}
+
+// Simulate is a helper for simulating candidate rules through the router
+func Simulate(t *testing.T, router http.Handler, body string) (*httptest.ResponseRecorder, *PostRulesSimulateResponse) {
+	request := httptest.NewRequest(http.MethodPost, "/rules/simulate", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	response := &PostRulesSimulateResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder, response
+}
@@ -0,0 +1,165 @@
+/*
+stream.go contains the Server-Sent Events stream of processed and scored receipts
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StreamEvent is an event in the receipt stream
+type StreamEvent struct {
+	// Id is the position of the event in the stream, from 1, sent as the SSE id
+	Id int64
+	// Type is receipt.processed or receipt.scored, sent as the SSE event
+	Type string
+	// Data describes the Receipt, sent as the SSE data
+	Data ReceiptEvent
+}
+
+// ReceiptStream keeps the latest receipt events in a bounded buffer, and wakes
+// subscribers when events are published
+type ReceiptStream struct {
+	// mu guards the fields below
+	mu sync.Mutex
+	// buffer holds the latest events, oldest first
+	buffer []StreamEvent
+	// capacity is the most events kept in buffer
+	capacity int
+	// lastId is the id of the latest event
+	lastId int64
+	// subscribers are woken when an event is published
+	subscribers map[chan struct{}]bool
+}
+
+// NewReceiptStream initializes an empty ReceiptStream
+// capacity: the most events kept for resuming
+func NewReceiptStream(capacity int) *ReceiptStream {
+	return &ReceiptStream{
+		capacity:    capacity,
+		subscribers: map[chan struct{}]bool{},
+	}
+}
+
+// Publish adds an event to the stream, dropping the oldest event when the buffer is full
+// event: the event type
+// data: describes the Receipt
+func (s *ReceiptStream) Publish(event string, data ReceiptEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastId++
+	s.buffer = append(s.buffer, StreamEvent{Id: s.lastId, Type: event, Data: data})
+	if len(s.buffer) > s.capacity {
+		s.buffer = append([]StreamEvent(nil), s.buffer[len(s.buffer)-s.capacity:]...)
+	}
+	for wake := range s.subscribers {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Since returns the buffered events after an event id, starting from the oldest
+// buffered event when events after the id were dropped
+// id: the id of the last event seen, 0 for every buffered event
+func (s *ReceiptStream) Since(id int64) []StreamEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []StreamEvent
+	for _, event := range s.buffer {
+		if event.Id > id {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// LastId returns the id of the latest event, 0 before the first event
+func (s *ReceiptStream) LastId() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastId
+}
+
+// subscribe returns a channel woken when events are published
+func (s *ReceiptStream) subscribe() chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wake := make(chan struct{}, 1)
+	s.subscribers[wake] = true
+	return wake
+}
+
+// unsubscribe stops waking a subscribed channel
+func (s *ReceiptStream) unsubscribe(wake chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers, wake)
+}
+
+// GetReceiptsStream handles GET requests for a Server-Sent Events stream of
+// processed and scored receipts, optionally only for the retailer or memberId
+// query parameters. Clients resume after the event in the Last-Event-ID header,
+// from the buffered events, or from the first buffered event when the id is from
+// before the server restarted, otherwise only new events are sent.
+// Response example: id: 42\nevent: receipt.scored\ndata: {"receiptId":"7d4d837b-...","retailer":"Target","ruleset":"v1","points":31}\n\n
+func (h *ReceiptHandler) GetReceiptsStream(w http.ResponseWriter, r *http.Request) {
+	retailer := normalizeRetailer(r.URL.Query().Get("retailer"))
+	memberId := r.URL.Query().Get("memberId")
+	lastId := h.Stream.LastId()
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		var err error
+		if lastId, err = strconv.ParseInt(header, 10, 64); err != nil || lastId < 0 {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		// ids restart from zero with the server, so a later id is from before a
+		// restart, and every buffered event is new to the client
+		if lastId > h.Stream.LastId() {
+			lastId = 0
+		}
+	}
+	wake := h.Stream.subscribe()
+	defer h.Stream.unsubscribe(wake)
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	controller.Flush()
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		for _, event := range h.Stream.Since(lastId) {
+			lastId = event.Id
+			if retailer != "" && normalizeRetailer(event.Data.Retailer) != retailer {
+				continue
+			}
+			if memberId != "" && (event.Data.MemberId == nil || *event.Data.MemberId != memberId) {
+				continue
+			}
+			data, _ := json.Marshal(event.Data)
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data); err != nil {
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-wake:
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
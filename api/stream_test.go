@@ -0,0 +1,135 @@
+/*
+stream_test.go contains functions for testing the Server-Sent Events stream of receipts.
+*/
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReceiptStreamBuffer verifies the buffer keeps only the latest events
+func TestReceiptStreamBuffer(t *testing.T) {
+	stream := NewReceiptStream(2)
+	assert.Equal(t, int64(0), stream.LastId())
+	for _, retailer := range []string{"Target", "Walgreens", "Costco"} {
+		stream.Publish(ReceiptProcessedEvent, ReceiptEvent{Retailer: retailer})
+	}
+	assert.Equal(t, int64(3), stream.LastId())
+	ids := func(events []StreamEvent) []int64 {
+		var ids []int64
+		for _, event := range events {
+			ids = append(ids, event.Id)
+		}
+		return ids
+	}
+	assert.Equal(t, []int64{2, 3}, ids(stream.Since(0)))
+	assert.Equal(t, []int64{3}, ids(stream.Since(2)))
+	assert.Empty(t, stream.Since(3))
+}
+
+// Stream connects to the receipt stream, sending the events received on the returned channel
+// until the test ends
+func Stream(t *testing.T, server *httptest.Server, query string, lastEventId string) <-chan StreamEvent {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/receipts/stream"+query, nil)
+	if lastEventId != "" {
+		request.Header.Set("Last-Event-ID", lastEventId)
+	}
+	response, err := http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	events := make(chan StreamEvent, 100)
+	go func() {
+		defer response.Body.Close()
+		scanner := bufio.NewScanner(response.Body)
+		event := StreamEvent{}
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				event.Id, _ = strconv.ParseInt(strings.TrimPrefix(line, "id: "), 10, 64)
+			case strings.HasPrefix(line, "event: "):
+				event.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data)
+			case line == "" && event.Id > 0:
+				events <- event
+				event = StreamEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+// TestReceiptsStream verifies processed and scored receipts are streamed, filtered
+// by retailer, and resumed after the Last-Event-ID
+func TestReceiptsStream(t *testing.T) {
+	server := httptest.NewServer(GetRouter())
+	// closed after the streams are disconnected
+	t.Cleanup(server.Close)
+	next := func(events <-chan StreamEvent) StreamEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("no event streamed")
+			return StreamEvent{}
+		}
+	}
+	submit := func(retailer string) string {
+		response, err := http.Post(server.URL+"/receipts/process", "application/json", strings.NewReader(`{
+			"retailer": "`+retailer+`",
+			"purchaseDate": "2022-01-02",
+			"purchaseTime": "13:13",
+			"total": "1.25",
+			"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}]
+		}`))
+		assert.NoError(t, err)
+		defer response.Body.Close()
+		id := &PostReceiptsProcessResponse{}
+		json.NewDecoder(response.Body).Decode(&id)
+		return id.Id
+	}
+
+	target := Stream(t, server, "?retailer=target", "")
+	submit("Walgreens")
+	id := submit("Target")
+	processed := next(target)
+	assert.Equal(t, StreamEvent{Id: 3, Type: ReceiptProcessedEvent, Data: ReceiptEvent{ReceiptId: id, Retailer: "Target"}}, processed)
+	scored := next(target)
+	assert.Equal(t, int64(4), scored.Id)
+	assert.Equal(t, ReceiptScoredEvent, scored.Type)
+	assert.Equal(t, "v1", scored.Data.Ruleset)
+	assert.Equal(t, 31, *scored.Data.Points)
+
+	// resumed from the buffer, then live
+	resumed := Stream(t, server, "", "1")
+	for _, expected := range []int64{2, 3, 4} {
+		assert.Equal(t, expected, next(resumed).Id)
+	}
+	submit("Costco")
+	assert.Equal(t, "Costco", next(resumed).Data.Retailer)
+
+	// an id from before a restart resumes from the first buffered event
+	restarted := Stream(t, server, "", "1000")
+	assert.Equal(t, int64(1), next(restarted).Id)
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/receipts/stream", nil)
+	request.Header.Set("Last-Event-ID", "latest")
+	response, err := http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
@@ -0,0 +1,251 @@
+/*
+textreceipt.go contains the parser of plain-text receipts, such as POS exports,
+extracting receipts with configurable layout templates
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout is a template for extracting a Receipt from a plain-text receipt layout.
+// Retailer, Date, Time and Total are searched for in the whole text, Item is matched
+// against each line, each with a named group for the value extracted, e.g.
+// `(?i)^\s*total\s+\$?(?P<total>\d+\.\d{2})`.
+type Layout struct {
+	// Name of the layout, reported when a receipt does not match it
+	Name string `json:"name"`
+	// Match identifies receipts in the layout, the layout is tried for every receipt when empty
+	Match string `json:"match,omitempty"`
+	// Retailer finds the retailer group, the first line is the retailer when empty
+	Retailer string `json:"retailer,omitempty"`
+	// Date finds the date group
+	Date string `json:"date"`
+	// DateFormats are the Go layouts the date is parsed with, e.g. 01/02/2006
+	DateFormats []string `json:"dateFormats,omitempty"`
+	// Time finds the time group
+	Time string `json:"time"`
+	// TimeFormats are the Go layouts the time is parsed with, e.g. 3:04 PM
+	TimeFormats []string `json:"timeFormats,omitempty"`
+	// Item matches an item line, with description and price groups
+	Item string `json:"item"`
+	// Total finds the total group
+	Total string `json:"total"`
+	// Skip matches lines that are never items, e.g. subtotal, tax and payment lines
+	Skip string `json:"skip,omitempty"`
+}
+
+// DefaultLayouts returns a layout for receipts with the retailer on the first line,
+// a date and time, one item per line with the price at the end, and a total line
+func DefaultLayouts() []Layout {
+	return []Layout{
+		{
+			Name:        "columns",
+			Date:        `\b(?P<date>\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{4}|\d{1,2}/\d{1,2}/\d{2}|[A-Z][a-z]{2} \d{1,2}, \d{4})\b`,
+			DateFormats: []string{"2006-01-02", "1/2/2006", "1/2/06", "Jan 2, 2006"},
+			Time:        `\b(?P<time>\d{1,2}:\d{2}(?::\d{2})?(?:\s?[AaPp][Mm])?)`,
+			TimeFormats: []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3:04:05 PM", "3:04 pm", "3:04pm"},
+			Item:        `^\s*(?P<description>\S.*?[A-Za-z].*?)(?:\s{2,}|\s*\.{2,}\s*|\t)\$?(?P<price>\d+\.\d{2})\s*[A-Z]?\s*$`,
+			Total:       `(?im)^\s*(?:grand\s+)?total\b[\s:.]*\$?(?P<total>\d+\.\d{2})`,
+			Skip:        `(?i)^\s*(?:sub\s*-?\s*total|tax|total|change|cash|visa|mastercard|amex|discover|debit|credit|balance|tender|payment|tip|items?\s+sold)\b`,
+		},
+	}
+}
+
+// layout is a compiled Layout
+type layout struct {
+	Layout
+	match, retailer, date, time, item, total, skip *regexp.Regexp
+}
+
+// TextParser extracts receipts from plain text with the first matching Layout
+type TextParser struct {
+	// layouts are tried in order
+	layouts []layout
+}
+
+// NewTextParser compiles layouts into a TextParser
+// layouts: the layouts, tried in order
+// Returns: the TextParser, or an error describing an invalid layout
+func NewTextParser(layouts []Layout) (*TextParser, error) {
+	parser := &TextParser{}
+	for _, template := range layouts {
+		compiled := layout{Layout: template}
+		if len(compiled.DateFormats) == 0 {
+			compiled.DateFormats = DefaultLayouts()[0].DateFormats
+		}
+		if len(compiled.TimeFormats) == 0 {
+			compiled.TimeFormats = DefaultLayouts()[0].TimeFormats
+		}
+		patterns := []struct {
+			pattern  string
+			regexp   **regexp.Regexp
+			groups   []string
+			optional bool
+		}{
+			{template.Match, &compiled.match, nil, true},
+			{template.Retailer, &compiled.retailer, []string{"retailer"}, true},
+			{template.Date, &compiled.date, []string{"date"}, false},
+			{template.Time, &compiled.time, []string{"time"}, false},
+			{template.Item, &compiled.item, []string{"description", "price"}, false},
+			{template.Total, &compiled.total, []string{"total"}, false},
+			{template.Skip, &compiled.skip, nil, true},
+		}
+		for _, p := range patterns {
+			if p.pattern == "" {
+				if !p.optional {
+					return nil, fmt.Errorf("layout %q is missing a %s pattern", template.Name, p.groups[0])
+				}
+				continue
+			}
+			re, err := regexp.Compile(p.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("layout %q has an invalid pattern: %w", template.Name, err)
+			}
+			for _, group := range p.groups {
+				if re.SubexpIndex(group) < 0 {
+					return nil, fmt.Errorf("layout %q pattern %q is missing the %s group", template.Name, p.pattern, group)
+				}
+			}
+			*p.regexp = re
+		}
+		parser.layouts = append(parser.layouts, compiled)
+	}
+	return parser, nil
+}
+
+// LoadTextParser initializes a TextParser with the layouts in the optional
+// LAYOUTS_FILE, a json array of Layout, tried before the DefaultLayouts
+func LoadTextParser() *TextParser {
+	var layouts []Layout
+	if path := os.Getenv("LAYOUTS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &layouts); err != nil {
+			log.Fatalf("invalid layouts file %s: %v", path, err)
+		}
+	}
+	parser, err := NewTextParser(append(layouts, DefaultLayouts()...))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return parser
+}
+
+// Parse extracts a Receipt from a plain-text receipt with the first layout that
+// matches it, validated like a submitted Receipt
+// text: the plain-text receipt
+// Returns: the Receipt, or an error describing why each layout did not match
+func (p *TextParser) Parse(text string) (Receipt, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var reasons []string
+	for _, layout := range p.layouts {
+		if layout.match != nil && !layout.match.MatchString(text) {
+			continue
+		}
+		receipt, err := layout.parse(text)
+		if err == nil {
+			return receipt, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %v", layout.Name, err))
+	}
+	if len(reasons) == 0 {
+		return Receipt{}, fmt.Errorf("Unrecognized receipt layout")
+	}
+	return Receipt{}, fmt.Errorf("Unrecognized receipt layout, %s", strings.Join(reasons, "; "))
+}
+
+// parse extracts a Receipt from a plain-text receipt in the layout
+func (l layout) parse(text string) (Receipt, error) {
+	var retailer string
+	if l.retailer != nil {
+		retailer = find(l.retailer, text, "retailer")
+	} else {
+		for _, line := range strings.Split(text, "\n") {
+			if retailer = strings.TrimSpace(line); retailer != "" {
+				break
+			}
+		}
+	}
+	retailer = clean(retailer, `[^\w\s\-&]`)
+	if retailer == "" {
+		return Receipt{}, fmt.Errorf("missing retailer")
+	}
+
+	date, err := parseFirst(find(l.date, text, "date"), l.DateFormats)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("missing or invalid date")
+	}
+	purchaseTime, err := parseFirst(strings.ToUpper(find(l.time, text, "time")), l.TimeFormats)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("missing or invalid time")
+	}
+	total := find(l.total, text, "total")
+	if total == "" {
+		return Receipt{}, fmt.Errorf("missing total")
+	}
+
+	items := []any{}
+	for _, line := range strings.Split(text, "\n") {
+		if (l.skip != nil && l.skip.MatchString(line)) || (l.total.MatchString(line)) {
+			continue
+		}
+		match := l.item.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		description := clean(match[l.item.SubexpIndex("description")], `[^\w\s\-]`)
+		if description == "" {
+			continue
+		}
+		items = append(items, map[string]any{
+			"shortDescription": description,
+			"price":            match[l.item.SubexpIndex("price")],
+		})
+	}
+	if len(items) == 0 {
+		return Receipt{}, fmt.Errorf("missing items")
+	}
+
+	return DecodeReceipt(map[string]any{
+		"retailer":     retailer,
+		"purchaseDate": date.Format("2006-01-02"),
+		"purchaseTime": purchaseTime.Format("15:04"),
+		"total":        total,
+		"items":        items,
+	})
+}
+
+// find returns a named group of the first match of a pattern in text, empty when not found
+func find(re *regexp.Regexp, text string, group string) string {
+	match := re.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(match[re.SubexpIndex(group)])
+}
+
+// parseFirst parses a value with the first of the Go time layouts that fits
+func parseFirst(value string, formats []string) (time.Time, error) {
+	for _, format := range formats {
+		if parsed, err := time.Parse(format, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s does not match %s", strconv.Quote(value), strings.Join(formats, ", "))
+}
+
+// clean removes the characters the Receipt schema does not allow, and collapses spaces
+func clean(value string, disallowed string) string {
+	value = regexp.MustCompile(disallowed).ReplaceAllString(value, "")
+	return strings.Join(strings.Fields(value), " ")
+}
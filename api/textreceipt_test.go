@@ -0,0 +1,88 @@
+/*
+textreceipt_test.go contains functions for testing the plain-text receipt parser
+against the fixture receipts in testdata/text.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixtureParser initializes a TextParser with the example layouts and the defaults
+func fixtureParser(t *testing.T) *TextParser {
+	data, err := os.ReadFile("../examples/layouts.json")
+	assert.NoError(t, err)
+	var layouts []Layout
+	assert.NoError(t, json.Unmarshal(data, &layouts))
+	parser, err := NewTextParser(append(layouts, DefaultLayouts()...))
+	assert.NoError(t, err)
+	return parser
+}
+
+// TestTextParserFixtures verifies each fixture receipt is extracted into the Receipt
+// in its .json file, or fails with the error in its .error file
+func TestTextParserFixtures(t *testing.T) {
+	parser := fixtureParser(t)
+	fixtures, _ := filepath.Glob("testdata/text/*.txt")
+	assert.NotEmpty(t, fixtures)
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			text, _ := os.ReadFile(fixture)
+			receipt, err := parser.Parse(string(text))
+			base := strings.TrimSuffix(fixture, ".txt")
+			if expected, readErr := os.ReadFile(base + ".error"); readErr == nil {
+				assert.EqualError(t, err, strings.TrimSpace(string(expected)))
+				return
+			}
+			expected, readErr := os.ReadFile(base + ".json")
+			assert.NoError(t, readErr)
+			var want Receipt
+			assert.NoError(t, json.Unmarshal(expected, &want))
+			assert.NoError(t, err)
+			assert.Equal(t, want, receipt)
+		})
+	}
+}
+
+// TestTextLayouts verifies invalid layout templates are rejected
+func TestTextLayouts(t *testing.T) {
+	valid := DefaultLayouts()[0]
+	for message, change := range map[string]func(layout *Layout){
+		`layout "columns" is missing a total pattern`:                                                     func(layout *Layout) { layout.Total = "" },
+		`layout "columns" pattern "^(.+) (\\d+\\.\\d{2})$" is missing the description group`:              func(layout *Layout) { layout.Item = `^(.+) (\d+\.\d{2})$` },
+		"layout \"columns\" has an invalid pattern: error parsing regexp: missing closing ): `(?P<date>`": func(layout *Layout) { layout.Date = `(?P<date>` },
+	} {
+		layout := valid
+		change(&layout)
+		_, err := NewTextParser([]Layout{layout})
+		assert.EqualError(t, err, message)
+	}
+}
+
+// TestPostReceiptsProcessText verifies plain-text receipts are processed like JSON receipts
+func TestPostReceiptsProcessText(t *testing.T) {
+	router := GetRouter()
+	text, _ := os.ReadFile("testdata/text/target.txt")
+	request := httptest.NewRequest(http.MethodPost, "/receipts/process", strings.NewReader(string(text)))
+	request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	recorder := ProcessRequest(router, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	id := &PostReceiptsProcessResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &id)
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/receipts/"+id.Id+"/points", nil))
+	assert.JSONEq(t, `{"points":28}`, recorder.Body.String())
+
+	request = httptest.NewRequest(http.MethodPost, "/receipts/process", strings.NewReader("TARGET\nthanks for shopping\n"))
+	request.Header.Set("Content-Type", "text/plain")
+	recorder = ProcessRequest(router, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "Unrecognized receipt layout, columns: missing or invalid date\n", recorder.Body.String())
+}
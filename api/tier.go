@@ -0,0 +1,220 @@
+/*
+tier.go contains member tiers, which multiply the points members earn based on
+the points they earned over the trailing 12 months
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Tier is a member status, reached by earning Points over the trailing 12 months
+type Tier struct {
+	// Name of the tier, e.g. gold
+	Name string `json:"name"`
+	// Points needed over the trailing 12 months to qualify
+	Points int `json:"points"`
+	// Multiplier is applied to the points earned for each receipt
+	Multiplier float64 `json:"multiplier"`
+}
+
+// Tiers are the member tiers, from the lowest to the highest
+type Tiers []Tier
+
+// DefaultTiers returns the bronze, silver and gold tiers
+func DefaultTiers() Tiers {
+	return Tiers{
+		{Name: "bronze", Points: 0, Multiplier: 1},
+		{Name: "silver", Points: 1000, Multiplier: 1.25},
+		{Name: "gold", Points: 5000, Multiplier: 1.5},
+	}
+}
+
+// validate checks the lowest tier needs no points, and each tier needs
+// more points than the last
+func (t Tiers) validate() error {
+	names := map[string]bool{}
+	for i, tier := range t {
+		if tier.Name == "" {
+			return fmt.Errorf("tier is missing a name")
+		}
+		if names[tier.Name] {
+			return fmt.Errorf("duplicate tier name %q", tier.Name)
+		}
+		names[tier.Name] = true
+		if tier.Multiplier <= 0 {
+			return fmt.Errorf("tier %q must have a positive multiplier", tier.Name)
+		}
+		if i == 0 && tier.Points != 0 {
+			return fmt.Errorf("the lowest tier %q must need 0 points", tier.Name)
+		}
+		if i > 0 && tier.Points <= t[i-1].Points {
+			return fmt.Errorf("tier %q must need more points than tier %q", tier.Name, t[i-1].Name)
+		}
+	}
+	return nil
+}
+
+// Qualify finds the highest tier reached with points
+// points: the points earned over the trailing 12 months
+// Returns: the tier reached, and the next tier if there is one
+func (t Tiers) Qualify(points int) (Tier, *Tier) {
+	index := 0
+	for i, tier := range t {
+		if points >= tier.Points {
+			index = i
+		}
+	}
+	if index+1 < len(t) {
+		return t[index], &t[index+1]
+	}
+	return t[index], nil
+}
+
+// Tier finds a tier by name, the lowest tier when not found
+func (t Tiers) Tier(name string) Tier {
+	for _, tier := range t {
+		if tier.Name == name {
+			return tier
+		}
+	}
+	return t[0]
+}
+
+// apply multiplies the points in a Breakdown by the multiplier of a tier
+func (t Tier) apply(breakdown *Breakdown) {
+	breakdown.Tier = t.Name
+	breakdown.Multiplier = t.Multiplier
+	multiplied := int(math.Floor(float64(breakdown.Points) * t.Multiplier))
+	breakdown.TierBonus = multiplied - breakdown.Points
+	breakdown.Points = multiplied
+	breakdown.Uncapped = int(math.Floor(float64(breakdown.Uncapped) * t.Multiplier))
+}
+
+// TierStatus is the tier a member qualified for at the last requalification
+type TierStatus struct {
+	// Tier is the name of the tier
+	Tier string `json:"tier"`
+	// Points earned over the trailing 12 months when qualified
+	Points int `json:"points"`
+	// QualifiedAt is when the member was requalified
+	QualifiedAt time.Time `json:"qualifiedAt"`
+}
+
+// TierQualifier requalifies the tiers of members from the points they earned
+type TierQualifier struct {
+	// Database is the receipt and member storage
+	Database *Database
+	// RuleSets are the rules, the active rule set defines the tiers
+	RuleSets *RuleSets
+}
+
+// NewTierQualifier initializes TierQualifier
+// database: the receipt and member storage
+// rulesets: the rule set registry
+func NewTierQualifier(database *Database, rulesets *RuleSets) *TierQualifier {
+	return &TierQualifier{
+		Database: database,
+		RuleSets: rulesets,
+	}
+}
+
+// TrailingPoints sums the points a member earned from receipts purchased
+// in the 12 months up to now, without the bonus of their tier's multiplier,
+// so a tier does not help requalify for itself
+// memberId: the id of the member
+// now: the end of the 12 months
+// Returns: the points earned
+func (q *TierQualifier) TrailingPoints(memberId string, now time.Time) int {
+	start := now.AddDate(-1, 0, 0)
+	points := 0
+	for _, id := range q.Database.GetMemberReceiptIds(memberId) {
+		receipt, err := q.Database.GetReceipt(id)
+		if err != nil || !receipt.PurchaseDate.After(start) || receipt.PurchaseDate.After(now) {
+			continue
+		}
+		if score, err := q.Database.GetScore(id); err == nil {
+			points += max(score.Breakdown.Points-score.Breakdown.TierBonus, 0)
+		}
+	}
+	return points
+}
+
+// Requalify updates the tier of every member with the tiers of the active rule set
+// now: the time of the requalification
+func (q *TierQualifier) Requalify(now time.Time) {
+	tiers := q.RuleSets.Active().Processor.tiers
+	for _, memberId := range q.Database.GetMemberIds() {
+		points := q.TrailingPoints(memberId, now)
+		tier, _ := tiers.Qualify(points)
+		q.Database.PutTier(memberId, TierStatus{Tier: tier.Name, Points: points, QualifiedAt: now.UTC()})
+	}
+}
+
+// Run requalifies every member nightly, at midnight in location, until stop is closed
+// location: the time zone of midnight
+// stop: closed to stop requalifying
+func (q *TierQualifier) Run(location *time.Location, stop <-chan struct{}) {
+	for {
+		now := time.Now().In(location)
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, location)
+		timer := time.NewTimer(midnight.Sub(now))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case now := <-timer.C:
+			q.Requalify(now)
+			log.Printf("requalified member tiers")
+		}
+	}
+}
+
+// GetMembersIdTier handles GET requests for the tier of a member, and the progress
+// to the next tier from the points earned over the trailing 12 months
+// Response example: {"tier":"silver","multiplier":1.25,"qualifiedAt":"2024-08-20T00:00:00Z","points":1800,"nextTier":"gold","pointsToNextTier":3200,"progress":0.2}
+func (q *TierQualifier) GetMembersIdTier(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	_, memberErr := q.Database.GetMember(id)
+	if memberErr != nil && len(q.Database.GetMemberReceiptIds(id)) == 0 {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+	tiers := q.RuleSets.Active().Processor.tiers
+	status, err := q.Database.GetTier(id)
+	if err != nil {
+		status = TierStatus{Tier: tiers[0].Name}
+	}
+	tier := tiers.Tier(status.Tier)
+	response := GetMembersIdTierResponse{
+		Tier:       tier.Name,
+		Multiplier: tier.Multiplier,
+		Points:     q.TrailingPoints(id, time.Now()),
+	}
+	if !status.QualifiedAt.IsZero() {
+		response.QualifiedAt = &status.QualifiedAt
+	}
+	// progress from the current tier to the tier above it
+	for i := range tiers {
+		if tiers[i].Name == tier.Name && i+1 < len(tiers) {
+			next := tiers[i+1]
+			response.NextTier = next.Name
+			response.PointsToNextTier = max(next.Points-response.Points, 0)
+			progress := float64(response.Points-tier.Points) / float64(next.Points-tier.Points)
+			response.Progress = math.Max(0, math.Min(1, progress))
+		}
+	}
+	if response.NextTier == "" {
+		response.Progress = 1
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,177 @@
+/*
+tier_test.go contains functions for testing member tiers and their requalification.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTiers verifies tiers are qualified for by points, and validated
+func TestTiers(t *testing.T) {
+	tiers := DefaultTiers()
+	tests := []struct {
+		points   int
+		expected string
+		next     string
+	}{
+		{points: 0, expected: "bronze", next: "silver"},
+		{points: 999, expected: "bronze", next: "silver"},
+		{points: 1000, expected: "silver", next: "gold"},
+		{points: 5000, expected: "gold"},
+	}
+	for _, tt := range tests {
+		tier, next := tiers.Qualify(tt.points)
+		assert.Equal(t, tt.expected, tier.Name, tt.points)
+		if tt.next == "" {
+			assert.Nil(t, next)
+		} else {
+			assert.Equal(t, tt.next, next.Name)
+		}
+	}
+	assert.Equal(t, "bronze", tiers.Tier("platinum").Name)
+
+	for expectedErr, invalid := range map[string]Tiers{
+		"must need 0 points":     {{Name: "bronze", Points: 10, Multiplier: 1}},
+		"must need more points":  {{Name: "bronze", Multiplier: 1}, {Name: "silver", Multiplier: 2}},
+		"positive multiplier":    {{Name: "bronze", Multiplier: 0}},
+		"duplicate tier name":    {{Name: "bronze", Multiplier: 1}, {Name: "bronze", Points: 1, Multiplier: 1}},
+		"tier is missing a name": {{Multiplier: 1}},
+	} {
+		_, err := NewRuleProcessorFromConfig(RuleConfig{Tiers: invalid}, nil)
+		assert.ErrorContains(t, err, expectedErr)
+	}
+}
+
+// TestTierQualification verifies members are requalified from their trailing 12 month
+// points, and earn at the multiplier of their tier
+func TestTierQualification(t *testing.T) {
+	database := &Database{}
+	rulesets := NewRuleSets(database)
+	_, err := rulesets.Register(RuleConfig{Tiers: Tiers{
+		{Name: "bronze", Points: 0, Multiplier: 1},
+		{Name: "silver", Points: 50, Multiplier: 1.5},
+	}})
+	assert.NoError(t, err)
+	router := NewRouter(database, rulesets)
+	qualifier := NewTierQualifier(database, rulesets)
+
+	now := time.Now()
+	submit := func(date time.Time) *GetReceiptsIdBreakdownResponse {
+		recorder := ProcessRequest(router, BuildRequest(`{
+			"retailer": "Target",
+			"memberId": "member-1",
+			"purchaseDate": "`+date.Format(time.DateOnly)+`",
+			"purchaseTime": "13:13",
+			"total": "1.25",
+			"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}]
+		}`))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		id := &PostReceiptsProcessResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &id)
+		return GetBreakdown(t, router, "/receipts/"+id.Id+"/breakdown")
+	}
+	getTier := func(id string) (*httptest.ResponseRecorder, *GetMembersIdTierResponse) {
+		recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/members/"+id+"/tier", nil))
+		response := &GetMembersIdTierResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder, response
+	}
+
+	// bronze before the first requalification
+	first := submit(now.AddDate(0, 0, -10))
+	assert.Equal(t, "bronze", first.Tier)
+	assert.Equal(t, 0, first.TierBonus)
+	recorder, tier := getTier("member-1")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, GetMembersIdTierResponse{
+		Tier:             "bronze",
+		Multiplier:       1,
+		Points:           first.Points,
+		NextTier:         "silver",
+		PointsToNextTier: 50 - first.Points,
+		Progress:         float64(first.Points) / 50,
+	}, *tier)
+
+	// receipts older than 12 months do not count
+	submit(now.AddDate(0, -13, 0))
+	assert.Equal(t, first.Points, qualifier.TrailingPoints("member-1", now))
+	qualifier.Requalify(now)
+	status, err := database.GetTier("member-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "bronze", status.Tier)
+
+	// silver once requalified with enough points
+	second := submit(now.AddDate(0, 0, -9))
+	qualifier.Requalify(now)
+	_, tier = getTier("member-1")
+	assert.Equal(t, "silver", tier.Tier)
+	assert.Equal(t, 1.5, tier.Multiplier)
+	assert.NotNil(t, tier.QualifiedAt)
+	assert.Equal(t, first.Points+second.Points, tier.Points)
+	assert.Equal(t, "", tier.NextTier)
+	assert.Equal(t, 1.0, tier.Progress)
+
+	// silver members earn half again
+	third := submit(now.AddDate(0, 0, -8))
+	assert.Equal(t, "silver", third.Tier)
+	assert.Equal(t, 1.5, third.Multiplier)
+	base := third.Points - third.TierBonus
+	assert.Equal(t, base/2, third.TierBonus)
+	assert.Contains(t, []int{31, 37}, base)
+
+	recorder, _ = getTier("member-2")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestTierQualificationBonus verifies the bonus of a member's tier does not count
+// towards requalifying, even when the multiplier alone would cross a threshold
+func TestTierQualificationBonus(t *testing.T) {
+	database := &Database{}
+	rulesets := NewRuleSets(database)
+	_, err := rulesets.Register(RuleConfig{Tiers: Tiers{
+		{Name: "bronze", Points: 0, Multiplier: 1},
+		{Name: "silver", Points: 50, Multiplier: 1.5},
+		{Name: "gold", Points: 80, Multiplier: 2},
+	}})
+	assert.NoError(t, err)
+	router := NewRouter(database, rulesets)
+	qualifier := NewTierQualifier(database, rulesets)
+	database.PutTier("member-1", TierStatus{Tier: "silver"})
+
+	now := time.Now()
+	for _, days := range []int{-2, -1} {
+		recorder := ProcessRequest(router, BuildRequest(`{
+			"retailer": "Target",
+			"memberId": "member-1",
+			"purchaseDate": "`+now.AddDate(0, 0, days).Format(time.DateOnly)+`",
+			"purchaseTime": "13:13",
+			"total": "1.25",
+			"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}]
+		}`))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+	// at least 31 points each, over 80 with the silver multiplier
+	earned := 0
+	for _, id := range database.GetMemberReceiptIds("member-1") {
+		score, err := database.GetScore(id)
+		assert.NoError(t, err)
+		assert.Greater(t, score.Breakdown.TierBonus, 0)
+		earned += score.Breakdown.Points
+	}
+	assert.GreaterOrEqual(t, earned, 80)
+	points := qualifier.TrailingPoints("member-1", now)
+	assert.Less(t, points, 80)
+
+	qualifier.Requalify(now)
+	status, err := database.GetTier("member-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "silver", status.Tier)
+	assert.Equal(t, points, status.Points)
+}
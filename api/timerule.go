@@ -0,0 +1,156 @@
+/*
+timerule.go contains rules that award points for the time of purchase,
+evaluated in the store's local time
+*/
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	// embed the time zone database, the server image has no zoneinfo
+	_ "time/tzdata"
+)
+
+// ReceiptLocation returns the store time zone of a Receipt, from its time zone,
+// its UTC offset, or the default location when it has neither
+// r: the Receipt
+// defaultLocation: the time zone of receipts without a time zone or UTC offset
+// Returns: the store time zone
+func ReceiptLocation(r Receipt, defaultLocation *time.Location) (*time.Location, error) {
+	if r.TimeZone != nil && r.UtcOffset != nil {
+		return nil, fmt.Errorf("only one of timeZone or utcOffset may be given")
+	}
+	if r.TimeZone != nil {
+		return time.LoadLocation(*r.TimeZone)
+	}
+	if r.UtcOffset != nil {
+		return parseUTCOffset(*r.UtcOffset)
+	}
+	return defaultLocation, nil
+}
+
+// parseUTCOffset parses a UTC offset like -05:00 into a fixed time zone
+func parseUTCOffset(offset string) (*time.Location, error) {
+	if len(offset) != 6 || (offset[0] != '+' && offset[0] != '-') || offset[3] != ':' {
+		return nil, fmt.Errorf("invalid UTC offset %q", offset)
+	}
+	hours, err := strconv.Atoi(offset[1:3])
+	if err != nil || hours > 14 {
+		return nil, fmt.Errorf("invalid UTC offset %q", offset)
+	}
+	minutes, err := strconv.Atoi(offset[4:6])
+	if err != nil || minutes > 59 {
+		return nil, fmt.Errorf("invalid UTC offset %q", offset)
+	}
+	seconds := hours*3600 + minutes*60
+	if offset[0] == '-' {
+		seconds = -seconds
+	}
+	return time.FixedZone("UTC"+offset, seconds), nil
+}
+
+// PurchaseTime returns the instant of purchase, reading the purchase date and
+// time in the store time zone. Times skipped by a daylight saving transition are
+// read as the same time before the transition, e.g. 02:30 on the spring forward
+// day in America/New_York is 03:30 EDT, and repeated times as their first occurrence.
+// r: the Receipt
+// location: the store time zone, see ReceiptLocation
+// Returns: the instant of purchase
+func PurchaseTime(r Receipt, location *time.Location) (time.Time, error) {
+	clock, err := time.Parse("15:04", r.PurchaseTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return onDate(r.PurchaseDate.Time, clock, location), nil
+}
+
+// onDate returns the instant of a clock time on a date in a time zone,
+// resolving times skipped or repeated by daylight saving transitions with the
+// offset in effect before the transition
+func onDate(date time.Time, clock time.Time, location *time.Location) time.Time {
+	year, month, day := date.Date()
+	hour, minute := clock.Hour(), clock.Minute()
+	local := time.Date(year, month, day, hour, minute, 0, 0, location)
+	// time.Date does not guarantee which offset it uses during a transition,
+	// so read the clock time with the offset a few hours earlier
+	_, before := local.Add(-3 * time.Hour).Zone()
+	wall := time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	first := wall.Add(-time.Duration(before) * time.Second).In(location)
+	if first.Hour() == hour && first.Minute() == minute {
+		return first
+	}
+	if local.Hour() == hour && local.Minute() == minute {
+		return local
+	}
+	// skipped by a transition
+	return first
+}
+
+// TimeWindowRule awards points for purchases after Start and before End,
+// both in the store's local time on the purchase date
+//
+// Example json, 10 points for purchases after 14:00 and before 16:00:
+//
+//	{"name": "afternoon", "start": "14:00", "end": "16:00", "points": 10}
+type TimeWindowRule struct {
+	// RuleName identifies the rule in a Breakdown
+	RuleName string `json:"name"`
+	// Start of the window in 24-hour time, exclusive
+	Start string `json:"start"`
+	// End of the window in 24-hour time, exclusive
+	End string `json:"end"`
+	// Points awarded for purchases in the window
+	Points int `json:"points"`
+
+	// start, end and location are set by Compile
+	start    time.Time
+	end      time.Time
+	location *time.Location
+}
+
+// Compile validates the rule and prepares it for scoring
+// location: the time zone of receipts without a time zone or UTC offset
+func (t *TimeWindowRule) Compile(location *time.Location) error {
+	if t.RuleName == "" {
+		return fmt.Errorf("time rule is missing a name")
+	}
+	var err error
+	if t.start, err = time.Parse("15:04", t.Start); err != nil {
+		return fmt.Errorf("time rule %q has an invalid start: %w", t.RuleName, err)
+	}
+	if t.end, err = time.Parse("15:04", t.End); err != nil {
+		return fmt.Errorf("time rule %q has an invalid end: %w", t.RuleName, err)
+	}
+	if !t.end.After(t.start) {
+		return fmt.Errorf("time rule %q must end after it starts", t.RuleName)
+	}
+	t.location = location
+	return nil
+}
+
+// Name returns the name of the rule
+func (t *TimeWindowRule) Name() string {
+	return t.RuleName
+}
+
+// Score awards Points when the purchase is inside the window
+func (t *TimeWindowRule) Score(r Receipt) RuleResult {
+	result := RuleResult{Rule: t.RuleName}
+	location, err := ReceiptLocation(r, t.location)
+	if err != nil {
+		return result
+	}
+	purchased, err := PurchaseTime(r, location)
+	if err != nil {
+		return result
+	}
+	start := onDate(r.PurchaseDate.Time, t.start, location)
+	end := onDate(r.PurchaseDate.Time, t.end, location)
+	if purchased.After(start) && purchased.Before(end) {
+		result.Points = t.Points
+		result.Uncapped = t.Points
+	}
+	return result
+}
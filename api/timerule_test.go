@@ -0,0 +1,112 @@
+/*
+timerule_test.go contains functions for testing time window rules across time zones
+and daylight saving transitions.
+*/
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPurchaseTime verifies purchase times are read in the store time zone
+func TestPurchaseTime(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+	tests := []struct {
+		name     string
+		receipt  string
+		expected string
+	}{
+		{
+			name:     "default location",
+			receipt:  `{"purchaseDate": "2024-07-01", "purchaseTime": "14:30"}`,
+			expected: "2024-07-01T14:30:00-04:00",
+		},
+		{
+			name:     "time zone",
+			receipt:  `{"purchaseDate": "2024-07-01", "purchaseTime": "14:30", "timeZone": "America/Los_Angeles"}`,
+			expected: "2024-07-01T14:30:00-07:00",
+		},
+		{
+			name:     "utc offset",
+			receipt:  `{"purchaseDate": "2024-07-01", "purchaseTime": "14:30", "utcOffset": "+05:30"}`,
+			expected: "2024-07-01T14:30:00+05:30",
+		},
+		{
+			name:     "skipped by spring forward",
+			receipt:  `{"purchaseDate": "2024-03-10", "purchaseTime": "02:30", "timeZone": "America/New_York"}`,
+			expected: "2024-03-10T03:30:00-04:00",
+		},
+		{
+			name:     "repeated by fall back",
+			receipt:  `{"purchaseDate": "2024-11-03", "purchaseTime": "01:30", "timeZone": "America/New_York"}`,
+			expected: "2024-11-03T01:30:00-04:00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt := ParseReceipt(t, tt.receipt)
+			location, err := ReceiptLocation(receipt, newYork)
+			assert.NoError(t, err)
+			purchased, err := PurchaseTime(receipt, location)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, purchased.Format(time.RFC3339))
+		})
+	}
+
+	_, err := ReceiptLocation(ParseReceipt(t, `{"timeZone": "Mars/Olympus_Mons"}`), time.UTC)
+	assert.Error(t, err)
+	_, err = ReceiptLocation(ParseReceipt(t, `{"utcOffset": "+25:00"}`), time.UTC)
+	assert.Error(t, err)
+	_, err = ReceiptLocation(ParseReceipt(t, `{"timeZone": "UTC", "utcOffset": "+00:00"}`), time.UTC)
+	assert.Error(t, err)
+}
+
+// TestTimeWindowRuleDST verifies time windows across daylight saving transitions
+func TestTimeWindowRuleDST(t *testing.T) {
+	processor, err := NewRuleProcessorFromConfig(RuleConfig{
+		TimeZone: "America/New_York",
+		TimeRules: []TimeWindowRule{
+			// spans the spring forward and fall back transitions at 02:00
+			{RuleName: "night", Start: "01:30", End: "03:15", Points: 7},
+		},
+	}, nil)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		date     string
+		time     string
+		zone     string
+		expected int
+	}{
+		{name: "before spring forward", date: "2024-03-10", time: "01:45", expected: 7},
+		{name: "skipped by spring forward", date: "2024-03-10", time: "02:30", expected: 0},
+		{name: "after spring forward", date: "2024-03-10", time: "03:00", expected: 7},
+		{name: "fixed offset on spring forward", date: "2024-03-10", time: "02:30", zone: `"utcOffset": "-05:00",`, expected: 7},
+		{name: "repeated by fall back", date: "2024-11-03", time: "01:45", expected: 7},
+		{name: "after fall back", date: "2024-11-03", time: "03:10", expected: 7},
+		{name: "end of window", date: "2024-11-03", time: "03:15", expected: 0},
+		{name: "other time zone", date: "2024-11-03", time: "01:45", zone: `"timeZone": "Asia/Tokyo",`, expected: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt := ParseReceipt(t, `{`+tt.zone+`"purchaseDate": "`+tt.date+`", "purchaseTime": "`+tt.time+`"}`)
+			assert.Equal(t, tt.expected, FindResult(t, processor.Breakdown(receipt), "night").Points)
+		})
+	}
+}
+
+// TestTimeWindowRuleConfigErrors verifies invalid time rules and time zones are rejected
+func TestTimeWindowRuleConfigErrors(t *testing.T) {
+	_, err := NewRuleProcessorFromConfig(RuleConfig{TimeZone: "Mars/Olympus_Mons"}, nil)
+	assert.ErrorContains(t, err, "invalid time zone")
+	_, err = NewRuleProcessorFromConfig(RuleConfig{TimeRules: []TimeWindowRule{{RuleName: "bad", Start: "2pm", End: "16:00"}}}, nil)
+	assert.ErrorContains(t, err, "invalid start")
+	_, err = NewRuleProcessorFromConfig(RuleConfig{TimeRules: []TimeWindowRule{{RuleName: "backwards", Start: "16:00", End: "14:00"}}}, nil)
+	assert.ErrorContains(t, err, "must end after it starts")
+	_, err = NewRuleProcessorFromConfig(RuleConfig{TimeRules: []TimeWindowRule{{RuleName: "afternoon", Start: "14:00", End: "16:00"}}}, nil)
+	assert.ErrorContains(t, err, "duplicate rule name")
+}
@@ -0,0 +1,401 @@
+/*
+webhook.go contains webhook notifications of receipt events, delivered signed
+with retries, and methods for handling webhook administration requests
+*/
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Webhook event types
+const (
+	// ReceiptProcessedEvent is sent when a Receipt is processed
+	ReceiptProcessedEvent = "receipt.processed"
+	// ReceiptScoredEvent is sent when a Receipt is pinned to a Score
+	ReceiptScoredEvent = "receipt.scored"
+	// ReceiptFlaggedEvent is sent when a Receipt is held for review
+	ReceiptFlaggedEvent = "receipt.flagged"
+	// PointsRedeemedEvent is sent when a member redeems points
+	PointsRedeemedEvent = "points.redeemed"
+)
+
+// Delivery statuses
+const (
+	// PendingDelivery is a delivery being attempted
+	PendingDelivery = "pending"
+	// DeadDelivery is a delivery that failed every attempt, kept in the dead-letter list
+	DeadDelivery = "dead"
+)
+
+// Webhook is a receiver registered for events
+type Webhook struct {
+	// Id identifies the webhook
+	Id string `json:"id"`
+	// Url receives the events with POST requests
+	Url string `json:"url"`
+	// Events are the event types sent to Url
+	Events []string `json:"events"`
+	// Secret signs the events, only returned when the webhook is registered
+	Secret string `json:"secret,omitempty"`
+	// CreatedAt is when the webhook was registered
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Event is the json body of a webhook delivery
+type Event struct {
+	// Id identifies the event
+	Id string `json:"id"`
+	// Type is the event type, e.g. receipt.processed
+	Type string `json:"type"`
+	// CreatedAt is when the event happened
+	CreatedAt time.Time `json:"createdAt"`
+	// Data describes what happened
+	Data any `json:"data"`
+}
+
+// ReceiptEvent is the data of the receipt events
+type ReceiptEvent struct {
+	// ReceiptId is the id of the Receipt
+	ReceiptId string `json:"receiptId"`
+	// Retailer is the name of the retailer
+	Retailer string `json:"retailer"`
+	// MemberId is the member who submitted the Receipt, if any
+	MemberId *string `json:"memberId,omitempty"`
+	// Ruleset is the rule set version the Receipt was scored with, for receipt.scored
+	Ruleset string `json:"ruleset,omitempty"`
+	// Points earned for the Receipt, for receipt.scored
+	Points *int `json:"points,omitempty"`
+	// Held is set when the Receipt is held at zero points pending review
+	Held bool `json:"held,omitempty"`
+	// Flags are the reasons the Receipt was flagged, for receipt.flagged
+	Flags []FraudFlag `json:"flags,omitempty"`
+}
+
+// Delivery is an attempt to send an Event to a Webhook
+type Delivery struct {
+	// Id identifies the delivery, sent in the X-Webhook-Delivery header
+	Id string `json:"id"`
+	// WebhookId is the webhook the event is sent to
+	WebhookId string `json:"webhookId"`
+	// Event is the event type
+	Event string `json:"event"`
+	// Payload is the json Event sent
+	Payload json.RawMessage `json:"payload"`
+	// Status is pending or dead, deliveries the receiver accepted are not kept
+	Status string `json:"status"`
+	// Attempts is the number of attempts made
+	Attempts int `json:"attempts"`
+	// LastError describes why the last attempt failed
+	LastError string `json:"lastError,omitempty"`
+	// CreatedAt is when the delivery was created
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RetryPolicy is how deliveries are retried, waiting twice as long after each failure
+type RetryPolicy struct {
+	// MaxAttempts is the most attempts before a delivery is dead
+	MaxAttempts int
+	// InitialBackoff is the wait after the first failure
+	InitialBackoff time.Duration
+	// MaxBackoff is the longest wait between attempts
+	MaxBackoff time.Duration
+}
+
+// backoff is the wait after a number of failed attempts
+func (p RetryPolicy) backoff(attempts int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < attempts && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, p.MaxBackoff)
+}
+
+// Webhooks registers webhooks, and delivers events to them
+type Webhooks struct {
+	// Policy is how failed deliveries are retried
+	Policy RetryPolicy
+	// Client sends the deliveries
+	Client *http.Client
+	// mu guards the fields below
+	mu sync.Mutex
+	// hooks is a map of webhook id->Webhook
+	hooks map[string]Webhook
+	// deliveries is a map of delivery id->Delivery
+	deliveries map[string]*Delivery
+}
+
+// NewWebhooks initializes Webhooks with no webhooks, retrying deliveries 6 times
+// over about a minute
+func NewWebhooks() *Webhooks {
+	return &Webhooks{
+		Policy:     RetryPolicy{MaxAttempts: 6, InitialBackoff: 2 * time.Second, MaxBackoff: 30 * time.Second},
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		hooks:      map[string]Webhook{},
+		deliveries: map[string]*Delivery{},
+	}
+}
+
+// Register adds a webhook, validating its url and events, and generating its secret when empty
+// webhook: the webhook to add
+// Returns: the registered Webhook, with its id and secret
+func (w *Webhooks) Register(webhook Webhook) (Webhook, error) {
+	if target, err := url.Parse(webhook.Url); err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return webhook, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if len(webhook.Events) == 0 {
+		return webhook, fmt.Errorf("webhook has no events")
+	}
+	for _, event := range webhook.Events {
+		switch event {
+		case ReceiptProcessedEvent, ReceiptScoredEvent, ReceiptFlaggedEvent, PointsRedeemedEvent:
+		default:
+			return webhook, fmt.Errorf("unknown event %q", event)
+		}
+	}
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		rand.Read(secret)
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	webhook.Id = uuid.New().String()
+	webhook.CreatedAt = time.Now().UTC()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.hooks[webhook.Id] = webhook
+	return webhook, nil
+}
+
+// Publish sends an event to every webhook registered for it, in the background
+// event: the event type
+// data: describes what happened
+func (w *Webhooks) Publish(event string, data any) {
+	payload, err := json.Marshal(Event{Id: uuid.New().String(), Type: event, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		log.Printf("webhook event %s: %v", event, err)
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, webhook := range w.hooks {
+		if !slices.Contains(webhook.Events, event) {
+			continue
+		}
+		delivery := &Delivery{
+			Id:        uuid.New().String(),
+			WebhookId: webhook.Id,
+			Event:     event,
+			Payload:   payload,
+			Status:    PendingDelivery,
+			CreatedAt: time.Now().UTC(),
+		}
+		w.deliveries[delivery.Id] = delivery
+		go w.deliver(delivery.Id)
+	}
+}
+
+// Replay sends a dead delivery again, with a fresh set of attempts
+// id: the id of the dead Delivery
+func (w *Webhooks) Replay(id string) (Delivery, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delivery, ok := w.deliveries[id]
+	if !ok || delivery.Status != DeadDelivery {
+		return Delivery{}, fmt.Errorf("dead delivery not found")
+	}
+	if _, ok := w.hooks[delivery.WebhookId]; !ok {
+		return Delivery{}, fmt.Errorf("webhook was deleted")
+	}
+	delivery.Status = PendingDelivery
+	delivery.Attempts = 0
+	go w.deliver(id)
+	return *delivery, nil
+}
+
+// DeadLetters returns the deliveries that failed every attempt
+// Returns: the dead deliveries, oldest first
+func (w *Webhooks) DeadLetters() []Delivery {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dead := []Delivery{}
+	for _, delivery := range w.deliveries {
+		if delivery.Status == DeadDelivery {
+			dead = append(dead, *delivery)
+		}
+	}
+	sort.Slice(dead, func(i, j int) bool { return dead[i].CreatedAt.Before(dead[j].CreatedAt) })
+	return dead
+}
+
+// deliver attempts a delivery until the receiver accepts it, or it is dead,
+// dropping delivered deliveries so only dead letters are kept
+func (w *Webhooks) deliver(id string) {
+	for {
+		w.mu.Lock()
+		delivery := w.deliveries[id]
+		webhook, ok := w.hooks[delivery.WebhookId]
+		payload := delivery.Payload
+		w.mu.Unlock()
+		var err error
+		if ok {
+			err = w.send(webhook, id, delivery.Event, payload)
+		} else {
+			err = fmt.Errorf("webhook was deleted")
+		}
+
+		w.mu.Lock()
+		delivery.Attempts++
+		if err == nil {
+			delete(w.deliveries, id)
+			w.mu.Unlock()
+			return
+		}
+		delivery.LastError = err.Error()
+		if !ok || delivery.Attempts >= w.Policy.MaxAttempts {
+			delivery.Status = DeadDelivery
+			log.Printf("webhook delivery %s to %s is dead: %v", id, webhook.Url, err)
+			w.mu.Unlock()
+			return
+		}
+		wait := w.Policy.backoff(delivery.Attempts)
+		w.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// send POSTs a payload to a webhook, signed with its secret
+// Returns: an error when the request fails or the receiver does not accept it
+func (w *Webhooks) send(webhook Webhook, id string, event string, payload []byte) error {
+	request, err := http.NewRequest(http.MethodPost, webhook.Url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Webhook-Event", event)
+	request.Header.Set("X-Webhook-Delivery", id)
+	request.Header.Set("X-Webhook-Timestamp", timestamp)
+	request.Header.Set("X-Webhook-Signature", Sign(webhook.Secret, timestamp, payload))
+	response, err := w.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("receiver responded %s", response.Status)
+	}
+	return nil
+}
+
+// Sign computes the X-Webhook-Signature of a delivery, which receivers recompute
+// with the secret to verify the delivery
+// secret: the secret of the webhook
+// timestamp: the X-Webhook-Timestamp of the delivery
+// payload: the body of the delivery
+// Returns: sha256= followed by the hex HMAC-SHA256 of the timestamp, a period and the payload
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookHandler handles the administration of webhooks
+type WebhookHandler struct {
+	// Webhooks are the registered webhooks
+	Webhooks *Webhooks
+}
+
+// NewWebhookHandler initializes WebhookHandler
+// webhooks: the registered webhooks
+func NewWebhookHandler(webhooks *Webhooks) WebhookHandler {
+	return WebhookHandler{
+		Webhooks: webhooks,
+	}
+}
+
+// PostWebhooks handles POST requests to register a webhook, returning its secret
+// Response example: {"id":"1f0e4a9c-...","url":"https://example.com/hooks","events":["receipt.processed"],"secret":"9c1d...","createdAt":"2024-08-20T05:11:44Z"}
+func (h *WebhookHandler) PostWebhooks(w http.ResponseWriter, r *http.Request) {
+	var webhook Webhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	webhook, err := h.Webhooks.Register(webhook)
+	if err != nil {
+		http.Error(w, "Invalid webhook: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// GetWebhooks handles GET requests to list the registered webhooks, without their secrets
+// Response example: [{"id":"1f0e4a9c-...","url":"https://example.com/hooks","events":["receipt.processed"],"createdAt":"2024-08-20T05:11:44Z"}]
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	h.Webhooks.mu.Lock()
+	webhooks := []Webhook{}
+	for _, webhook := range h.Webhooks.hooks {
+		webhook.Secret = ""
+		webhooks = append(webhooks, webhook)
+	}
+	h.Webhooks.mu.Unlock()
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt) })
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// DeleteWebhooksId handles DELETE requests to remove a webhook
+func (h *WebhookHandler) DeleteWebhooksId(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.Webhooks.mu.Lock()
+	_, ok := h.Webhooks.hooks[id]
+	delete(h.Webhooks.hooks, id)
+	h.Webhooks.mu.Unlock()
+	if !ok {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhooksDeadletters handles GET requests to list the deliveries that failed every attempt
+// Response example: [{"id":"5b7c...","webhookId":"1f0e4a9c-...","event":"receipt.processed","payload":{...},"status":"dead","attempts":6,"lastError":"receiver responded 500 Internal Server Error","createdAt":"2024-08-20T05:11:44Z"}]
+func (h *WebhookHandler) GetWebhooksDeadletters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.Webhooks.DeadLetters())
+}
+
+// PostWebhooksDeadlettersIdReplay handles POST requests to send a dead delivery again
+// Response example: {"id":"5b7c...","webhookId":"1f0e4a9c-...","event":"receipt.processed","payload":{...},"status":"pending","attempts":0,...}
+func (h *WebhookHandler) PostWebhooksDeadlettersIdReplay(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.Webhooks.Replay(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Dead delivery not found: "+err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
@@ -0,0 +1,176 @@
+/*
+webhook_test.go contains functions for testing webhook notifications.
+*/
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// receiver is a local webhook receiver, failing the first failures deliveries
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	secret   string
+	attempts int
+	events   []Event
+	invalid  int
+}
+
+// ServeHTTP verifies the signature and event header of a delivery, and records its Event
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.attempts++
+	event := Event{}
+	json.Unmarshal(body, &event)
+	if r.Header.Get("X-Webhook-Signature") != Sign(rc.secret, r.Header.Get("X-Webhook-Timestamp"), body) ||
+		r.Header.Get("X-Webhook-Event") != event.Type {
+		rc.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rc.events = append(rc.events, event)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// received returns the types of the events received
+func (rc *receiver) received() []string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	var types []string
+	for _, event := range rc.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+// TestRetryPolicy verifies the backoff doubles up to the maximum
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	var waits []time.Duration
+	for attempts := 1; attempts <= 5; attempts++ {
+		waits = append(waits, policy.backoff(attempts))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, waits)
+}
+
+// TestWebhooks verifies signed events are delivered to registered webhooks,
+// retried, kept in the dead-letter list, and replayed
+func TestWebhooks(t *testing.T) {
+	handler := NewReceiptHandler(&Database{})
+	handler.Webhooks.Policy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+	router := chi.NewRouter()
+	router.Mount("/receipts", ReceiptRoutes(handler))
+	router.Mount("/webhooks", WebhookRoutes(handler.Webhooks))
+
+	register := func(url string, events string) Webhook {
+		request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url": "`+url+`", "events": `+events+`, "secret": "0123456789abcdef"}`))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := ProcessRequest(router, request)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		webhook := Webhook{}
+		json.Unmarshal(recorder.Body.Bytes(), &webhook)
+		return webhook
+	}
+	submit := func() {
+		recorder := ProcessRequest(router, BuildRequest(`{
+			"retailer": "Target",
+			"purchaseDate": "2022-01-02",
+			"purchaseTime": "13:13",
+			"total": "1.25",
+			"items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}]
+		}`))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	// delivered after two failures, flagged duplicates notify receipt.flagged
+	flaky := &receiver{failures: 2, secret: "0123456789abcdef"}
+	flakyServer := httptest.NewServer(flaky)
+	defer flakyServer.Close()
+	webhook := register(flakyServer.URL, `["receipt.processed", "receipt.scored", "receipt.flagged"]`)
+	assert.Equal(t, "0123456789abcdef", webhook.Secret)
+	submit()
+	submit()
+	assert.Eventually(t, func() bool { return len(flaky.received()) == 5 }, time.Second, time.Millisecond)
+	assert.ElementsMatch(t, []string{"receipt.processed", "receipt.scored", "receipt.processed", "receipt.flagged", "receipt.scored"}, flaky.received())
+	assert.Equal(t, 0, flaky.invalid)
+	assert.Equal(t, 7, flaky.attempts)
+	for _, event := range flaky.events {
+		if event.Type == ReceiptScoredEvent {
+			data := event.Data.(map[string]any)
+			assert.Equal(t, "v1", data["ruleset"])
+			assert.Contains(t, []any{float64(31), float64(0)}, data["points"])
+		}
+	}
+
+	// dead after every attempt fails, then replayed
+	down := &receiver{failures: 3, secret: "0123456789abcdef"}
+	downServer := httptest.NewServer(down)
+	defer downServer.Close()
+	register(downServer.URL, `["receipt.processed"]`)
+	submit()
+	var dead []Delivery
+	assert.Eventually(t, func() bool {
+		recorder := ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/webhooks/deadletters", nil))
+		dead = nil
+		json.Unmarshal(recorder.Body.Bytes(), &dead)
+		return len(dead) == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, DeadDelivery, dead[0].Status)
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Equal(t, "receiver responded 500 Internal Server Error", dead[0].LastError)
+	assert.Empty(t, down.received())
+
+	recorder := ProcessRequest(router, httptest.NewRequest(http.MethodPost, "/webhooks/deadletters/"+dead[0].Id+"/replay", nil))
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Eventually(t, func() bool { return len(down.received()) == 1 }, time.Second, time.Millisecond)
+	assert.Empty(t, handler.Webhooks.DeadLetters())
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodPost, "/webhooks/deadletters/"+dead[0].Id+"/replay", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// listed without secrets, and removed
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodGet, "/webhooks", nil))
+	var webhooks []Webhook
+	json.Unmarshal(recorder.Body.Bytes(), &webhooks)
+	assert.Len(t, webhooks, 2)
+	assert.Equal(t, "", webhooks[0].Secret)
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodDelete, "/webhooks/"+webhook.Id, nil))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = ProcessRequest(router, httptest.NewRequest(http.MethodDelete, "/webhooks/"+webhook.Id, nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// invalid registrations
+	for _, body := range []string{
+		`{"url": "ftp://example.com", "events": ["receipt.processed"]}`,
+		`{"url": "https://example.com", "events": []}`,
+		`{"url": "https://example.com", "events": ["receipt.deleted"]}`,
+		`{"url": "https:///hooks", "events": ["receipt.processed"]}`,
+		`{"url": "http://exa mple.com", "events": ["receipt.processed"]}`,
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		assert.Equal(t, http.StatusBadRequest, ProcessRequest(router, request).Code, body)
+	}
+	// urls are validated without the request validator
+	for _, url := range []string{"", "hooks", "/hooks", "ftp://example.com", "https://", "https:///hooks", "http://%zz"} {
+		_, err := handler.Webhooks.Register(Webhook{Url: url, Events: []string{ReceiptProcessedEvent}})
+		assert.EqualError(t, err, "url must be an absolute http or https URL", url)
+	}
+}
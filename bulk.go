@@ -0,0 +1,139 @@
+/*
+bulk.go contains the import and export subcommands, moving receipts in and out
+of a running server in bulk
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"receiptprocessor/api"
+)
+
+// runImport imports receipts from a CSV or NDJSON file, or stdin, printing the
+// errors of the receipts not imported with their line numbers
+// args: the flags and the optional file
+// Returns: the exit code, 1 when a receipt was not imported
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	url := flags.String("url", "http://localhost:8080", "the URL of the receipt processor")
+	format := flags.String("format", "", "csv or ndjson, from the file extension by default")
+	user := flags.String("user", os.Getenv("USER"), "who is importing, recorded in the audit log")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: receiptprocessor import [flags] [file]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	input, name := io.Reader(os.Stdin), "stdin"
+	if flags.NArg() > 0 {
+		name = flags.Arg(0)
+		file, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+	}
+	if *format == "" {
+		*format = api.NDJSONFormat
+		if strings.EqualFold(filepath.Ext(name), ".csv") {
+			*format = api.CSVFormat
+		}
+	}
+	contentType := "application/x-ndjson"
+	if *format == api.CSVFormat {
+		contentType = "text/csv"
+	} else if *format != api.NDJSONFormat {
+		fmt.Fprintf(os.Stderr, "unsupported format %q, expected csv or ndjson\n", *format)
+		return 2
+	}
+
+	request, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(*url, "/")+"/receipts/import", input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	request.Header.Set("Content-Type", contentType)
+	if *user != "" {
+		request.Header.Set("X-Forwarded-User", *user)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(response.Body)
+		fmt.Fprintf(os.Stderr, "%s: %s", response.Status, body)
+		return 1
+	}
+	result := api.PostReceiptsImportResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, importErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "%s:%d: %s\n", name, importErr.Line, importErr.Error)
+	}
+	fmt.Printf("imported %d receipts, %d not imported\n", result.Imported, len(result.Errors))
+	if len(result.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runExport exports every receipt with its points to a file, or stdout
+// args: the flags
+// Returns: the exit code
+func runExport(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	url := flags.String("url", "http://localhost:8080", "the URL of the receipt processor")
+	format := flags.String("format", api.NDJSONFormat, "csv or ndjson")
+	output := flags.String("o", "", "the file to write, stdout by default")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: receiptprocessor export [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	response, err := http.Get(strings.TrimSuffix(*url, "/") + "/receipts/export?format=" + *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(response.Body)
+		fmt.Fprintf(os.Stderr, "%s: %s", response.Status, body)
+		return 1
+	}
+	destination := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer file.Close()
+		destination = file
+	}
+	if _, err := io.Copy(destination, response.Body); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -1,9 +1,26 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"receiptprocessor/api"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "score":
+			os.Exit(runScore(os.Args[2:]))
+		case "serve":
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command %q, expected serve, score, import or export\n", os.Args[1])
+			os.Exit(2)
+		}
+	}
 	api.Serve()
 }
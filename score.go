@@ -0,0 +1,149 @@
+/*
+score.go contains the score subcommand, scoring receipt files locally without a server
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"receiptprocessor/api"
+)
+
+// scored is a scored receipt, printed by the score subcommand
+type scored struct {
+	// Source is the file of the receipt, with its position when the file has several
+	Source string `json:"source"`
+	// Retailer of the receipt
+	Retailer string `json:"retailer"`
+	// Points earned from the receipt
+	Points int `json:"points"`
+	// Breakdown is the result of each rule, when requested
+	Breakdown *api.Breakdown `json:"breakdown,omitempty"`
+}
+
+// runScore scores receipt JSON files, or stdin, with the default rules or a rules
+// file, printing the points as a table or JSON. Receipts in other currencies are
+// converted with the EXCHANGE_RATES_FILE. Member tiers and caps are not applied,
+// as they depend on the member's other receipts.
+// args: the flags and the files
+// Returns: the exit code, 1 when a receipt is invalid
+func runScore(args []string) int {
+	flags := flag.NewFlagSet("score", flag.ContinueOnError)
+	rules := flags.String("rules", os.Getenv("RULES_FILE"), "a json rules file added to the default rules")
+	breakdown := flags.Bool("breakdown", false, "print the points earned from each rule")
+	format := flags.String("format", "table", "table or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: receiptprocessor score [flags] [file ...]")
+		fmt.Fprintln(flags.Output(), "Scores each receipt in the files, or stdin when none are given or the file is -")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unsupported format %q, expected table or json\n", *format)
+		return 2
+	}
+
+	processor := api.NewRuleProcessor()
+	if *rules != "" {
+		config, err := api.LoadRuleConfig(*rules)
+		if err == nil {
+			processor, err = api.NewRuleProcessorFromConfig(config, &api.Database{})
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *rules, err)
+			return 1
+		}
+	}
+
+	rates := api.LoadExchangeRates()
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	code := 0
+	results := []scored{}
+	for _, name := range files {
+		input, source := io.Reader(os.Stdin), "stdin"
+		if name != "-" {
+			file, err := os.Open(name)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				code = 1
+				continue
+			}
+			defer file.Close()
+			input, source = file, name
+		}
+		decoder := json.NewDecoder(input)
+		for position := 1; ; position++ {
+			label := source
+			if position > 1 {
+				label = fmt.Sprintf("%s#%d", source, position)
+			}
+			var value any
+			if err := decoder.Decode(&value); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: invalid JSON: %v\n", label, err)
+				code = 1
+				break
+			}
+			receipt, err := api.DecodeReceipt(value)
+			if err == nil {
+				receipt, err = rates.Record(receipt)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", label, err)
+				code = 1
+				continue
+			}
+			result := processor.Breakdown(receipt)
+			score := scored{Source: label, Retailer: receipt.Retailer, Points: result.Points}
+			if *breakdown {
+				score.Breakdown = &result
+			}
+			results = append(results, score)
+		}
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(results)
+		return code
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "RECEIPT\tRETAILER\tPOINTS")
+	for _, result := range results {
+		fmt.Fprintf(table, "%s\t%s\t%d\n", result.Source, result.Retailer, result.Points)
+		if result.Breakdown == nil {
+			continue
+		}
+		for _, rule := range result.Breakdown.Rules {
+			note := ""
+			switch {
+			case rule.Error != "":
+				note = " (" + rule.Error + ")"
+			case rule.Suppressed:
+				note = " (suppressed)"
+			case rule.Points != rule.Uncapped:
+				note = fmt.Sprintf(" (capped from %d)", rule.Uncapped)
+			}
+			fmt.Fprintf(table, "  %s\t\t%d%s\n", rule.Rule, rule.Points, note)
+		}
+		if len(result.Breakdown.Capped) > 0 {
+			fmt.Fprintf(table, "  receipt cap\t\t%d (capped from %d)\n", result.Points, result.Breakdown.Uncapped)
+		}
+	}
+	table.Flush()
+	return code
+}
@@ -0,0 +1,111 @@
+/*
+score_test.go contains functions for testing the score subcommand
+*/
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Score runs the score subcommand with stdin, returning its exit code, stdout and stderr
+func Score(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Setenv("RULES_FILE", "")
+	t.Setenv("EXCHANGE_RATES_FILE", "")
+	dir := t.TempDir()
+	files := map[string]*os.File{}
+	for _, name := range []string{"stdin", "stdout", "stderr"} {
+		file, err := os.Create(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		defer file.Close()
+		files[name] = file
+	}
+	files["stdin"].WriteString(stdin)
+	files["stdin"].Seek(0, 0)
+	stdinBefore, stdoutBefore, stderrBefore := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = files["stdin"], files["stdout"], files["stderr"]
+	code := runScore(args)
+	os.Stdin, os.Stdout, os.Stderr = stdinBefore, stdoutBefore, stderrBefore
+	stdout, _ := os.ReadFile(files["stdout"].Name())
+	stderr, _ := os.ReadFile(files["stderr"].Name())
+	return code, string(stdout), string(stderr)
+}
+
+// TestScoreTable verifies receipt files are scored as a table, with the points of
+// each rule when requested
+func TestScoreTable(t *testing.T) {
+	code, stdout, stderr := Score(t, "", "examples/simple-receipt.json", "examples/morning-receipt.json")
+	assert.Equal(t, 0, code)
+	assert.Empty(t, stderr)
+	assert.Equal(t, [][]string{
+		{"RECEIPT", "RETAILER", "POINTS"},
+		{"examples/simple-receipt.json", "Target", "31"},
+		{"examples/morning-receipt.json", "Walgreens", "15"},
+	}, fields(stdout))
+
+	code, stdout, _ = Score(t, "", "-breakdown", "examples/simple-receipt.json")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, fields(stdout), []string{"quarter-multiple", "25"})
+	assert.Contains(t, fields(stdout), []string{"retailer-name", "6"})
+
+	code, stdout, _ = Score(t, "", "-rules", "examples/rules.json", "examples/simple-receipt.json")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"examples/simple-receipt.json", "Target", "41"}, fields(stdout)[1])
+}
+
+// TestScoreJSON verifies receipts read from stdin are scored as JSON, labelled by
+// their position in stdin
+func TestScoreJSON(t *testing.T) {
+	simple, err := os.ReadFile("examples/simple-receipt.json")
+	assert.NoError(t, err)
+	morning, err := os.ReadFile("examples/morning-receipt.json")
+	assert.NoError(t, err)
+	code, stdout, stderr := Score(t, string(simple)+string(morning), "-format", "json", "-breakdown", "-")
+	assert.Equal(t, 0, code)
+	assert.Empty(t, stderr)
+	results := []scored{}
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &results))
+	assert.Len(t, results, 2)
+	assert.Equal(t, "stdin", results[0].Source)
+	assert.Equal(t, 31, results[0].Points)
+	assert.Equal(t, 31, results[0].Breakdown.Points)
+	assert.Equal(t, "stdin#2", results[1].Source)
+	assert.Equal(t, "Walgreens", results[1].Retailer)
+	assert.Equal(t, 15, results[1].Points)
+}
+
+// TestScoreInvalid verifies invalid receipts and missing files are reported, exiting
+// 1 after scoring the valid receipts
+func TestScoreInvalid(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	assert.NoError(t, os.WriteFile(invalid, []byte(`{"retailer": "Target", "purchaseDate": "2022-01-02", "purchaseTime": "13:13", "total": "1.25", "items": []}`), 0o644))
+	code, stdout, stderr := Score(t, "", invalid, "examples/simple-receipt.json", "missing.json")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, invalid+": Invalid items")
+	assert.Contains(t, stderr, "missing.json")
+	assert.Equal(t, [][]string{
+		{"RECEIPT", "RETAILER", "POINTS"},
+		{"examples/simple-receipt.json", "Target", "31"},
+	}, fields(stdout))
+
+	code, _, stderr = Score(t, "{", "-format", "json")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "stdin: invalid JSON")
+
+	code, _, _ = Score(t, "", "-format", "xml", "examples/simple-receipt.json")
+	assert.Equal(t, 2, code)
+}
+
+// fields splits the lines of a table into their fields
+func fields(table string) [][]string {
+	rows := [][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(table), "\n") {
+		rows = append(rows, strings.Fields(line))
+	}
+	return rows
+}